@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+)
+
+type accountSeriesFlags struct {
+	years      string // range of years (e.g., 2012-2023)
+	format     string // output format of the report
+	scriptMode bool   // selects the company with the closest name
+	unit       string // display unit of the monetary values
+}
+
+// accountSeriesCmd represents the conta command
+var accountSeriesCmd = &cobra.Command{
+	Use:   "conta EMPRESA CONTA",
+	Args:  cobra.ExactArgs(2),
+	Short: "Mostra a série histórica de uma conta dos demonstrativos anuais",
+	Long: `Mostra a série histórica de uma conta dos demonstrativos anuais (DFP) de uma
+empresa e das suas subcontas, com a variação anual e o CAGR.
+
+A CONTA pode ser o código da CVM (ex.: 3.01) ou o nome de uma conta do
+mapeamento de contas (ex.: Vendas, LucLiq, EBIT). Veja o comando 'contas'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := accountSeries(args[0], args[1], flags.accountSeries); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s conta WEG 3.01 --years 2012-2023", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(accountSeriesCmd)
+	f := accountSeriesCmd.Flags()
+	f.StringVarP(&flags.accountSeries.years, Fyears,
+		"y", "", "intervalo de anos (ex.: 2012-2023)")
+	f.StringVarP(&flags.accountSeries.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|csv")
+	f.StringVarP(&flags.accountSeries.unit, Funit,
+		"u", "mil", "unidade dos valores: unidade|mil|milhoes")
+	f.BoolVarP(&flags.accountSeries.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe a empresa com nome mais próximo)")
+}
+
+func accountSeries(company, account string, f accountSeriesFlags) error {
+	begin, end, err := rapina.YearRange(f.years)
+	if err != nil {
+		return err
+	}
+	unit, err := reports.ParseUnit(f.unit)
+	if err != nil {
+		return err
+	}
+
+	company = SelectCompany(company, f.scriptMode)
+	if company == "" {
+		return fmt.Errorf("empresa não encontrada")
+	}
+	company = strings.Split(company, "@#")[0] // remove ticker
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	r, err := reports.New(map[string]interface{}{"db": db, "dataDir": dataDir})
+	if err != nil {
+		return err
+	}
+
+	series, err := r.AccountSeries(company, account, begin, end, unit)
+	if err != nil {
+		return err
+	}
+
+	reports.PrintAccountSeries(series, f.format)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type accountsFlags struct {
+	company string // filter by company name
+	apply   bool   // update the accounts codes on the DB
+}
+
+// accountsCmd represents the contas command
+var accountsCmd = &cobra.Command{
+	Use:     "contas",
+	Aliases: []string{"accounts"},
+	Args:    cobra.NoArgs,
+	Short:   "Mostra as contas encontradas por cada regra do mapeamento de contas",
+	Long: fmt.Sprintf(`Mostra as contas dos demonstrativos anuais encontradas por cada regra do
+mapeamento de contas.
+
+O mapeamento padrão pode ser estendido ou alterado, por empresa ou por setor,
+no arquivo %s (YAML ou JSON), por exemplo:
+
+  Contas:
+    - {codigo: Vendas, conta: "3.01", descricao: "Receita*"}
+  Empresas:
+    - Nome: BANCO DO BRASIL S.A.
+      Contas:
+        - {codigo: Vendas, conta: "3.01"}
+  Setores:
+    - Nome: Seguradoras
+      Contas:
+        - {codigo: Vendas, conta: "3.01", descricao: "Prêmios*"}
+
+Após alterar o arquivo, use --aplicar para atualizar as contas já
+armazenadas no banco de dados.
+
+Códigos disponíveis: %s`, accountsFile, strings.Join(parsers.AccountNames(), ", ")),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := accounts(flags.accounts.company, flags.accounts.apply); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s contas -e petrobras -v", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(accountsCmd)
+	accountsCmd.Flags().StringVarP(&flags.accounts.company, Fcompany,
+		"e", "", "mostra apenas as contas das empresas com este nome")
+	accountsCmd.Flags().BoolVarP(&flags.accounts.apply, Fapply,
+		"a", false, "atualiza as contas armazenadas no banco de dados")
+}
+
+// accountsMapping loads the accounts mapping file set on the config
+// ('contas') or the default one, with the sectors stored on 'db'.
+func accountsMapping(db *sql.DB) (*parsers.AccountsMapping, error) {
+	file := viper.GetString("contas")
+	if file == "" {
+		file = accountsFile
+	}
+	return parsers.LoadAccountsMapping(file, db)
+}
+
+func accounts(company string, apply bool) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	m, err := accountsMapping(db)
+	if err != nil {
+		return err
+	}
+
+	if apply {
+		n, err := parsers.RemapAccounts(db, m)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[√] %d contas atualizadas\n", n)
+	}
+
+	return reports.AccountRules(db, m, strings.ToUpper(company), flags.verbose)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+)
+
+type alertsFlags struct {
+	years      string // range of years (e.g., 2012-2023)
+	severity   string // minimum severity
+	format     string // output format of the report
+	scriptMode bool   // selects the company with the closest name
+}
+
+// alertsCmd represents the alertas command
+var alertsCmd = &cobra.Command{
+	Use:     "alertas [EMPRESA]",
+	Aliases: []string{"alerts"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Procura sinais de alerta na qualidade dos lucros",
+	Long: `Procura sinais de alerta nos demonstrativos das empresas, com a severidade
+(baixa, média ou alta) e a explicação de cada um:
+
+  lucro-fco      lucro líquido não acompanhado pelo fluxo de caixa operacional
+  recebiveis     contas a receber crescendo muito mais que as vendas
+  estoques       aumento do prazo médio de estocagem
+  pl-negativo    patrimônio líquido negativo
+  proventos-fco  proventos acima do fluxo de caixa operacional
+  outros         contas "outros" grandes em relação ao ativo ou à receita
+
+Sem EMPRESA, verifica todas as empresas do banco de dados, por padrão apenas
+no último ano. Com EMPRESA, verifica todos os anos disponíveis.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		company := ""
+		if len(args) > 0 {
+			company = args[0]
+		}
+		if err := alerts(company, flags.alerts); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s alertas -m alta\n  %s alertas WEG --years 2015-2023",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(alertsCmd)
+	f := alertsCmd.Flags()
+	f.StringVarP(&flags.alerts.years, Fyears,
+		"y", "", "intervalo de anos (ex.: 2012-2023)")
+	f.StringVarP(&flags.alerts.severity, FminSeverity,
+		"m", "baixa", "severidade mínima: baixa|media|alta")
+	f.StringVarP(&flags.alerts.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|csv")
+	f.BoolVarP(&flags.alerts.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe a empresa com nome mais próximo)")
+}
+
+func alerts(company string, f alertsFlags) error {
+	begin, end, err := rapina.YearRange(f.years)
+	if err != nil {
+		return err
+	}
+	severity, err := reports.ParseSeverity(f.severity)
+	if err != nil {
+		return err
+	}
+
+	if company != "" {
+		company = SelectCompany(company, f.scriptMode)
+		if company == "" {
+			return fmt.Errorf("empresa não encontrada")
+		}
+		company = strings.Split(company, "@#")[0] // remove ticker
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	r, err := reports.New(map[string]interface{}{"db": db, "dataDir": dataDir})
+	if err != nil {
+		return err
+	}
+
+	// Database-wide scan: last year only, unless set
+	if company == "" && f.years == "" {
+		if _, end, err = reports.TimeRange(db); err != nil {
+			return err
+		}
+		begin = end
+	}
+
+	list, err := r.Alerts(company, begin, end, severity)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("[ ] Nenhum alerta encontrado")
+		return nil
+	}
+
+	reports.PrintAlerts(list, f.format)
+
+	return nil
+}
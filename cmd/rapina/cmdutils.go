@@ -7,13 +7,18 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/dude333/rapina/parsers"
+	"github.com/dude333/rapina/reports"
 	"github.com/manifoldco/promptui"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 )
 
 // Directory where the DB and downloaded files are stored
 const dataDir = ".data"
 const yamlFile = "./setores.yml"
+const accountsFile = "./contas.yml"
+const ipcaFile = "./ipca.csv"
 
 // Parms holds the input parameters
 type Parms struct {
@@ -25,10 +30,46 @@ type Parms struct {
 	Format string
 	// OutputDir: path of the output xlsx
 	OutputDir string
-	// YamlFile: file with the companies' sectors
-	YamlFile string
 	// Reports is a map with the reports and reports items to be printed
 	Reports map[string]bool
+	// Unit used to display the monetary values
+	Unit reports.Unit
+	// Inflation used to report the values in constant currency of BaseYear
+	// (nil for nominal values)
+	Inflation parsers.Inflation
+	BaseYear  int
+	// Valuation parameters, if the valuation sheet is to be created
+	Valuation *reports.ValuationParms
+	// Peers is the peer group used instead of the B3 segment, if set
+	Peers string
+	// Template is the file with the metrics to be printed, if set
+	Template string
+}
+
+//
+// loadInflation loads the IPCA rates from the file set on the config ('ipca')
+// or from the default one, if 'realValues' is set. Returns the base year, which
+// defaults to the last year with known IPCA.
+//
+func loadInflation(realValues bool, baseYear int) (parsers.Inflation, int, error) {
+	if !realValues {
+		return nil, 0, nil
+	}
+
+	file := viper.GetString("ipca")
+	if file == "" {
+		file = ipcaFile
+	}
+	infl, err := parsers.LoadInflation(file)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if baseYear == 0 {
+		baseYear = infl.LastYear()
+	}
+
+	return infl, baseYear, nil
 }
 
 //
@@ -80,6 +121,13 @@ func promptUser(list []string, label string) (result string) {
 //
 // filename cleans up the filename and returns the path/filename
 func filename(path, name string) (fpath string, err error) {
+	return filenameExt(path, name, "xlsx")
+}
+
+//
+// filenameExt cleans up the filename and returns the path/filename with
+// the extension 'ext'
+func filenameExt(path, name, ext string) (fpath string, err error) {
 	clean := func(r rune) rune {
 		switch r {
 		case ' ', ',', '/', '\\':
@@ -90,7 +138,7 @@ func filename(path, name string) (fpath string, err error) {
 	path = strings.TrimSuffix(path, "/")
 	name = strings.TrimSuffix(name, ".")
 	name = strings.Map(clean, name)
-	fpath = filepath.FromSlash(path + "/" + name + ".xlsx")
+	fpath = filepath.FromSlash(path + "/" + name + "." + ext)
 
 	const max = 50
 	var x int
@@ -98,7 +146,7 @@ func filename(path, name string) (fpath string, err error) {
 		_, err = os.Stat(fpath)
 		if err == nil {
 			// File exists, try again with another name
-			fpath = fmt.Sprintf("%s/%s(%d).xlsx", path, name, x)
+			fpath = fmt.Sprintf("%s/%s(%d).%s", path, name, x, ext)
 		} else if os.IsNotExist(err) {
 			err = nil // reset error
 			break
@@ -109,7 +157,7 @@ func filename(path, name string) (fpath string, err error) {
 	}
 
 	if x > max {
-		err = fmt.Errorf("remova o arquivo %s/%s.xlsx antes de continuar", path, name)
+		err = fmt.Errorf("remova o arquivo %s/%s.%s antes de continuar", path, name, ext)
 		return
 	}
 
@@ -0,0 +1,133 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+)
+
+type compareFlags struct {
+	years      int    // number of years, up to the last one on the DB
+	format     string // output format: xlsx, stdout or both
+	outputDir  string // directory of the xlsx file
+	unit       string // display unit of the monetary values
+	realValues bool   // values in constant currency (IPCA)
+	baseYear   int    // base year of the constant currency
+	all        bool   // shows all the metrics
+	extra      bool   // extra ratios
+	fleuriet   bool   // working capital (Fleuriet model)
+	quality    bool   // DuPont, Piotroski and Altman
+	shares     bool   // number of shares and free float
+	charts     bool   // normalized charts
+	scriptMode bool   // selects the companies with the closest names
+}
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:     "compare EMPRESA EMPRESA...",
+	Aliases: []string{"compara"},
+	Args:    cobra.MinimumNArgs(2),
+	Short:   "Compara os indicadores de várias empresas",
+	Long: `Compara os indicadores de várias empresas lado a lado, nos mesmos anos, em
+uma planilha (aba COMPARATIVO) e/ou em uma tabela no terminal.
+
+As empresas são identificadas pelo nome, CNPJ ou código de negociação. Com
+--graficos, a planilha inclui a aba GRAFICOS com os valores monetários de
+cada empresa normalizados para 100 no primeiro ano (base 100).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := compare(args, flags.compare); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s compare WEGE3 EMBR3 RAPT4 --years 5 --graficos\n  %s compare ITUB4 BBDC4 -r stdout",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	f := compareCmd.Flags()
+	f.IntVarP(&flags.compare.years, Fyears,
+		"y", 5, "número de anos, até o último disponível (0: todos)")
+	f.StringVarP(&flags.compare.format, Fformat,
+		"r", "xlsx", "formato do relatório: xlsx|stdout|ambos")
+	f.StringVarP(&flags.compare.outputDir, FoutputDir,
+		"d", "reports", "diretório onde a planilha será salva")
+	f.StringVarP(&flags.compare.unit, Funit,
+		"u", "mil", "unidade dos valores: unidade|mil|milhoes")
+	f.BoolVar(&flags.compare.realValues, Freal, false, "valores reais, corrigidos pelo IPCA")
+	f.IntVar(&flags.compare.baseYear, FbaseYear, 0, "ano base dos valores reais (padrão: último ano com IPCA)")
+	f.BoolVarP(&flags.compare.all, "all", "a", false, "mostra todos os indicadores")
+	f.BoolVarP(&flags.compare.extra, "extraRatios", "x", false, "índices extras")
+	f.BoolVarP(&flags.compare.fleuriet, "fleuriet", "F", false, "capital de giro no modelo Fleuriet")
+	f.BoolVarP(&flags.compare.quality, "quality", "q", false, "indicadores de qualidade (DuPont, Piotroski e Altman)")
+	f.BoolVarP(&flags.compare.shares, "showShares", "f", false, "mostra o número de ações e free float")
+	f.BoolVarP(&flags.compare.charts, Fcharts, "g", false, "cria gráficos normalizados (base 100)")
+	f.BoolVarP(&flags.compare.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe as empresas com nome mais próximo)")
+}
+
+func compare(list []string, f compareFlags) error {
+	switch f.format {
+	case "xlsx", "stdout", "ambos":
+	default:
+		return fmt.Errorf("formato inválido: %s (opções: xlsx|stdout|ambos)", f.format)
+	}
+	u, err := reports.ParseUnit(f.unit)
+	if err != nil {
+		return err
+	}
+	infl, base, err := loadInflation(f.realValues, f.baseYear)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	companies, err := selectCompanies(db, list, f.scriptMode)
+	if err != nil {
+		return err
+	}
+
+	file, err := filename(f.outputDir, "comparativo")
+	if err != nil {
+		return err
+	}
+
+	parms := map[string]interface{}{
+		"db":       db,
+		"dataDir":  dataDir,
+		"filename": file,
+		"reports": map[string]bool{
+			"ExtraRatios": f.extra || f.all,
+			"ShowShares":  f.shares || f.all,
+			"Fleuriet":    f.fleuriet || f.all,
+			"Quality":     f.quality || f.all,
+		},
+		"unit":      u,
+		"inflation": infl,
+		"baseYear":  base,
+	}
+
+	if f.format != "xlsx" {
+		if err := reports.CompareToStdout(parms, companies, f.years); err != nil {
+			return err
+		}
+	}
+	if f.format != "stdout" {
+		return reports.CompareToXlsx(parms, companies, f.years, f.charts)
+	}
+
+	return nil
+}
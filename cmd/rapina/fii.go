@@ -8,14 +8,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/dude333/rapina/reports"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 type fiiFlags struct {
-	num       int // number of months since current
+	num       int    // number of months since current
+	segment   string // FII segment (e.g. logística, lajes, papel)
 	dividends fiiDividendsFlags
 	monthly   fiiMonthlyFlags
+	list      fiiListFlags
+	ranking   fiiRankingFlags
 }
 
 // fiiCmd represents the fii command
@@ -35,4 +41,34 @@ func init() {
 	rootCmd.AddCommand(fiiCmd)
 	fiiCmd.PersistentFlags().IntVarP(&flags.fii.num,
 		Fnum, "n", 1, "número de meses desde o último disponível")
+	fiiCmd.PersistentFlags().StringVarP(&flags.fii.segment,
+		Fsegment, "s", "", "segmento dos FIIs (logística, lajes, shoppings, papel, híbrido...)")
+}
+
+// fiiArgs requires at least one FII code, unless the segment is set.
+func fiiArgs(cmd *cobra.Command, args []string) error {
+	if flags.fii.segment != "" {
+		return nil
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// fiiCodes returns 'codes' in upper case, plus the codes of all FIIs from the
+// segment set in parms, if any.
+func fiiCodes(r *reports.FIITerminal, parms map[string]string, codes []string) ([]string, error) {
+	for i := 0; i < len(codes); i++ {
+		codes[i] = strings.ToUpper(codes[i])
+	}
+
+	segment, ok := parms[Fsegment]
+	if !ok || segment == "" {
+		return codes, nil
+	}
+
+	s, err := r.SegmentCodes(segment)
+	if err != nil {
+		return nil, errors.Wrap(err, "rode 'rapina fii list' para atualizar a lista de FIIs")
+	}
+
+	return append(codes, s...), nil
 }
@@ -9,7 +9,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/dude333/rapina/reports"
 	"github.com/spf13/cobra"
@@ -24,7 +23,7 @@ type fiiDividendsFlags struct {
 var fiiDividendsCmd = &cobra.Command{
 	Use:     "rendimentos",
 	Aliases: []string{"rend", "dividendos", "dividends", "div"},
-	Args:    cobra.MinimumNArgs(1),
+	Args:    fiiArgs,
 	Short:   "Lista os rendimentos de um FII",
 	Long:    `Lista os rendimentos de um Fundos de Investiment Imobiliários (FII).`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -41,6 +40,8 @@ var fiiDividendsCmd = &cobra.Command{
 		}
 		// Report format
 		parms[Fformat] = flags.fii.dividends.format
+		// Segment
+		parms[Fsegment] = flags.fii.segment
 
 		if err := FIIDividends(parms, args, n); err != nil {
 			log.Println(err)
@@ -61,10 +62,6 @@ func init() {
 // FIIDividends prints the dividends from 'code' for 'n' months,
 // starting from latest.
 func FIIDividends(parms map[string]string, codes []string, n int) error {
-	for i := 0; i < len(codes); i++ {
-		codes[i] = strings.ToUpper(codes[i])
-	}
-
 	db, err := openDatabase()
 	if err != nil {
 		return err
@@ -82,6 +79,11 @@ func FIIDividends(parms map[string]string, codes []string, n int) error {
 
 	r.SetParms(parms)
 
+	codes, err = fiiCodes(r, parms, codes)
+	if err != nil {
+		return err
+	}
+
 	err = r.Dividends(codes, n)
 	if err != nil {
 		return err
@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type fiiListFlags struct {
+	format string // output format of the report
+	update bool   // download the list from B3
+}
+
+// fiiListCmd represents the list command
+var fiiListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"lista"},
+	Args:    cobra.NoArgs,
+	Short:   "Lista os FIIs listados na B3 e seus segmentos",
+	Long: `Lista os Fundos de Investimento Imobiliários (FII) listados na B3, com
+código, nome, CNPJ, administrador e segmento (logística, lajes, shoppings,
+papel, híbrido, fundo de fundos, residencial, hospitalar, hotel, agências,
+desenvolvimento ou outros).
+
+Na primeira vez (ou com --update), a lista é baixada da B3 e armazenada no
+banco de dados local. Os demais comandos 'fii' aceitam --segment para gerar
+o relatório de todos os FIIs de um segmento.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		parms := make(map[string]string)
+		// Verbose
+		if flags.verbose {
+			parms[Fverbose] = "true"
+		}
+		// Report format
+		parms[Fformat] = flags.fii.list.format
+
+		if err := FIIList(parms, flags.fii.segment, flags.fii.list.update); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s fii list --segment logística", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	fiiCmd.AddCommand(fiiListCmd)
+	fiiListCmd.Flags().StringVarP(&flags.fii.list.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|csv")
+	fiiListCmd.Flags().BoolVarP(&flags.fii.list.update, Fupdate,
+		"u", false, "baixa a lista atualizada da B3")
+}
+
+// FIIList prints the FIIs stored on the DB, filtered by 'segment'. The list
+// is downloaded from B3 if 'update' is set or if the DB list is empty.
+func FIIList(parms map[string]string, segment string, update bool) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	opts := reports.FIITerminalOptions{
+		APIKey:  viper.GetString("apikey"),
+		DataDir: dataDir,
+	}
+
+	r, err := reports.NewFIITerminal(db, opts)
+	if err != nil {
+		return err
+	}
+
+	r.SetParms(parms)
+
+	if !update {
+		if _, err := r.SegmentCodes(""); err != nil {
+			update = true // empty list
+		}
+	}
+	if update {
+		if err := r.UpdateList(); err != nil {
+			return err
+		}
+	}
+
+	return r.List(segment)
+}
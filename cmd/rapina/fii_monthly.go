@@ -5,8 +5,10 @@ Distributed under the MIT License.
 package main
 
 import (
+	"fmt"
 	"log"
-	"strings"
+	"os"
+	"path/filepath"
 
 	"github.com/dude333/rapina/reports"
 	"github.com/spf13/cobra"
@@ -17,17 +19,21 @@ type fiiMonthlyFlags struct {
 	format string // output format of the report
 }
 
-// fiiMonthlyCmd represents the rendimentos command
+// fiiMonthlyCmd represents the mensal command
 var fiiMonthlyCmd = &cobra.Command{
-	Hidden:  true,
 	Use:     "mensal",
-	Aliases: []string{"monthly"},
-	Args:    cobra.MinimumNArgs(1),
-	Short:   "Lista os informes mensais de um FII",
-	Long:    `Lista os informes mensais de um Fundos de Investiment Imobiliários (FII).`,
+	Aliases: []string{"monthly", "pvp"},
+	Args:    fiiArgs,
+	Short:   "Lista o valor patrimonial e o P/VP de um FII",
+	Long: `Lista o valor patrimonial por cota (VP) dos informes mensais de um Fundo de
+Investimento Imobiliário (FII), junto com a cotação no último dia útil do mês,
+o P/VP, o ágio/deságio e a variação mensal do VP.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Number of reports
 		n := flags.fii.num
+		if n <= 0 {
+			n = 1
+		}
 
 		parms := make(map[string]string)
 		// Verbose
@@ -36,27 +42,28 @@ var fiiMonthlyCmd = &cobra.Command{
 		}
 		// Report format
 		parms[Fformat] = flags.fii.monthly.format
+		// Segment
+		parms[Fsegment] = flags.fii.segment
 
 		if err := FIIMonthly(parms, args, n); err != nil {
 			log.Println(err)
 		}
 
 	},
+	Example: func() string {
+		return fmt.Sprintf("%s fii mensal KNIP11 KNCR11 HGLG11 -n 12", filepath.Base(os.Args[0]))
+	}(),
 }
 
 func init() {
 	fiiCmd.AddCommand(fiiMonthlyCmd)
 	fiiMonthlyCmd.Flags().StringVarP(&flags.fii.monthly.format, Fformat,
-		"f", "tabela", "formato do relatório: tabela|csv|csvrend")
+		"f", "tabela", "formato do relatório: tabela|csv")
 }
 
 // FIIMonthly prints the monthly reports from 'code' for 'n' months,
 // starting from latest.
 func FIIMonthly(parms map[string]string, codes []string, n int) error {
-	for i := 0; i < len(codes); i++ {
-		codes[i] = strings.ToUpper(codes[i])
-	}
-
 	db, err := openDatabase()
 	if err != nil {
 		return err
@@ -74,6 +81,11 @@ func FIIMonthly(parms map[string]string, codes []string, n int) error {
 
 	r.SetParms(parms)
 
+	codes, err = fiiCodes(r, parms, codes)
+	if err != nil {
+		return err
+	}
+
 	err = r.Monthly(codes, n)
 	if err != nil {
 		return err
@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type fiiRankingFlags struct {
+	format    string // output format of the report
+	order     string // ranking order
+	minYield  float64
+	maxPVP    float64
+	maxCV     float64
+	minVolume float64
+}
+
+// fiiRankingCmd represents the ranking command
+var fiiRankingCmd = &cobra.Command{
+	Use:     "ranking",
+	Aliases: []string{"rank"},
+	Args:    fiiArgs,
+	Short:   "Classifica FIIs por DY, consistência, P/VP e liquidez",
+	Long: `Classifica os Fundos de Investimento Imobiliários (FII) informados (ou os
+do segmento escolhido com --segment) por:
+
+  dy            rendimentos dos últimos 12 meses / cotação atual
+  consistencia  desvio padrão dos rendimentos mensais em relação à média
+  pvp           cotação atual / valor patrimonial por cota do último informe
+  liquidez      volume financeiro médio diário dos últimos 21 pregões`,
+	Run: func(cmd *cobra.Command, args []string) {
+		parms := make(map[string]string)
+		// Verbose
+		if flags.verbose {
+			parms[Fverbose] = "true"
+		}
+		// Report format
+		parms[Fformat] = flags.fii.ranking.format
+		// Segment
+		parms[Fsegment] = flags.fii.segment
+
+		filter := reports.FIIRankFilter{
+			MinYield:  flags.fii.ranking.minYield,
+			MaxPVP:    flags.fii.ranking.maxPVP,
+			MaxCV:     flags.fii.ranking.maxCV,
+			MinVolume: flags.fii.ranking.minVolume,
+			OrderBy:   flags.fii.ranking.order,
+		}
+
+		if err := FIIRanking(parms, args, filter); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s fii ranking --segment papel --order pvp --max-pvp 1.05", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	fiiCmd.AddCommand(fiiRankingCmd)
+	f := fiiRankingCmd.Flags()
+	f.StringVarP(&flags.fii.ranking.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|csv")
+	f.StringVarP(&flags.fii.ranking.order, Forder,
+		"o", reports.OrderYield, "ordem: dy|consistencia|pvp|liquidez")
+	f.Float64Var(&flags.fii.ranking.minYield, FminYield, 0, "DY 12 meses mínimo (%)")
+	f.Float64Var(&flags.fii.ranking.maxPVP, FmaxPVP, 0, "P/VP máximo")
+	f.Float64Var(&flags.fii.ranking.maxCV, FmaxCV, 0, "desvio padrão máximo dos rendimentos mensais (% da média)")
+	f.Float64Var(&flags.fii.ranking.minVolume, FminVolume, 0, "volume médio diário mínimo (R$)")
+}
+
+// FIIRanking prints the ranking of the FII 'codes'.
+func FIIRanking(parms map[string]string, codes []string, filter reports.FIIRankFilter) error {
+	switch filter.OrderBy {
+	case reports.OrderYield, reports.OrderConsistency, reports.OrderPVP, reports.OrderVolume:
+	default:
+		return fmt.Errorf("ordem inválida: %s", filter.OrderBy)
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	opts := reports.FIITerminalOptions{
+		APIKey:  viper.GetString("apikey"),
+		DataDir: dataDir,
+	}
+
+	r, err := reports.NewFIITerminal(db, opts)
+	if err != nil {
+		return err
+	}
+
+	r.SetParms(parms)
+
+	codes, err = fiiCodes(r, parms, codes)
+	if err != nil {
+		return err
+	}
+
+	return r.Ranking(codes, filter)
+}
@@ -6,8 +6,62 @@ const (
 	Fverbose = "verbose"
 
 	// fiiCmd persistent
-	Fnum = "num"
+	Fnum     = "num"
+	Fsegment = "segment"
 
 	// fiiDividendsCmd
 	Fformat = "format"
+
+	// fiiListCmd
+	Fupdate = "update"
+
+	// fiiRankingCmd
+	Forder     = "order"
+	FminYield  = "min-dy"
+	FmaxPVP    = "max-pvp"
+	FmaxCV     = "max-desvio"
+	FminVolume = "min-liquidez"
+
+	// accountsCmd
+	Fcompany = "empresa"
+	Fapply   = "aplicar"
+
+	// accountSeriesCmd, alertsCmd
+	Fyears = "years"
+
+	// alertsCmd
+	FminSeverity = "severidade"
+
+	// reportCmd, accountSeriesCmd, listCmd
+	Funit = "unidade"
+
+	// reportCmd, listCmd
+	Freal     = "real"
+	FbaseYear = "base-year"
+
+	// reportCmd
+	Fpeers    = "peers"
+	Fbatch    = "batch"
+	Fsector   = "sector"
+	Fworkers  = "workers"
+	Fcombined = "combined"
+	Ftemplate = "template"
+
+	// compareCmd
+	Fcharts = "graficos"
+
+	// valuationCmd
+	Fgrowth         = "crescimento"
+	Fdiscount       = "desconto"
+	FterminalGrowth = "perpetuidade"
+	FprojYears      = "anos"
+	FbazinYield     = "dy-bazin"
+
+	// sectorsCmd
+	Fimport = "importar"
+	Fexport = "exportar"
+	Ffile   = "arquivo"
+
+	// portfolioCmd
+	FoutputDir = "outputDir"
 )
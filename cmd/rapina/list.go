@@ -22,6 +22,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/dude333/rapina/reports"
 	"github.com/pkg/errors"
@@ -39,6 +40,12 @@ func init() {
 		listCompanies bool
 		sector        string
 		netProfitRate float32
+		unit          string
+		realValues    bool
+		baseYear      int
+		formula       string
+		year          int
+		top           int
 	)
 
 	rootCmd.AddCommand(listCmd)
@@ -46,6 +53,12 @@ func init() {
 	listCmd.Flags().BoolVarP(&listCompanies, "empresas", "e", false, "Lista todas as empresas disponíveis")
 	listCmd.Flags().StringVarP(&sector, "setor", "s", "", "Lista todas as empresas do mesmo setor")
 	listCmd.Flags().Float32VarP(&netProfitRate, "lucroLiquido", "l", -0.8, "Lista empresas com lucros lucros positivos e com a taxa de crescimento definida")
+	listCmd.Flags().StringVarP(&unit, Funit, "u", "mil", "Unidade dos lucros: unidade|mil|milhoes")
+	listCmd.Flags().BoolVar(&realValues, Freal, false, "Lucros reais, corrigidos pelo IPCA")
+	listCmd.Flags().IntVar(&baseYear, FbaseYear, 0, "Ano base dos lucros reais (padrão: último ano com IPCA)")
+	listCmd.Flags().StringVarP(&formula, "indicador", "i", "", "Lista empresas ordenadas por um indicador do arquivo de configuração ou fórmula (ex.: \"LucLiq / Vendas\")")
+	listCmd.Flags().IntVar(&year, "ano", 0, "Ano do indicador (padrão: último ano)")
+	listCmd.Flags().IntVar(&top, "top", 0, "Lista apenas as primeiras empresas do indicador")
 
 	listCmd.Run = func(cmd *cobra.Command, args []string) {
 		var err error
@@ -58,9 +71,11 @@ func init() {
 		if listCompanies {
 			err = ListCompanies()
 		} else if sector != "" {
-			err = ListSector(sector, yamlFile)
+			err = ListSector(sector)
 		} else if listCmd.Flags().Changed("lucroLiquido") {
-			err = ListCompaniesProfits(netProfitRate)
+			err = ListCompaniesProfits(netProfitRate, unit, realValues, baseYear)
+		} else if formula != "" {
+			err = ListScreen(formula, year, top, unit)
 		}
 		if err != nil {
 			fmt.Println("[x]", err)
@@ -92,13 +107,19 @@ func ListCompanies() (err error) {
 //
 // ListSector shows all companies from the same sector as 'company'
 //
-func ListSector(company, yamlFile string) (err error) {
+func ListSector(company string) (err error) {
+	company = strings.Split(SelectCompany(company, true), "@#")[0]
+	if company == "" {
+		return fmt.Errorf("empresa não encontrada")
+	}
+
 	db, err := openDatabase()
 	if err != nil {
 		return errors.Wrap(err, "fail to open db")
 	}
+	checkSectors(db)
 
-	err = reports.ListSector(db, company, yamlFile)
+	err = reports.ListSector(db, company)
 	if err != nil {
 		return errors.Wrap(err, "erro ao listar empresas")
 	}
@@ -109,16 +130,53 @@ func ListSector(company, yamlFile string) (err error) {
 //
 // ListCompaniesProfits lists companies profits
 //
-func ListCompaniesProfits(rate float32) (err error) {
+func ListCompaniesProfits(rate float32, unit string, realValues bool, baseYear int) (err error) {
+	u, err := reports.ParseUnit(unit)
+	if err != nil {
+		return err
+	}
+	infl, base, err := loadInflation(realValues, baseYear)
+	if err != nil {
+		return err
+	}
+
 	db, err := openDatabase()
 	if err != nil {
 		return errors.Wrap(err, "fail to open db")
 	}
 
-	err = reports.ListCompaniesProfits(db, rate)
+	err = reports.ListCompaniesProfits(db, rate, u, infl, base)
 	if err != nil {
 		return errors.Wrap(err, "erro ao listar lucros")
 	}
 
 	return
 }
+
+//
+// ListScreen lists the companies ranked by a custom metric or formula
+//
+func ListScreen(formula string, year, top int, unit string) (err error) {
+	u, err := reports.ParseUnit(unit)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return errors.Wrap(err, "fail to open db")
+	}
+
+	r, err := reports.New(map[string]interface{}{"db": db, "dataDir": dataDir})
+	if err != nil {
+		return err
+	}
+
+	s, err := r.Screen(formula, year, u)
+	if err != nil {
+		return err
+	}
+	reports.PrintScreen(s, top, "tabela")
+
+	return
+}
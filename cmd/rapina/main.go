@@ -26,15 +26,25 @@ import (
 	"os/signal"
 
 	"github.com/dude333/rapina/progress"
+	"github.com/dude333/rapina/reports"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var flags = struct {
-	verbose bool
-	fii     fiiFlags
-	server  serverFlags
+	verbose       bool
+	fii           fiiFlags
+	server        serverFlags
+	portfolio     portfolioFlags
+	accounts      accountsFlags
+	accountSeries accountSeriesFlags
+	restatements  restatementsFlags
+	valuation     valuationFlags
+	alerts        alertsFlags
+	sectors       sectorsFlags
+	peers         peersFlags
+	compare       compareFlags
 }{}
 
 var cfgFile string
@@ -127,6 +137,14 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintf(os.Stderr, "[INFO]  Usando arquivo de configuração %s\n\n", viper.ConfigFileUsed())
 	}
+
+	// Custom metrics
+	var formulas []reports.Formula
+	if err := viper.UnmarshalKey("indicadores", &formulas); err != nil {
+		fmt.Fprintf(os.Stderr, "[x] Indicadores do arquivo de configuração: %v\n", err)
+	} else if err := reports.SetFormulas(formulas); err != nil {
+		fmt.Fprintf(os.Stderr, "[x] Indicadores do arquivo de configuração: %v\n", err)
+	}
 }
 
 var (
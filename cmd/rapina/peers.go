@@ -0,0 +1,230 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type peersFlags struct {
+	scriptMode bool // selects the company with the closest name
+}
+
+// peersCmd represents the grupo command
+var peersCmd = &cobra.Command{
+	Use:     "grupo",
+	Aliases: []string{"group"},
+	Short:   "Grupos de empresas para comparação (pares)",
+	Long: `Grupos de empresas definidos pelo usuário (ex.: "bancos médios", "elétricas
+transmissoras"), usados no lugar do segmento da B3 na aba SETOR e na média
+setorial do relatório (report --peers NOME).
+
+Os grupos são armazenados no banco de dados (grupo add) ou definidos na chave
+'grupos' do arquivo de configuração, com as empresas identificadas pelo nome,
+CNPJ ou código de negociação:
+
+  grupos:
+    bancos médios:
+      - BANCO ABC BRASIL S.A.
+      - BPAN4
+
+Os grupos do arquivo de configuração têm prioridade sobre os do banco de dados.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s grupo add \"bancos médios\" ABC PAN BMG\n  %s report PAN --peers \"bancos médios\"",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	}(),
+}
+
+var peersAddCmd = &cobra.Command{
+	Use:     "add NOME EMPRESA...",
+	Aliases: []string{"adiciona"},
+	Args:    cobra.MinimumNArgs(2),
+	Short:   "Adiciona empresas ao grupo, criando-o se necessário",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := addPeers(args[0], args[1:], flags.peers.scriptMode); err != nil {
+			log.Println(err)
+		}
+	},
+}
+
+var peersListCmd = &cobra.Command{
+	Use:     "list [NOME]",
+	Aliases: []string{"lista"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Lista os grupos e suas empresas",
+	Run: func(cmd *cobra.Command, args []string) {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if err := listPeers(name); err != nil {
+			log.Println(err)
+		}
+	},
+}
+
+var peersRemoveCmd = &cobra.Command{
+	Use:     "remove NOME [EMPRESA...]",
+	Aliases: []string{"rm"},
+	Args:    cobra.MinimumNArgs(1),
+	Short:   "Remove empresas do grupo ou, sem EMPRESA, o grupo inteiro",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := removePeers(args[0], args[1:], flags.peers.scriptMode); err != nil {
+			log.Println(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(peersCmd)
+	peersCmd.AddCommand(peersAddCmd)
+	peersCmd.AddCommand(peersListCmd)
+	peersCmd.AddCommand(peersRemoveCmd)
+	peersCmd.PersistentFlags().BoolVarP(&flags.peers.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe a empresa com nome mais próximo)")
+}
+
+// selectCompanies returns the names (as stored on the DB) of the companies
+// identified by the exact name, CNPJ or trading code; the others are
+// selected by the closest name.
+func selectCompanies(db *sql.DB, list []string, scriptMode bool) ([]string, error) {
+	names, missing, err := parsers.LinkCompanies(db, list)
+	if err != nil {
+		return nil, err
+	}
+	for _, co := range missing {
+		name := strings.Split(SelectCompany(co, scriptMode), "@#")[0]
+		if name == "" {
+			return nil, fmt.Errorf("empresa '%s' não encontrada", co)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func addPeers(group string, companies []string, scriptMode bool) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	names, err := selectCompanies(db, companies, scriptMode)
+	if err != nil {
+		return err
+	}
+	missing, err := parsers.AddPeers(db, group, names)
+	if err != nil {
+		return err
+	}
+	for _, co := range missing {
+		fmt.Printf("[i] Empresa '%s' não encontrada\n", co)
+	}
+
+	return listPeers(group)
+}
+
+func removePeers(group string, companies []string, scriptMode bool) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	names, err := selectCompanies(db, companies, scriptMode)
+	if err != nil {
+		return err
+	}
+	if err := parsers.RemovePeers(db, group, names); err != nil {
+		return err
+	}
+
+	fmt.Println("[√] Grupo atualizado:", group)
+	return nil
+}
+
+func listPeers(group string) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	groups, err := parsers.PeerGroups(db)
+	if err != nil {
+		return err
+	}
+	origin := make(map[string]string, len(groups))
+	for name := range groups {
+		origin[name] = "banco de dados"
+	}
+	for name, list := range viper.GetStringMapStringSlice("grupos") {
+		names, missing, err := parsers.LinkCompanies(db, list)
+		if err != nil {
+			return err
+		}
+		for _, co := range missing {
+			names = append(names, co+" (não encontrada)")
+		}
+		groups[name], origin[name] = names, "arquivo de configuração"
+	}
+
+	keys := make([]string, 0, len(groups))
+	for name := range groups {
+		if group == "" || name == strings.ToLower(group) {
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("nenhum grupo encontrado")
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		fmt.Printf("%s (%s):\n", name, origin[name])
+		for _, co := range groups[name] {
+			fmt.Println("  -", co)
+		}
+	}
+
+	return nil
+}
+
+// peerGroup returns the companies (names as stored on the DB) of the peer
+// group, defined on the config file ('grupos') or on the DB.
+func peerGroup(db *sql.DB, group string) ([]string, error) {
+	group = strings.ToLower(strings.TrimSpace(group))
+
+	if list, ok := viper.GetStringMapStringSlice("grupos")[group]; ok {
+		names, missing, err := parsers.LinkCompanies(db, list)
+		if err != nil {
+			return nil, err
+		}
+		for _, co := range missing {
+			fmt.Printf("[i] Grupo %s: empresa '%s' não encontrada\n", group, co)
+		}
+		return names, nil
+	}
+
+	groups, err := parsers.PeerGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := groups[group]; ok {
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("grupo '%s' não encontrado (veja 'rapina grupo list')", group)
+}
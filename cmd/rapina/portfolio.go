@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type portfolioFlags struct {
+	format    string // output format of the report
+	outputDir string // directory where the spreadsheet will be saved
+}
+
+// portfolioCmd represents the carteira command
+var portfolioCmd = &cobra.Command{
+	Use:     "carteira ARQUIVO",
+	Aliases: []string{"portfolio"},
+	Args:    cobra.ExactArgs(1),
+	Short:   "Calcula a posição e os rendimentos de uma carteira de FIIs e ações",
+	Long: `Lê as transações de compra e venda de um arquivo CSV ou YAML e calcula,
+para cada FII ou ação, a quantidade, o preço médio, o valor atual (cotação do
+último dia útil) e os rendimentos recebidos (proporcionais às cotas detidas na
+data-base de cada rendimento), além dos rendimentos recebidos por mês.
+
+Cabeçalho do arquivo CSV (separado por ',' ou ';'):
+  data,codigo,operacao,quantidade,preco,taxas
+
+Operação: C (compra) ou V (venda). Data no formato AAAA-MM-DD.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		parms := make(map[string]string)
+		// Verbose
+		if flags.verbose {
+			parms[Fverbose] = "true"
+		}
+		// Report format
+		parms[Fformat] = flags.portfolio.format
+
+		if err := portfolio(parms, args[0]); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s carteira transacoes.csv -f xlsx", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(portfolioCmd)
+	portfolioCmd.Flags().StringVarP(&flags.portfolio.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|xlsx")
+	portfolioCmd.Flags().StringVarP(&flags.portfolio.outputDir, FoutputDir,
+		"d", "reports", "diretório onde a planilha será salva")
+}
+
+// portfolio reads the transactions from 'file' and prints or saves the
+// portfolio report.
+func portfolio(parms map[string]string, file string) error {
+	transactions, err := parsers.ReadTransactions(file)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	opts := reports.PortfolioOptions{
+		APIKey:  viper.GetString("apikey"),
+		DataDir: dataDir,
+	}
+
+	r, err := reports.NewPortfolio(db, opts)
+	if err != nil {
+		return err
+	}
+
+	r.SetParms(parms)
+
+	var xlsx string
+	if reports.PortfolioFormat(parms[Fformat]) == reports.Rxlsx {
+		xlsx, err = filename(flags.portfolio.outputDir, "carteira")
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.Report(transactions, xlsx)
+}
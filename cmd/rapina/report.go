@@ -21,7 +21,9 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
@@ -29,6 +31,8 @@ import (
 	"github.com/lithammer/fuzzysearch/fuzzy"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 // Flags
@@ -37,17 +41,41 @@ var all bool
 var showShares bool
 var extraRatios bool
 var fleuriet bool
+var quality bool
 var omitSector bool
 var outputDir = "reports"
-var format string // output format of the report
+var format string                 // output format of the report
+var unit string                   // display unit of the monetary values
+var realValues bool               // report in constant currency (IPCA)
+var baseYear int                  // base year of the constant currency
+var withValuation bool            // creates the valuation sheet
+var valuationParms valuationFlags // parameters of the valuation sheet
+var peers string                  // peer group used instead of the B3 segment
+var batch batchFlags              // batch reports (--batch or --sector)
+var template string               // report template (metrics printed)
 
 // reportCmd represents the report command
 var reportCmd = &cobra.Command{
 	Use:   "report [-s] nome_empresa",
 	Short: "Cria planilha com dados da companhia escolhida",
-	Long:  "Cria planilha com dados da companhia escolhida",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Cria planilha com dados da companhia escolhida.
+
+Com --batch ARQUIVO (uma empresa por linha: nome, CNPJ ou código de negociação)
+ou --sector NOME (setor, subsetor ou segmento da B3), cria uma planilha por
+empresa, ou uma planilha única com uma aba por empresa (--combined).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if batch.file != "" || batch.sector != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if batch.file != "" || batch.sector != "" {
+			if err := reportBatch(batch); err != nil {
+				fmt.Println("[x]", err)
+			}
+			return
+		}
 		report(args[0])
 	},
 }
@@ -60,9 +88,21 @@ func init() {
 	reportCmd.Flags().BoolVarP(&showShares, "showShares", "f", false, "Mostra o número de ações e free float")
 	reportCmd.Flags().BoolVarP(&extraRatios, "extraRatios", "x", false, "Reporte de índices extras")
 	reportCmd.Flags().BoolVarP(&fleuriet, "fleuriet", "F", false, "Capital de giro no modelo Fleuriet")
+	reportCmd.Flags().BoolVarP(&quality, "quality", "q", false, "Indicadores de qualidade: DuPont, Piotroski F-Score e Altman Z''-Score")
 	reportCmd.Flags().BoolVarP(&omitSector, "omitSector", "o", false, "Omite o relatório das empresas do mesmo setor")
 	reportCmd.Flags().StringVarP(&outputDir, "outputDir", "d", "reports", "Diretório onde o relatório será salvo")
-	reportCmd.Flags().StringVarP(&format, "format", "r", "xlsx", "Formato do relatório: xlsx|stdout")
+	reportCmd.Flags().StringVarP(&format, "format", "r", "xlsx", "Formato do relatório: xlsx|stdout|raw|markdown|json|html|pdf")
+	reportCmd.Flags().StringVarP(&unit, Funit, "u", "mil", "Unidade dos valores: unidade|mil|milhoes")
+	reportCmd.Flags().BoolVar(&realValues, Freal, false, "Valores reais, corrigidos pelo IPCA")
+	reportCmd.Flags().BoolVar(&withValuation, "valuation", false, "Cria a aba de valuation (DCF, Graham e Bazin)")
+	addValuationFlags(reportCmd, &valuationParms)
+	reportCmd.Flags().IntVar(&baseYear, FbaseYear, 0, "Ano base dos valores reais (padrão: último ano com IPCA)")
+	reportCmd.Flags().StringVar(&peers, Fpeers, "", "Grupo de empresas usado na aba SETOR e na média setorial (veja o comando grupo)")
+	reportCmd.Flags().StringVar(&template, Ftemplate, "", "Modelo de relatório com os indicadores (padrão: chave 'modelo' do arquivo de configuração)")
+	reportCmd.Flags().StringVar(&batch.file, Fbatch, "", "Arquivo com a lista de empresas (uma por linha)")
+	reportCmd.Flags().StringVar(&batch.sector, Fsector, "", "Setor, subsetor ou segmento da B3 com as empresas")
+	reportCmd.Flags().IntVar(&batch.workers, Fworkers, 4, "Número de relatórios criados em paralelo (--batch e --sector)")
+	reportCmd.Flags().BoolVar(&batch.combined, Fcombined, false, "Planilha única com uma aba por empresa (--batch e --sector)")
 }
 
 func report(company string) {
@@ -77,19 +117,47 @@ func report(company string) {
 		company = companyWithTicker[0]
 		spcfctnCd = companyWithTicker[1]
 	}
-	fmt.Println()
-	fmt.Printf("[√] Criando relatório para %s ========\n", company)
+	if format != "json" && format != "markdown" {
+		fmt.Println()
+		fmt.Printf("[√] Criando relatório para %s ========\n", company)
+	}
+
+	parms, err := reportFlags(company, spcfctnCd)
+	if err != nil {
+		fmt.Println("[x]", err)
+		return
+	}
+	err = Report(parms)
+	if err != nil {
+		fmt.Println("[x]", err)
+	}
+}
+
+//
+// reportFlags returns the report parameters set by the command line flags
+//
+func reportFlags(company, spcfctnCd string) (Parms, error) {
+	u, err := reports.ParseUnit(unit)
+	if err != nil {
+		return Parms{}, err
+	}
+	infl, base, err := loadInflation(realValues, baseYear)
+	if err != nil {
+		return Parms{}, err
+	}
 
 	if all {
 		extraRatios = true
 		showShares = true
 		fleuriet = true
+		quality = true
 	}
 
 	r := make(map[string]bool)
 	r["ExtraRatios"] = extraRatios
 	r["ShowShares"] = showShares
 	r["Fleuriet"] = fleuriet
+	r["Quality"] = quality
 	r["PrintSector"] = !omitSector
 
 	parms := Parms{
@@ -97,13 +165,22 @@ func report(company string) {
 		SpcfctnCd: spcfctnCd,
 		Format:    format,
 		OutputDir: outputDir,
-		YamlFile:  yamlFile,
 		Reports:   r,
+		Unit:      u,
+		Inflation: infl,
+		BaseYear:  base,
+		Peers:     peers,
+		Template:  template,
 	}
-	err := Report(parms)
-	if err != nil {
-		fmt.Println("[x]", err)
+	if parms.Template == "" {
+		parms.Template = viper.GetString("modelo")
 	}
+	if withValuation {
+		v := valuationParms.parms()
+		parms.Valuation = &v
+	}
+
+	return parms, nil
 }
 
 //
@@ -174,16 +251,59 @@ func Report(p Parms) (err error) {
 	if err != nil {
 		return errors.Wrap(err, "fail to open db")
 	}
+	if p.Reports["PrintSector"] {
+		checkSectors(db)
+	}
 
 	if p.OutputDir == "" {
 		p.OutputDir = outputDir
 	}
 
-	file, err := filename(p.OutputDir, p.Company)
+	ext := "xlsx"
+	if p.Format == "html" || p.Format == "pdf" {
+		ext = p.Format
+	}
+	file, err := filenameExt(p.OutputDir, p.Company, ext)
 	if err != nil {
 		return err
 	}
 
+	parms, err := reportParms(db, p, file)
+	if err != nil {
+		return err
+	}
+
+	if p.Format == "stdout" {
+		fd := int(os.Stdout.Fd())
+		if width, _, err := term.GetSize(fd); err == nil {
+			parms["width"] = width
+		}
+		parms["color"] = term.IsTerminal(fd) && os.Getenv("NO_COLOR") == ""
+		return reports.ReportToStdout(parms)
+	}
+	if p.Format == "raw" {
+		return reports.ReportRawToStdout(parms)
+	}
+	if p.Format == "markdown" {
+		return reports.ReportToMarkdown(parms)
+	}
+	if p.Format == "json" {
+		return reports.ReportToJSON(parms)
+	}
+	if p.Format == "html" {
+		return reports.ReportToHTML(parms)
+	}
+	if p.Format == "pdf" {
+		return reports.ReportToPDF(parms)
+	}
+
+	return reports.ReportToXlsx(parms)
+}
+
+//
+// reportParms returns the parameters used by the reports package
+//
+func reportParms(db *sql.DB, p Parms, file string) (map[string]interface{}, error) {
 	parms := map[string]interface{}{
 		"db":        db,
 		"dataDir":   dataDir,
@@ -191,13 +311,21 @@ func Report(p Parms) (err error) {
 		"SpcfctnCd": p.SpcfctnCd,
 		"format":    p.Format,
 		"filename":  file,
-		"yamlFile":  p.YamlFile,
 		"reports":   p.Reports,
+		"unit":      p.Unit,
+		"inflation": p.Inflation,
+		"baseYear":  p.BaseYear,
+		"valuation": p.Valuation,
+		"template":  p.Template,
 	}
-
-	if p.Format == "stdout" {
-		return reports.ReportToStdout(parms)
+	if p.Peers != "" {
+		list, err := peerGroup(db, p.Peers)
+		if err != nil {
+			return nil, err
+		}
+		parms["peers"] = list
+		parms["peersName"] = p.Peers
 	}
 
-	return reports.ReportToXlsx(parms)
+	return parms, nil
 }
@@ -0,0 +1,183 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/dude333/rapina/reports"
+	"github.com/pkg/errors"
+)
+
+type batchFlags struct {
+	file     string // file with the list of companies
+	sector   string // sector, subsector or segment of the companies
+	workers  int    // number of reports created concurrently
+	combined bool   // single workbook, with one sheet per company
+}
+
+// reportBatch creates the reports of the companies listed on a file or from
+// a B3 sector, sharing the DB connection, and prints a summary at the end.
+func reportBatch(f batchFlags) error {
+	if f.file != "" && f.sector != "" {
+		return errors.New("use --batch ou --sector, não ambos")
+	}
+	if format != "xlsx" {
+		return errors.New("--batch e --sector criam apenas planilhas (formato xlsx)")
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	if f.sector != "" || !omitSector {
+		checkSectors(db)
+	}
+
+	var list []reports.BatchReport
+	name := f.sector
+	if f.file != "" {
+		list, err = batchFromFile(db, f.file)
+		name = strings.TrimSuffix(filepath.Base(f.file), filepath.Ext(f.file))
+	} else {
+		list, err = batchFromSector(db, f.sector)
+	}
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errors.New("nenhuma empresa encontrada")
+	}
+
+	p, err := reportFlags("", "ON")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	fmt.Printf("[i] Criando %d relatórios (%d em paralelo)\n", len(list), f.workers)
+	if f.combined {
+		file, err := filename(outputDir, name)
+		if err != nil {
+			return err
+		}
+		parms, err := reportParms(db, p, file)
+		if err != nil {
+			return err
+		}
+		err = reports.CombinedToXlsx(parms, list, f.workers)
+		printBatchSummary(list)
+		if err == nil {
+			fmt.Printf("[√] Dados salvos em %s\n", file)
+		}
+		return err
+	}
+
+	for i := range list {
+		if list[i].Err != nil {
+			continue
+		}
+		list[i].Filename, list[i].Err = filename(outputDir, list[i].Company)
+	}
+	parms, err := reportParms(db, p, "")
+	if err != nil {
+		return err
+	}
+	err = reports.BatchToXlsx(parms, list, f.workers)
+	printBatchSummary(list)
+	if err == nil {
+		fmt.Printf("[√] Dados salvos em %s\n", outputDir)
+	}
+
+	return err
+}
+
+// batchFromFile returns the companies listed on the file, one per line
+// (name, CNPJ or trading code). Empty lines and lines starting with # are
+// ignored. Lines that don't match a company exactly (see
+// parsers.LinkCompanies) are returned with Err set, to be reported as
+// failures instead of guessing a similar company.
+func batchFromFile(db *sql.DB, file string) ([]reports.BatchReport, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "lista de empresas")
+	}
+	defer fh.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "lista de empresas")
+	}
+
+	names, missing, err := parsers.LinkCompanies(db, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []reports.BatchReport
+	seen := make(map[string]bool)
+	add := func(co string, err error) {
+		if !seen[co] {
+			seen[co] = true
+			list = append(list, reports.BatchReport{Company: co, Err: err})
+		}
+	}
+	for _, co := range names {
+		add(co, nil)
+	}
+	for _, co := range missing {
+		add(co, errors.New("empresa não encontrada"))
+	}
+
+	return list, nil
+}
+
+// batchFromSector returns the companies of the B3 sector, subsector or
+// segment.
+func batchFromSector(db *sql.DB, sector string) ([]reports.BatchReport, error) {
+	companies, err := parsers.SectorCompanies(db, sector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]reports.BatchReport, len(companies))
+	for i, co := range companies {
+		list[i].Company = co
+	}
+
+	return list, nil
+}
+
+// printBatchSummary prints the number of reports created and the failures.
+func printBatchSummary(list []reports.BatchReport) {
+	var failed []reports.BatchReport
+	for _, b := range list {
+		if b.Err != nil {
+			failed = append(failed, b)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("[i] Resumo: %d relatórios criados, %d com falha\n", len(list)-len(failed), len(failed))
+	for _, b := range failed {
+		fmt.Printf("    - %s: %v\n", b.Company, b.Err)
+	}
+}
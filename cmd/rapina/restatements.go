@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+)
+
+type restatementsFlags struct {
+	format     string // output format of the report
+	scriptMode bool   // selects the company with the closest name
+}
+
+// restatementsCmd represents the reapresentacoes command
+var restatementsCmd = &cobra.Command{
+	Use:     "reapresentacoes [EMPRESA]",
+	Aliases: []string{"restatements"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Lista as empresas que reapresentaram os demonstrativos anuais",
+	Long: `Compara os valores originalmente divulgados nos demonstrativos anuais (DFP)
+com os da última versão reapresentada, armazenados no banco de dados.
+
+Sem EMPRESA, lista as empresas que reapresentaram os demonstrativos, com o
+número de contas alteradas e a variação do lucro líquido, do patrimônio
+líquido e a maior variação entre as contas. Com EMPRESA, mostra o valor
+original e o reapresentado de cada conta alterada.
+
+As versões anteriores só estão disponíveis se os arquivos da CVM tiverem sido
+baixados (comando 'update') antes da reapresentação.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		company := ""
+		if len(args) > 0 {
+			company = args[0]
+		}
+		if err := restatements(company, flags.restatements); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s reapresentacoes\n  %s reapresentacoes WEG",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(restatementsCmd)
+	f := restatementsCmd.Flags()
+	f.StringVarP(&flags.restatements.format, Fformat,
+		"f", "tabela", "formato do relatório: tabela|csv")
+	f.BoolVarP(&flags.restatements.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe a empresa com nome mais próximo)")
+}
+
+func restatements(company string, f restatementsFlags) error {
+	if company != "" {
+		company = SelectCompany(company, f.scriptMode)
+		if company == "" {
+			return fmt.Errorf("empresa não encontrada")
+		}
+		company = strings.Split(company, "@#")[0] // remove ticker
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	list, err := reports.Restatements(db, company)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("[ ] Nenhuma reapresentação encontrada")
+		return nil
+	}
+
+	if company == "" {
+		reports.PrintRestatementsSummary(reports.SummarizeRestatements(list), f.format)
+		return nil
+	}
+	reports.PrintRestatements(list, f.format)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/parsers"
+	"github.com/spf13/cobra"
+)
+
+type sectorsFlags struct {
+	importFile bool   // imports the sectors file into the DB
+	exportFile bool   // exports the sectors from the DB to the file
+	file       string // sectors file
+}
+
+// sectorsCmd represents the setores command
+var sectorsCmd = &cobra.Command{
+	Use:     "setores",
+	Aliases: []string{"sectors"},
+	Args:    cobra.NoArgs,
+	Short:   "Importa ou exporta a classificação setorial da B3",
+	Long: `Importa ou exporta a classificação setorial da B3 (setor > subsetor >
+segmento), armazenada no banco de dados.
+
+Na importação, cada empresa do arquivo é vinculada às empresas da CVM pelo
+CNPJ, pelo nome ou pelo código de negociação, nesta ordem. As empresas não
+vinculadas são listadas e podem ser corrigidas no arquivo, informando o CNPJ
+ou o código:
+
+  Empresas:
+    - GRENDENE S.A.
+    - Nome: VULCABRAS/AZALEIA S.A.
+      CNPJ: 50.926.955/0001-42
+
+A exportação grava o arquivo com o CNPJ e o código de cada empresa.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := sectorsIO(flags.sectors); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s setores --exportar\n  %s setores --importar -a setores.yml",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(sectorsCmd)
+	f := sectorsCmd.Flags()
+	f.BoolVarP(&flags.sectors.importFile, Fimport,
+		"i", false, "importa o arquivo de setores para o banco de dados")
+	f.BoolVarP(&flags.sectors.exportFile, Fexport,
+		"x", false, "exporta os setores do banco de dados para o arquivo")
+	f.StringVarP(&flags.sectors.file, Ffile,
+		"a", yamlFile, "arquivo de setores")
+}
+
+func sectorsIO(f sectorsFlags) error {
+	if f.importFile == f.exportFile {
+		return fmt.Errorf("use --%s ou --%s", Fimport, Fexport)
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	if f.importFile {
+		return importSectors(db, f.file)
+	}
+
+	err = parsers.ExportSectors(db, f.file)
+	if errors.Is(err, rapina.ErrFileNotUpdated) {
+		return nil
+	}
+	if err == nil {
+		fmt.Println("[√] Arquivo salvo:", f.file)
+	}
+	return err
+}
+
+// importSectors imports the sectors file into the DB, listing the companies
+// that could not be linked to the CVM companies.
+func importSectors(db *sql.DB, file string) error {
+	missing, err := parsers.ImportSectors(db, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("[√] Setores importados de", file)
+	if len(missing) > 0 {
+		fmt.Printf("[i] %d empresas não vinculadas (informe o CNPJ ou o código no arquivo):\n", len(missing))
+		for _, name := range missing {
+			fmt.Println("    -", name)
+		}
+	}
+
+	return nil
+}
+
+// checkSectors imports the sectors file into the DB, if the sectors are not
+// there yet (DBs created before the sectors were stored on the DB), so that
+// the sector reports are not silently skipped. If the file is not found,
+// tells the user how to get it.
+func checkSectors(db *sql.DB) {
+	if parsers.HasSectors(db) {
+		return
+	}
+	if _, err := os.Stat(yamlFile); err != nil {
+		fmt.Fprintln(os.Stderr, "[i] Setores não encontrados no banco de dados: execute 'rapina update -s' para incluir os relatórios setoriais")
+		return
+	}
+	fmt.Fprintln(os.Stderr, "[i] Importando setores de", yamlFile)
+	if _, err := parsers.ImportSectors(db, yamlFile); err != nil {
+		fmt.Fprintln(os.Stderr, "[x]", err)
+	}
+}
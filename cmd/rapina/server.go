@@ -46,6 +46,7 @@ func serve(parms map[string]string) error {
 	if err != nil {
 		return err
 	}
+	checkSectors(db)
 
 	v := parms[Fverbose] == "true"
 
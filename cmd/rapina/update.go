@@ -27,6 +27,7 @@ import (
 
 	"github.com/dude333/rapina"
 	"github.com/dude333/rapina/fetch"
+	"github.com/dude333/rapina/parsers"
 	"github.com/dude333/rapina/reports"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -56,6 +57,9 @@ var getUpdate = &cobra.Command{
 		if err == nil {
 			fmt.Println("[√] Arquivo salvo:", yamlFile)
 		}
+		if err := importSectors(db, yamlFile); err != nil {
+			fmt.Println("[x]", err)
+		}
 		//
 		fmt.Println()
 		//
@@ -63,6 +67,13 @@ var getUpdate = &cobra.Command{
 			return
 		}
 
+		m, err := accountsMapping(db)
+		if err != nil {
+			fmt.Println("[x]", err)
+			return
+		}
+		parsers.SetAccountsMapping(m)
+
 		err = fetch.CVM(db, dataDir)
 		if err != nil {
 			fmt.Println("[x]", err)
@@ -77,6 +88,11 @@ var getUpdate = &cobra.Command{
 			return
 		}
 		_ = stock.UpdateStockCodes()
+
+		// Link the sectors to the companies added by this update
+		if err := importSectors(db, yamlFile); err != nil {
+			fmt.Println("[x]", err)
+		}
 	},
 }
 
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Adriano P <dev@dude333.com>
+Distributed under the MIT License.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dude333/rapina/reports"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type valuationFlags struct {
+	growth         float64 // free cash flow growth (%)
+	discount       float64 // discount rate (%)
+	terminalGrowth float64 // perpetual growth (%)
+	years          int     // projection years
+	bazinYield     float64 // minimum dividend yield (%)
+	scriptMode     bool    // selects the company with the closest name
+}
+
+// parms returns the valuation parameters, converting the rates from %.
+func (f valuationFlags) parms() reports.ValuationParms {
+	return reports.ValuationParms{
+		Growth:         f.growth / 100,
+		Discount:       f.discount / 100,
+		TerminalGrowth: f.terminalGrowth / 100,
+		Years:          f.years,
+		BazinYield:     f.bazinYield / 100,
+	}
+}
+
+// valuationCmd represents the valuation command
+var valuationCmd = &cobra.Command{
+	Use:   "valuation EMPRESA",
+	Args:  cobra.ExactArgs(1),
+	Short: "Calcula o preço justo das ações pelo fluxo de caixa descontado, Graham e Bazin",
+	Long: `Calcula o preço justo das ações de uma empresa com base nos últimos
+demonstrativos armazenados no banco de dados:
+
+  DCF:    fluxo de caixa livre (FCO+FCI) projetado com a taxa de crescimento
+          pelo número de anos da projeção, mais a perpetuidade (Gordon),
+          trazidos a valor presente pela taxa de desconto, menos a dívida
+          líquida, dividido pelo número de ações.
+  Graham: raiz de 22,5 x LPA x VPA.
+  Bazin:  média dos proventos por ação dos últimos 3 anos dividida pelo
+          dividend yield mínimo.
+
+Mostra também o upside em relação à última cotação e tabelas de
+sensibilidade do DCF à taxa de desconto, ao crescimento e à perpetuidade.
+A planilha do comando 'report --valuation' inclui os mesmos dados.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := valuation(args[0], flags.valuation); err != nil {
+			log.Println(err)
+		}
+	},
+	Example: func() string {
+		return fmt.Sprintf("%s valuation WEG --crescimento 8 --desconto 11", filepath.Base(os.Args[0]))
+	}(),
+}
+
+func init() {
+	rootCmd.AddCommand(valuationCmd)
+	addValuationFlags(valuationCmd, &flags.valuation)
+	valuationCmd.Flags().BoolVarP(&flags.valuation.scriptMode, "scriptMode",
+		"s", false, "para modo script (escolhe a empresa com nome mais próximo)")
+}
+
+// addValuationFlags adds the valuation parameters to the command.
+func addValuationFlags(cmd *cobra.Command, f *valuationFlags) {
+	d := reports.DefaultValuationParms()
+	cmd.Flags().Float64Var(&f.growth, Fgrowth, 100*d.Growth, "crescimento anual do fluxo de caixa livre na projeção (%)")
+	cmd.Flags().Float64Var(&f.discount, Fdiscount, 100*d.Discount, "taxa de desconto (%)")
+	cmd.Flags().Float64Var(&f.terminalGrowth, FterminalGrowth, 100*d.TerminalGrowth, "crescimento na perpetuidade (%)")
+	cmd.Flags().IntVar(&f.years, FprojYears, d.Years, "anos da projeção")
+	cmd.Flags().Float64Var(&f.bazinYield, FbazinYield, 100*d.BazinYield, "dividend yield mínimo do preço justo de Bazin (%)")
+}
+
+func valuation(company string, f valuationFlags) error {
+	company = SelectCompany(company, f.scriptMode)
+	if company == "" {
+		return fmt.Errorf("empresa não encontrada")
+	}
+	spcfctnCd := "ON"
+	if s := strings.Split(company, "@#"); len(s) > 1 {
+		company, spcfctnCd = s[0], s[1]
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	r, err := reports.New(map[string]interface{}{
+		"db":      db,
+		"dataDir": dataDir,
+		"apiKey":  viper.GetString("apikey"),
+	})
+	if err != nil {
+		return err
+	}
+
+	v, err := r.Valuation(company, spcfctnCd, f.parms())
+	if err != nil {
+		return err
+	}
+
+	reports.PrintValuation(v)
+
+	return nil
+}
@@ -93,6 +93,26 @@ func LastBusinessDayOfYear(year int) string {
 	return date.Format("2006-01-02")
 }
 
+// LastBusinessDayOfMonth returns the last business day of 'monthYear'
+// (YYYY-MM), not considering holidays. Returns date as YYYY-MM-DD.
+func LastBusinessDayOfMonth(monthYear string) (string, error) {
+	t, err := time.Parse("2006-01", monthYear)
+	if err != nil {
+		return "", ErrInvalidDate
+	}
+
+	date := time.Date(t.Year(), t.Month()+1, 0, 12, 0, 0, 0, time.UTC)
+
+	if date.Weekday() == time.Saturday {
+		date = date.AddDate(0, 0, -1)
+	}
+	if date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, -2)
+	}
+
+	return date.Format("2006-01-02"), nil
+}
+
 // LastBusinessDay returns the most recent business day 'n' days before today.
 // Returns date as YYYY-MM-DD.
 func LastBusinessDay(n int) string {
@@ -110,3 +130,33 @@ func LastBusinessDay(n int) string {
 
 	return date.Format("2006-01-02")
 }
+
+// YearRange parses a year ("2020") or a range of years ("2012-2023").
+// Returns zeros if 'years' is empty.
+func YearRange(years string) (begin, end int, err error) {
+	years = strings.TrimSpace(years)
+	if years == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.Split(years, "-")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("intervalo de anos inválido: %s", years)
+	}
+	begin, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("ano inválido: %s", parts[0])
+	}
+	end = begin
+	if len(parts) == 2 {
+		end, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, fmt.Errorf("ano inválido: %s", parts[1])
+		}
+	}
+	if begin < 1970 || end > 2200 || begin > end {
+		return 0, 0, fmt.Errorf("intervalo de anos inválido: %s", years)
+	}
+
+	return begin, end, nil
+}
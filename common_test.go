@@ -157,3 +157,60 @@ func TestLastBusinessDayOfYear(t *testing.T) {
 		})
 	}
 }
+
+func TestLastBusinessDayOfMonth(t *testing.T) {
+	tests := []struct {
+		name      string
+		monthYear string
+		want      string
+		wantErr   bool
+	}{
+		{name: "weekday", monthYear: "2021-03", want: "2021-03-31"},
+		{name: "saturday", monthYear: "2021-07", want: "2021-07-30"},
+		{name: "sunday", monthYear: "2021-10", want: "2021-10-29"},
+		{name: "leap year", monthYear: "2020-02", want: "2020-02-28"},
+		{name: "invalid", monthYear: "2021-13", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LastBusinessDayOfMonth(tt.monthYear)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LastBusinessDayOfMonth() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("LastBusinessDayOfMonth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYearRange(t *testing.T) {
+	tests := []struct {
+		years     string
+		wantBegin int
+		wantEnd   int
+		wantErr   bool
+	}{
+		{years: "", wantBegin: 0, wantEnd: 0},
+		{years: "2020", wantBegin: 2020, wantEnd: 2020},
+		{years: "2012-2023", wantBegin: 2012, wantEnd: 2023},
+		{years: " 2012 - 2023 ", wantBegin: 2012, wantEnd: 2023},
+		{years: "2023-2012", wantErr: true},
+		{years: "2012-", wantErr: true},
+		{years: "20a2", wantErr: true},
+		{years: "2010-2012-2014", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.years, func(t *testing.T) {
+			begin, end, err := YearRange(tt.years)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("YearRange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if begin != tt.wantBegin || end != tt.wantEnd {
+				t.Errorf("YearRange() = %d, %d, want %d, %d", begin, end, tt.wantBegin, tt.wantEnd)
+			}
+		})
+	}
+}
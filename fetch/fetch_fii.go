@@ -18,14 +18,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dude333/rapina"
 	"github.com/dude333/rapina/parsers"
 	"github.com/dude333/rapina/progress"
-	"github.com/gocolly/colly/v2"
 	"github.com/pkg/errors"
 	"golang.org/x/net/html"
 )
@@ -55,94 +56,240 @@ type id int
 // Report holds the result of all documents filtered by a criteria defined by a
 // http.Get on the B3 server.
 type Report struct {
-	Data []docID `json:"data"`
+	Data  []docID `json:"data"`
+	Total int     `json:"recordsFiltered"`
 }
 type docID struct {
-	ID          id     `json:"id"`
-	Description string `json:"descricaoFundo"`
-	DocType     string `json:"tipoDocumento"`
-	Status      string `json:"situacaoDocumento"`
+	ID           id         `json:"id"`
+	Description  string     `json:"descricaoFundo"`
+	DocType      string     `json:"tipoDocumento"`
+	Status       string     `json:"situacaoDocumento"`
+	RefDate      string     `json:"dataReferencia"`      // DD/MM/YYYY or MM/YYYY
+	DeliveryDate string     `json:"dataEntrega"`         // DD/MM/YYYY HH:MM
+	Version      docVersion `json:"versao"`              // > 1 if rectified
+	Modality     string     `json:"descricaoModalidade"` // e.g. Retificação
+	Admin        string     `json:"nomeAdministrador"`
 }
 
-// Dividends gets the report IDs for one company ('cnpj') and then the
-// yeld montlhy report for 'n' months, starting from the latest released.
+// docVersion accepts the document version as a number or a string.
+type docVersion int
+
+func (v *docVersion) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.Trim(string(b), `"`))
+	if err != nil {
+		return errors.Wrapf(err, "versão do documento inválida: %s", b)
+	}
+	*v = docVersion(n)
+	return nil
+}
+
+// rectified returns true if the document rectifies a previous one.
+func (d docID) rectified() bool {
+	m := strings.ToLower(d.Modality)
+	return d.Version > 1 || strings.Contains(m, "retifica") || strings.Contains(m, "reapresenta")
+}
+
+// delivery returns the delivery date as YYYY-MM-DD HH:MM.
+func (d docID) delivery() string {
+	date := strings.TrimSpace(d.DeliveryDate)
+	if len(date) < len("26/04/2021") {
+		return date
+	}
+	return strings.TrimSpace(fixDate(date[:10]) + " " + strings.TrimSpace(date[10:]))
+}
+
+// refDate returns the reference date as YYYY-MM-DD, or YYYY-MM for the
+// documents referring to a month.
+func (d docID) refDate() string {
+	date := strings.TrimSpace(d.RefDate)
+	if len(date) >= len("26/04/2021") {
+		return fixDate(date[:10])
+	}
+	return fixMonth(date)
+}
+
+// refMonth returns the reference month as YYYY-MM.
+func (d docID) refMonth() string {
+	date := strings.TrimSpace(d.RefDate)
+	if len(date) >= len("26/04/2021") {
+		date = date[3:10]
+	}
+	return fixMonth(date)
+}
+
+// Dividends returns the dividends of the FII 'code' for 'n' months, starting
+// from the latest released. Only documents delivered after the last one
+// fetched, or referring to months missing in the DB, are fetched from the
+// server.
 func (fii FII) Dividends(code string, n int) (*[]rapina.Dividend, error) {
-	dividends, months, err := fii.dividendsFromDB(code, n)
-	if err == nil {
-		if months >= n {
-			return dividends, err
+	last, _ := fii.storage.LastDocument(code, repDividends.String())
+	missing := fii.dividendGaps(code, n)
+
+	// The server is skipped only when there are no gaps and the dividend of
+	// the current month, the newest document expected, is already stored
+	if len(missing) == 0 && last != "" {
+		current := rapina.MonthsFromToday(1)[0]
+		if _, err := fii.storage.Dividends(code, current); err == nil {
+			return fii.dividendsFromDB(code, n)
 		}
 	}
 
-	dividends, err = fii.dividendsFromServer(code, n)
+	err := fii.dividendsFromServer(code, n, last, missing)
 	if err != nil {
 		return nil, err
 	}
-	for _, d := range *dividends {
-		err := fii.storage.SaveDividend(d) // Save dividends to DB
-		if err != nil {
-			progress.ErrorMsg("Erro ao salvar dividendos no banco de dados: %s - %v", err, d)
+
+	// Months really without dividends are not queried again
+	for monthYear := range missing {
+		if _, err := fii.storage.Dividends(code, monthYear); err != nil {
+			if err := fii.storage.SaveCheckedMonth(code, repDividends.String(), monthYear); err != nil {
+				progress.ErrorMsg("%s: %v", code, err)
+			}
 		}
 	}
 
 	// Load dividends from DB to filter results
-	dividends, _, err = fii.dividendsFromDB(code, n)
-	return dividends, err
+	return fii.dividendsFromDB(code, n)
 }
 
-func (fii FII) dividendsFromDB(code string, n int) (*[]rapina.Dividend, int, error) {
+func (fii FII) dividendsFromDB(code string, n int) (*[]rapina.Dividend, error) {
 	var dividends []rapina.Dividend
-	var months int
-	for _, monthYear := range rapina.MonthsFromToday(n + 2) {
+	for _, monthYear := range rapina.MonthsFromToday(n) {
 		d, err := fii.storage.Dividends(code, monthYear)
 		if err == nil { // ignore errors
 			dividends = append(dividends, *d...)
-			months++
-		}
-		if months == n {
-			break
 		}
 	}
 
 	if len(dividends) == 0 {
-		return nil, 0, errors.New("dividendos não encontrados")
+		return nil, errors.New("dividendos não encontrados")
 	}
 
-	return &dividends, months, nil
+	return &dividends, nil
 }
 
-// Dividends gets the report IDs for one company ('cnpj') and then the
-// yeld montlhy report for 'n' months, starting from the latest released.
-//
-// If the number of reports does not match n, it'll retry with a bigger n as
-// sometimes reports from follow-on offerings (FPO).
-func (fii *FII) dividendsFromServer(code string, n int) (*[]rapina.Dividend, error) {
-	n = int(float64(n) * 1.25)
-	if n > MAX_N {
-		n = MAX_N
+// dividendGaps returns the months (YYYY-MM) without dividends in the DB over
+// the last 'n' months, not considering the current month, as its dividend
+// may not have been announced yet, nor the months already checked on the
+// server.
+func (fii FII) dividendGaps(code string, n int) map[string]bool {
+	checked, _ := fii.storage.CheckedMonths(code, repDividends.String())
+	missing := make(map[string]bool)
+	for _, monthYear := range rapina.MonthsFromToday(n + 1)[1:] {
+		if checked[monthYear] {
+			continue
+		}
+		if _, err := fii.storage.Dividends(code, monthYear); err != nil {
+			missing[monthYear] = true
+		}
 	}
+	return missing
+}
+
+// dividendsFromServer fetches the dividend reports delivered after 'last'
+// (YYYY-MM-DD HH:MM) or referring to the 'missing' months, and stores the
+// dividends in the DB. Dividends from rectified reports replace the ones
+// previously stored.
+func (fii *FII) dividendsFromServer(code string, n int, last string, missing map[string]bool) error {
+	from := dividendsFrom(n, last, missing)
 
-	ids, err := fii.reportIDs(repDividends, code, n)
+	docs, err := fii.reportDocs(repDividends, code, from)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	docs = docsToFetch(docs, last, missing)
+	progress.Debug("Report IDs: %v", docs)
+	if len(docs) == 0 {
+		return nil
 	}
-	progress.Debug("Report IDs: %v", ids)
 
 	progress.Status("Relatórios de dividendos: %s", code)
-	dividends, err := fii.dividendReport(code, ids)
-	if err != nil {
-		return nil, err
+	client := documentClient()
+	for _, doc := range docs {
+		data, err := documentData(client, doc.ID)
+		if err != nil {
+			return err
+		}
+
+		d, ok := parseData(data)
+		if !ok {
+			continue
+		}
+		d.RefDate = doc.refDate()
+		if doc.rectified() {
+			err = fii.storage.ReplaceDividend(d)
+		} else {
+			err = fii.storage.SaveDividend(d)
+		}
+		if err != nil {
+			progress.ErrorMsg("Erro ao salvar dividendos no banco de dados: %s - %v", err, d)
+		}
 	}
 
-	return dividends, nil
+	// Documents are sorted by delivery date
+	if date := docs[len(docs)-1].delivery(); date > last {
+		if err := fii.storage.SaveLastDocument(code, repDividends.String(), date); err != nil {
+			progress.ErrorMsg("%s: %v", code, err)
+		}
+	}
+
+	return nil
 }
 
-// dividendReport parses the dividend reports and returns their dividends.
-func (fii *FII) dividendReport(code string, ids []id) (*[]rapina.Dividend, error) {
-	var dividends []rapina.Dividend
+// dividendsFrom returns the initial date used to list the dividend reports:
+// a few weeks before the 'last' document fetched or before the oldest
+// 'missing' month, limited to 'n' months ago.
+func dividendsFrom(n int, last string, missing map[string]bool) time.Time {
+	now := time.Now()
+	limit := now.AddDate(0, -minmax(int(float64(n)*1.25), 1, MAX_N), -now.Day()+1)
 
-	// HTTP client setup
-	client := &http.Client{
+	from := now
+	if t, err := time.Parse("2006-01-02", firstN(last, len("2006-01-02"))); err == nil {
+		from = t.AddDate(0, 0, -45) // rectifications may refer to older months
+	} else {
+		from = limit
+	}
+	for m := range missing {
+		if t, err := time.Parse("2006-01", m); err == nil && t.AddDate(0, -1, 0).Before(from) {
+			from = t.AddDate(0, -1, 0)
+		}
+	}
+	if from.Before(limit) {
+		from = limit
+	}
+
+	return from
+}
+
+// docsToFetch returns the 'docs' delivered after 'last' or referring to the
+// 'missing' months, sorted by delivery date.
+func docsToFetch(docs []docID, last string, missing map[string]bool) []docID {
+	var list []docID
+	for _, d := range docs {
+		if d.delivery() > last || missing[d.refMonth()] {
+			list = append(list, d)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].delivery() < list[j].delivery()
+	})
+	return list
+}
+
+func firstN(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
+
+// documentClient returns the HTTP client used to download the documents
+// from fnet.
+func documentClient() *http.Client {
+	return &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true,
@@ -150,66 +297,63 @@ func (fii *FII) dividendReport(code string, ids []id) (*[]rapina.Dividend, error
 			MaxIdleConnsPerHost: 10,
 		},
 	}
+}
 
-	for _, id := range ids {
-		url := fmt.Sprintf("https://fnet.bmfbovespa.com.br/fnet/publico/exibirDocumento?id=%d&cvm=true", id)
-		progress.Debug("GET %s", url)
-
-		// Make HTTP request
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, err
-		}
+// documentData downloads the document 'id' from fnet and returns the text
+// of all its table cells (<td>), in order.
+func documentData(client *http.Client, id id) ([]string, error) {
+	url := fmt.Sprintf("https://fnet.bmfbovespa.com.br/fnet/publico/exibirDocumento?id=%d&cvm=true", id)
+	progress.Debug("GET %s", url)
 
-		// Reuse the same client for subsequent requests
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, err
-		}
+	// Make HTTP request
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			return nil, errors.Wrapf(err, "unexpected status code: %d", resp.StatusCode)
-		}
+	// Reuse the same client for subsequent requests
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
 
-		// Read response body
-		body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		if err != nil {
-			return nil, err
-		}
+		return nil, errors.Wrapf(err, "unexpected status code: %d", resp.StatusCode)
+	}
 
-		// Decode base64 encoded body
-		decodedBody, err := base64.StdEncoding.DecodeString(strings.Trim(string(body), `"`))
-		if err != nil {
-			return nil, err
-		}
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
 
-		doc, err := html.Parse(bytes.NewReader(decodedBody))
-		if err != nil {
-			return nil, errors.Wrap(err, "error parsing HTML: %s")
-		}
+	// Decode base64 encoded body
+	decodedBody, err := base64.StdEncoding.DecodeString(strings.Trim(string(body), `"`))
+	if err != nil {
+		return nil, err
+	}
 
-		var data []string
-		var extractData func(*html.Node)
-		extractData = func(n *html.Node) {
-			if n.Type == html.ElementNode && n.Data == "td" {
-				text := getTextContent(n)
-				data = append(data, text)
-			}
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				extractData(c)
-			}
-		}
-		extractData(doc)
+	doc, err := html.Parse(bytes.NewReader(decodedBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing HTML: %s")
+	}
 
-		// Store dividend
-		if d, ok := parseData(data); ok {
-			dividends = append(dividends, d)
+	var data []string
+	var extractData func(*html.Node)
+	extractData = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "td" {
+			text := getTextContent(n)
+			data = append(data, text)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			extractData(c)
 		}
 	}
+	extractData(doc)
 
-	return &dividends, nil
+	return data, nil
 }
 
 func parseData(data []string) (rapina.Dividend, bool) {
@@ -272,79 +416,149 @@ func getTextContent(n *html.Node) string {
 	return strings.TrimSpace(textContent)
 }
 
-func (fii *FII) MonthlyReportIDs(code string, n int) ([]id, error) {
-	ids, err := fii.reportIDs(repMonthly, code, n)
-	if err != nil {
-		return []id{}, err
+// Monthly returns the monthly reports of the FII 'code' for 'n' months,
+// starting from the latest released. Reports not found in the DB, or a report
+// newer than the ones stored, are fetched from the server.
+func (fii FII) Monthly(code string, n int) (*[]rapina.Monthly, error) {
+	monthly, months, err := fii.monthlyFromDB(code, n)
+	if err == nil {
+		if months >= n && (*monthly)[0].Date >= latestMonthly(time.Now()) {
+			return monthly, err
+		}
 	}
-	_, err = fii.monthlyReport(code, ids)
+
+	monthly, err = fii.monthlyFromServer(code, n)
 	if err != nil {
-		return []id{}, err
+		return nil, err
+	}
+	for _, m := range *monthly {
+		err := fii.storage.SaveMonthly(m) // Save monthly reports to DB
+		if err != nil {
+			progress.ErrorMsg("Erro ao salvar informe mensal no banco de dados: %s - %v", err, m)
+		}
 	}
 
-	return ids, nil
+	// Load monthly reports from DB to filter results
+	monthly, _, err = fii.monthlyFromDB(code, n)
+	return monthly, err
 }
 
-// monthlyReport parses the FII monthly reports.
-func (fii *FII) monthlyReport(code string, ids []id) (*[]rapina.Monthly, error) {
-	yeld := make(map[string]string, len(ids))
+func (fii FII) monthlyFromDB(code string, n int) (*[]rapina.Monthly, int, error) {
+	var monthly []rapina.Monthly
+	// Monthly reports are released until the middle of the next month
+	for _, monthYear := range rapina.MonthsFromToday(n + 2) {
+		m, err := fii.storage.Monthly(code, monthYear)
+		if err == nil { // ignore errors
+			monthly = append(monthly, *m)
+		}
+		if len(monthly) == n {
+			break
+		}
+	}
 
-	c := colly.NewCollector()
-	c.WithTransport(&http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	})
+	if len(monthly) == 0 {
+		return nil, 0, errors.New("informes mensais não encontrados")
+	}
 
-	c.OnRequest(func(r *colly.Request) {
-		r.Headers.Set("Accept", "text/html")
-	})
+	return &monthly, len(monthly), nil
+}
 
-	c.OnError(func(r *colly.Response, err error) {
-		progress.ErrorMsg("Request URL: %v failed with response: %v\nError: %v", r.Request.URL, string(r.Body), err)
-	})
+// latestMonthly returns the month (YYYY-MM) of the latest monthly report
+// expected on 'now': reports are released until the middle of the next month.
+func latestMonthly(now time.Time) string {
+	month := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if now.Day() <= 15 {
+		month = month.AddDate(0, -1, 0)
+	}
+	return month.Format("2006-01")
+}
 
-	// Handles the html report
-	c.OnHTML("tr", func(e *colly.HTMLElement) {
-		var fieldName string
-		e.ForEach("td", func(_ int, el *colly.HTMLElement) {
-			v := strings.Trim(el.Text, " \r\n")
-			progress.Debug("%q", v)
-			if v != "" {
-				if fieldName == "" {
-					if v[0] < '0' || v[0] > '9' { // Ignore fields starting with number
-						fieldName = v
-					}
-				} else {
-					fmt.Printf("%-30s => %s\n", fieldName, v)
-					yeld[fieldName] = v
-					fieldName = ""
-				}
-			}
-		})
-		progress.Status("----------------------")
-	})
+// monthlyFromServer gets the report IDs for the FII 'code' and then parses
+// the monthly reports for 'n' months, starting from the latest released.
+func (fii *FII) monthlyFromServer(code string, n int) (*[]rapina.Monthly, error) {
+	ids, err := fii.reportIDs(repMonthly, code, n+1)
+	if err != nil {
+		return nil, err
+	}
+	progress.Debug("Report IDs: %v", ids)
+
+	progress.Status("Informes mensais: %s", code)
+	return fii.monthlyReport(code, ids)
+}
 
-	// Get the yeld monthly report given the list of 'report IDs' -- returns HTML
+// monthlyReport parses the FII monthly reports.
+func (fii *FII) monthlyReport(code string, ids []id) (*[]rapina.Monthly, error) {
 	monthly := make([]rapina.Monthly, 0, len(ids))
+
+	client := documentClient()
 	for _, id := range ids {
-		u := fmt.Sprintf("https://fnet.bmfbovespa.com.br/fnet/publico/exibirDocumento?id=%d&cvm=true", id)
-		progress.Debug(u)
-		if err := c.Visit(u); err != nil {
+		data, err := documentData(client, id)
+		if err != nil {
 			return nil, err
 		}
-		// d, err := fii.storage.SaveDividend(yeld)
-		// if err != nil {
-		// 	fii.log.Error("%v", err)
-		// 	continue
-		// }
-		// // fmt.Println("from server", d.Code, d.Date, d.Val)
-		// if d.Code == code {
-		// 	monthly = append(monthly, *d)
-		// }
+
+		if m, ok := parseMonthlyData(data); ok {
+			m.Code = code // the monthly report does not contain the trading code
+			monthly = append(monthly, m)
+		}
 	}
 
 	return &monthly, nil
 }
 
+// parseMonthlyData parses the table cells of a monthly report, where every
+// field name is followed by its value.
+func parseMonthlyData(data []string) (rapina.Monthly, bool) {
+	monthly := rapina.Monthly{}
+	fieldName := ""
+	for _, str := range data {
+		if fieldName == "" {
+			if str != "" && (str[0] < '0' || str[0] > '9') { // ignore item numbers
+				fieldName = str
+			}
+			continue
+		}
+		if str == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(fieldName, "Competência"):
+			if monthly.Date == "" {
+				monthly.Date = fixMonth(str)
+			}
+		case strings.Contains(fieldName, "Patrimônio Líquido"):
+			if monthly.Equity == 0 {
+				monthly.Equity = comma2dot(str)
+			}
+		case strings.Contains(fieldName, "Cotas Emitidas"),
+			strings.Contains(fieldName, "cotas emitidas"):
+			if monthly.Quotas == 0 {
+				monthly.Quotas = comma2dot(str)
+			}
+		case strings.Contains(fieldName, "Valor Patrimonial das Cotas"):
+			if monthly.NAV == 0 {
+				monthly.NAV = comma2dot(str)
+			}
+		}
+		fieldName = ""
+	}
+
+	if monthly.NAV == 0 && monthly.Quotas > 0 {
+		monthly.NAV = monthly.Equity / monthly.Quotas
+	}
+
+	return monthly, monthly.Date != "" && monthly.NAV > 0
+}
+
+// fixMonth converts months from MM/YYYY to YYYY-MM.
+func fixMonth(month string) string {
+	if len(month) != len("04/2021") || month[2] != '/' {
+		return month
+	}
+
+	return month[3:7] + "-" + month[0:2]
+}
+
 // Details returns the FII Details from DB. If not found:
 // fetches from server, stores it in the DB and returns the Details.
 func (fii *FII) Details(fiiCode string) (*rapina.FIIDetails, error) {
@@ -397,6 +611,151 @@ func (fii *FII) Details(fiiCode string) (*rapina.FIIDetails, error) {
 	return fii.storage.Details(fiiCode)
 }
 
+// UpdateList downloads the list of FIIs listed on B3, fetches the details of
+// each fund and stores its main data on the DB. Returns the number of funds
+// stored.
+func (fii *FII) UpdateList() (int, error) {
+	const listFundsURL = `https://sistemaswebb3-listados.b3.com.br/fundsProxy/fundsCall/GetListFundDownload/eyJ0eXBlRnVuZCI6NywicGFnZU51bWJlciI6MSwicGFnZVNpemUiOjIwfQ==`
+
+	progress.Running("Baixando lista de FIIs")
+	resp, err := documentClient().Get(listFundsURL)
+	if err != nil {
+		progress.RunFail()
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		progress.RunFail()
+		return 0, fmt.Errorf("%s: %s", resp.Status, listFundsURL)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		progress.RunFail()
+		return 0, errors.Wrap(err, "lendo lista de FIIs")
+	}
+	acronyms, err := fiiAcronyms(body)
+	if err != nil {
+		progress.RunFail()
+		return 0, err
+	}
+	progress.RunOK()
+
+	const workers = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var count int
+	ch := make(chan string)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for acronym := range ch {
+				details, err := fii.Details(acronym)
+				if err != nil {
+					progress.ErrorMsg("%s: %v", acronym, err)
+					continue
+				}
+				if err := fii.storage.SaveFund(fiiFund(details, fii.administrator(acronym))); err != nil {
+					progress.ErrorMsg("%s: %v", acronym, err)
+					continue
+				}
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, acronym := range acronyms {
+		ch <- acronym
+	}
+	close(ch)
+	wg.Wait()
+
+	return count, nil
+}
+
+// Funds returns the FIIs stored on the DB, filtered by 'segment' if not
+// empty.
+func (fii *FII) Funds(segment string) ([]rapina.FIIFund, error) {
+	return fii.storage.Funds(segment)
+}
+
+// fiiAcronyms parses the B3 list of funds (base64 encoded CSV, quoted) and
+// returns the funds acronyms (e.g. 'HGLG').
+func fiiAcronyms(body []byte) ([]string, error) {
+	unq, err := strconv.Unquote(string(body))
+	if err != nil {
+		unq = string(body)
+	}
+	txt, err := base64.StdEncoding.DecodeString(strings.TrimSpace(unq))
+	if err != nil {
+		return nil, errors.Wrap(err, "lista de FIIs inválida")
+	}
+
+	var acronyms []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(txt), "\n") {
+		p := strings.Split(line, ";")
+		if len(p) <= 3 {
+			continue
+		}
+		code := strings.TrimSpace(p[3])
+		if len(code) == 4 && !seen[code] {
+			seen[code] = true
+			acronyms = append(acronyms, code)
+		}
+	}
+
+	if len(acronyms) == 0 {
+		return nil, errors.New("lista de FIIs vazia")
+	}
+
+	return acronyms, nil
+}
+
+// administrator returns the administrator of the FII 'code' as informed on
+// its latest monthly reports on fnet, or "" if not found. The B3 details only
+// have the fund's own company name.
+func (fii *FII) administrator(code string) string {
+	docs, err := fii.reportDocs(repMonthly, code, time.Now().AddDate(0, -3, 0))
+	if err != nil {
+		progress.Debug("%s: %v", code, err)
+		return ""
+	}
+	for i := len(docs) - 1; i >= 0; i-- {
+		if admin := strings.TrimSpace(docs[i].Admin); admin != "" {
+			return admin
+		}
+	}
+	return ""
+}
+
+// fiiFund extracts the FII main data from its details and the 'admin' name.
+func fiiFund(details *rapina.FIIDetails, admin string) rapina.FIIFund {
+	d := details.DetailFund
+
+	code := strings.TrimSpace(d.TradingCode)
+	if code == "" {
+		code = strings.TrimSpace(d.Acronym) + "11"
+	}
+
+	segment := string(d.Segment)
+	if segment == "" {
+		segment = d.Classification
+	}
+
+	return rapina.FIIFund{
+		Code:          code,
+		Acronym:       strings.TrimSpace(d.Acronym),
+		Name:          strings.TrimSpace(d.TradingName),
+		CNPJ:          strings.TrimSpace(d.CNPJ),
+		Administrator: strings.TrimSpace(admin),
+		Segment:       parsers.FIISegment(segment),
+	}
+}
+
 // Report type
 type repType int
 
@@ -405,13 +764,37 @@ const (
 	repDividends
 )
 
+func (rt repType) String() string {
+	switch rt {
+	case repMonthly:
+		return "monthly"
+	case repDividends:
+		return "dividends"
+	}
+	return ""
+}
+
+// reportIDs returns the IDs of the reports delivered on the last 'n' months.
 func (fii *FII) reportIDs(rt repType, code string, n int) ([]id, error) {
 	n = minmax(n, 1, MAX_N)
-
-	// Parameters to list the report IDs for the last 'n' dividend reports
-	timestamp := strconv.FormatInt(int64(time.Now().UnixNano()/1e6), 10)
 	nMonthAgo := time.Now()
 	nMonthAgo = nMonthAgo.AddDate(0, -n, -nMonthAgo.Day()+1)
+
+	docs, err := fii.reportDocs(rt, code, nMonthAgo)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]id, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+
+	return ids, nil
+}
+
+// reportDocs lists the active reports of the FII 'code' since 'from'.
+func (fii *FII) reportDocs(rt repType, code string, from time.Time) ([]docID, error) {
 	det, err := fii.Details(code)
 	if err != nil {
 		return nil, err
@@ -428,42 +811,51 @@ func (fii *FII) reportIDs(rt repType, code string, n int) ([]id, error) {
 		idCategoriaDocumento = "14"
 		d = "2"
 	} else {
-		return []id{}, errors.New("invalid report type")
-	}
-
-	v := url.Values{
-		"tipoFundo":            []string{"1"},
-		"cnpjFundo":            []string{cnpj},
-		"idTipoDocumento":      []string{idTipoDocumento},
-		"idCategoriaDocumento": []string{idCategoriaDocumento},
-		"d":                    []string{d},
-		"idEspecieDocumento":   []string{"0"},
-		"situacao":             []string{"A"},
-		"s":                    []string{"0"},
-		"l":                    []string{"200"}, // 'n*2' latest reports as other codes may appear (e.g.:ABCD11, ABCD12, ABCD13...)
-		"dataFinal":            []string{time.Now().Format("02/01/2006")},
-		"dataInicial":          []string{nMonthAgo.Format("02/01/2006")},
-		"o[0][dataReferencia]": []string{"asc"},
-		"_":                    []string{timestamp},
-	}
-
-	// Get the 'report IDs' for a given company (CNPJ) -- returns JSON
-	var report Report
-	u := "https://fnet.bmfbovespa.com.br/fnet/publico/pesquisarGerenciadorDocumentosDados?" +
-		v.Encode()
-	progress.Debug("* Report IDs: %s", u)
-	if err := getJSON(u, &report); err != nil {
-		return nil, err
-	}
+		return []docID{}, errors.New("invalid report type")
+	}
+
+	const pageSize, maxPages = 100, 20
+	var docs []docID
+	for start := 0; start < maxPages*pageSize; start += pageSize {
+		// Parameters to list the reports delivered since 'from'
+		timestamp := strconv.FormatInt(int64(time.Now().UnixNano()/1e6), 10)
+		v := url.Values{
+			"tipoFundo":            []string{"1"},
+			"cnpjFundo":            []string{cnpj},
+			"idTipoDocumento":      []string{idTipoDocumento},
+			"idCategoriaDocumento": []string{idCategoriaDocumento},
+			"d":                    []string{d},
+			"idEspecieDocumento":   []string{"0"},
+			"situacao":             []string{"A"},
+			"s":                    []string{strconv.Itoa(start)},
+			"l":                    []string{strconv.Itoa(pageSize)},
+			"dataFinal":            []string{time.Now().Format("02/01/2006")},
+			"dataInicial":          []string{from.Format("02/01/2006")},
+			"o[0][dataReferencia]": []string{"asc"},
+			"_":                    []string{timestamp},
+		}
 
-	var ids []id
-	for _, d := range report.Data {
-		if d.Status == "A" {
-			ids = append(ids, d.ID)
+		// Get the 'report IDs' for a given company (CNPJ) -- returns JSON
+		var report Report
+		u := "https://fnet.bmfbovespa.com.br/fnet/publico/pesquisarGerenciadorDocumentosDados?" +
+			v.Encode()
+		progress.Debug("* Report IDs: %s", u)
+		if err := getJSON(u, &report); err != nil {
+			return nil, err
+		}
+
+		for _, d := range report.Data {
+			if d.Status == "A" {
+				docs = append(docs, d)
+			}
+		}
+
+		if len(report.Data) < pageSize || (report.Total > 0 && start+pageSize >= report.Total) {
+			break
 		}
 	}
 
-	return ids, nil
+	return docs, nil
 }
 
 // minmax returns n limited to [min, max]
@@ -1,7 +1,14 @@
 package fetch
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
+
+	"github.com/dude333/rapina"
 )
 
 func Test_comma2dot(t *testing.T) {
@@ -61,3 +68,197 @@ func Test_FixDate(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseMonthlyData(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []string
+		want   rapina.Monthly
+		wantOk bool
+	}{
+		{
+			name: "should work",
+			data: []string{
+				"Nome do Fundo/Classe:", "FII ABC",
+				"Competência:", "03/2021",
+				"1", "Ativo – R$", "200.000.000,00",
+				"4", "Patrimônio Líquido – R$", "198.000.000,00",
+				"5", "Nº de Cotas Emitidas", "2.000.000",
+				"6", "Valor Patrimonial das Cotas – R$", "99,00",
+			},
+			want:   rapina.Monthly{Date: "2021-03", Equity: 198000000, Quotas: 2000000, NAV: 99},
+			wantOk: true,
+		},
+		{
+			name: "should calculate NAV",
+			data: []string{
+				"Competência:", "04/2021",
+				"Patrimônio Líquido – R$", "1.000,00",
+				"Nº de Cotas Emitidas", "10",
+			},
+			want:   rapina.Monthly{Date: "2021-04", Equity: 1000, Quotas: 10, NAV: 100},
+			wantOk: true,
+		},
+		{
+			name:   "should fail without date",
+			data:   []string{"Valor Patrimonial das Cotas – R$", "99,00"},
+			want:   rapina.Monthly{NAV: 99},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseMonthlyData(tt.data)
+			if ok != tt.wantOk {
+				t.Errorf("parseMonthlyData() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMonthlyData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_latestMonthly(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want string
+	}{
+		{time.Date(2021, 5, 10, 0, 0, 0, 0, time.UTC), "2021-03"},
+		{time.Date(2021, 5, 16, 0, 0, 0, 0, time.UTC), "2021-04"},
+		{time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), "2020-12"},
+		{time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC), "2020-11"},
+	}
+	for _, tt := range tests {
+		if got := latestMonthly(tt.now); got != tt.want {
+			t.Errorf("latestMonthly(%v) = %s, want %s", tt.now.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func Test_fiiAcronyms(t *testing.T) {
+	csv := "Razão Social;Fundo;Segmento;Código\n" +
+		"FII KINEA IP;KINEA IP;;KNIP\n" +
+		"FII CSHG LOG;HGLG;;HGLG\n" +
+		"FII DUP;DUP;;KNIP\n" +
+		"INVALID;X;;ABCDE\n"
+	body := []byte(strconv.Quote(base64.StdEncoding.EncodeToString([]byte(csv))))
+
+	got, err := fiiAcronyms(body)
+	if err != nil {
+		t.Fatalf("fiiAcronyms() error = %v", err)
+	}
+	want := []string{"KNIP", "HGLG"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fiiAcronyms() = %v, want %v", got, want)
+	}
+
+	if _, err := fiiAcronyms([]byte(`"not base64"`)); err == nil {
+		t.Error("fiiAcronyms() expected error")
+	}
+}
+
+func Test_fiiFund(t *testing.T) {
+	var details rapina.FIIDetails
+	stream := []byte(`{"detailFund":{"acronym":"HGLG","tradingName":"FII CSHG LOG",
+		"tradingCode":"HGLG11","cnpj":"11728688000147","companyName":"CSHG LOGÍSTICA FII",
+		"classification":"Logística","segment":null}}`)
+	if err := json.Unmarshal(stream, &details); err != nil {
+		t.Fatal(err)
+	}
+
+	want := rapina.FIIFund{
+		Code:          "HGLG11",
+		Acronym:       "HGLG",
+		Name:          "FII CSHG LOG",
+		CNPJ:          "11728688000147",
+		Administrator: "CREDIT SUISSE HEDGING-GRIFFO CV S/A",
+		Segment:       "logística",
+	}
+	if got := fiiFund(&details, "CREDIT SUISSE HEDGING-GRIFFO CV S/A "); !reflect.DeepEqual(got, want) {
+		t.Errorf("fiiFund() = %+v, want %+v", got, want)
+	}
+
+	// The fund's company name is not its administrator
+	want.Administrator = ""
+	if got := fiiFund(&details, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("fiiFund() without administrator = %+v, want %+v", got, want)
+	}
+}
+
+func Test_docID(t *testing.T) {
+	var docs []docID
+	stream := []byte(`[
+		{"id":1,"dataReferencia":"31/03/2021","dataEntrega":"31/03/2021 18:05","versao":1,"descricaoModalidade":"Apresentação","nomeAdministrador":"BTG PACTUAL"},
+		{"id":2,"dataReferencia":"03/2021","dataEntrega":"02/04/2021 09:00","versao":"2"},
+		{"id":3,"dataReferencia":"30/04/2021","dataEntrega":"30/04/2021 17:00","descricaoModalidade":"Retificação"}
+	]`)
+	if err := json.Unmarshal(stream, &docs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		delivery, refDate, refMonth string
+		rectified                   bool
+	}{
+		{"2021-03-31 18:05", "2021-03-31", "2021-03", false},
+		{"2021-04-02 09:00", "2021-03", "2021-03", true},
+		{"2021-04-30 17:00", "2021-04-30", "2021-04", true},
+	}
+	if docs[0].Admin != "BTG PACTUAL" || docs[1].Admin != "" {
+		t.Errorf("administrators = %q, %q", docs[0].Admin, docs[1].Admin)
+	}
+	for i, w := range want {
+		d := docs[i]
+		if d.delivery() != w.delivery || d.refDate() != w.refDate || d.refMonth() != w.refMonth || d.rectified() != w.rectified {
+			t.Errorf("doc %d: delivery=%q refDate=%q refMonth=%q rectified=%v, want %+v",
+				d.ID, d.delivery(), d.refDate(), d.refMonth(), d.rectified(), w)
+		}
+	}
+}
+
+func Test_docsToFetch(t *testing.T) {
+	docs := []docID{
+		{ID: 4, RefDate: "30/04/2021", DeliveryDate: "30/04/2021 17:00"},
+		{ID: 1, RefDate: "29/01/2021", DeliveryDate: "29/01/2021 18:00"},
+		{ID: 2, RefDate: "26/02/2021", DeliveryDate: "26/02/2021 18:00"},
+		{ID: 3, RefDate: "31/03/2021", DeliveryDate: "31/03/2021 18:00"},
+	}
+	missing := map[string]bool{"2021-01": true}
+
+	var got []id
+	for _, d := range docsToFetch(docs, "2021-03-31 18:00", missing) {
+		got = append(got, d.ID)
+	}
+	if want := []id{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("docsToFetch() = %v, want %v", got, want)
+	}
+
+	got = nil
+	for _, d := range docsToFetch(docs, "", nil) {
+		got = append(got, d.ID)
+	}
+	if want := []id{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("docsToFetch() = %v, want %v", got, want)
+	}
+}
+
+func Test_docVersion(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    docVersion
+		wantErr bool
+	}{
+		{`{"versao": 2}`, 2, false},
+		{`{"versao": "3"}`, 3, false},
+		{`{"versao": null}`, 0, false},
+		{`{"versao": "x"}`, 0, true},
+	}
+	for _, tt := range tests {
+		var d docID
+		err := json.Unmarshal([]byte(tt.json), &d)
+		if (err != nil) != tt.wantErr || d.Version != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v", tt.json, d.Version, err, tt.want)
+		}
+	}
+}
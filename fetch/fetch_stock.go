@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/dude333/rapina"
@@ -32,6 +33,9 @@ type Stock struct {
 	cache   map[string]int // Cache to avoid duplicated fetch on Alpha Vantage server
 	dataDir string         // working directory where files will be stored to be parsed
 	log     rapina.Logger
+
+	mu           sync.Mutex // serializes the downloads (Stock is shared by the batch reports)
+	codesUpdated bool       // stock codes already downloaded
 }
 
 //
@@ -67,6 +71,14 @@ func (s *Stock) Quote(code, date string) (float64, error) {
 		return val, nil // returning data found on db
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check again, the quotes may have been loaded while waiting
+	if val, err = s.store.Quote(code, date); err == nil {
+		return val, nil
+	}
+
 	// Load quotes from B3
 	if err := s.stockQuoteFromB3(date); ifNot(err) {
 		if val, err = s.store.Quote(code, date); ifNot(err) {
@@ -102,6 +114,70 @@ func (s *Stock) Quote(code, date string) (float64, error) {
 	return val, nil
 }
 
+// StoredQuote returns the quote for 'code' on 'date' only if already stored
+// on the database, without downloading it.
+func (s *Stock) StoredQuote(code, date string) (float64, error) {
+	return s.store.Quote(code, date)
+}
+
+// AvgVolume returns the average daily volume of 'code' for the last 'n'
+// business days. The volumes are read from the DB at once, and only the
+// days not found there are downloaded.
+func (s *Stock) AvgVolume(code string, n int) (float64, error) {
+	dates := lastBusinessDays(n)
+	if len(dates) == 0 {
+		return 0, errors.New("nenhum dia útil")
+	}
+	from := dates[len(dates)-1]
+
+	volumes, err := s.store.Volumes(code, from)
+	if err != nil {
+		return 0, err
+	}
+	loaded := false
+	for _, date := range dates {
+		if _, ok := volumes[date]; ok {
+			continue
+		}
+		if _, err := s.Quote(code, date); err == nil { // ignore holidays
+			loaded = true
+		}
+	}
+	if loaded {
+		if volumes, err = s.store.Volumes(code, from); err != nil {
+			return 0, err
+		}
+	}
+
+	var total float64
+	var count int
+	for _, v := range volumes {
+		if v > 0 {
+			total += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, errors.New("volume não encontrado")
+	}
+
+	return total / float64(count), nil
+}
+
+// lastBusinessDays returns the last 'n' business days before today, from
+// the most recent, not considering holidays.
+func lastBusinessDays(n int) []string {
+	var dates []string
+	for d := 1; len(dates) < n && d <= 2*n+7; d++ {
+		date := rapina.LastBusinessDay(d)
+		if len(dates) > 0 && date == dates[len(dates)-1] {
+			continue // weekend
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
+
 //
 // stockQuoteFromB3 downloads the quotes for all companies for the given date,
 // where 'date' format is YYYY-MM-DD.
@@ -195,13 +271,59 @@ func (s *Stock) stockQuoteFromAPIServer(code, date string, apiProvider int) erro
 	return err
 }
 
+// Dividends returns the dividends and JCP per share of the stock 'code' with
+// ex-dividend date from 'from' (YYYY-MM-DD) until today, downloaded from
+// Yahoo Finance. The date of each dividend is the day before the ex-dividend
+// date, the last day to hold the shares to receive it.
+func (s *Stock) Dividends(code, from string) ([]rapina.Dividend, error) {
+	u := yahooDividendsURL(code, from)
+	if u == "" {
+		return nil, fmt.Errorf("data inválida: %q", from)
+	}
+
+	tr := &http.Transport{
+		DisableCompression: true,
+		IdleConnTimeout:    _http_timeout,
+		TLSClientConfig:    &tls.Config{InsecureSkipVerify: true},
+	}
+	client := &http.Client{Transport: tr}
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dividendos de %s: %s", code, resp.Status)
+	}
+
+	list, err := parsers.YahooDividends(resp.Body, code)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if t, err := time.Parse("2006-01-02", list[i].Date); err == nil {
+			list[i].PaymentDate = list[i].Date // payment date not available
+			list[i].Date = t.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+	}
+
+	return list, nil
+}
+
 func (s *Stock) Code(companyName, stockType string) (string, error) {
 	if val, err := s.store.Code(companyName, stockType); err == nil {
 		return val, nil // returning data found on db
 	}
 
-	if err := s.UpdateStockCodes(); err != nil {
-		return "", err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.codesUpdated {
+		if err := s.UpdateStockCodes(); err != nil {
+			return "", err
+		}
+		s.codesUpdated = true
 	}
 
 	return s.store.Code(companyName, stockType)
@@ -296,6 +418,22 @@ func apiURL(provider int, apiKey, code, date string) string {
 	return ""
 }
 
+// yahooDividendsURL returns the URL of the dividends of 'code' from 'from'
+// (YYYY-MM-DD) until today on Yahoo Finance.
+func yahooDividendsURL(code, from string) string {
+	t, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return ""
+	}
+	v := url.Values{}
+	v.Set("period1", fmt.Sprint(t.Unix()))
+	v.Add("period2", fmt.Sprint(time.Now().Unix()))
+	v.Add("interval", "1d")
+	v.Add("events", "div")
+	return fmt.Sprintf("https://query1.finance.yahoo.com/v7/finance/download/%s.SA?%s",
+		code, v.Encode())
+}
+
 func map2str(data map[string]interface{}) string {
 	var buf string
 	for k, v := range data {
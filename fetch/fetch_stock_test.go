@@ -0,0 +1,73 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// volumeStore is a rapina.StockStorage with the volumes on memory. Quote
+// simulates a quote found on the storage, adding its volume.
+type volumeStore struct {
+	volumes      map[string]float64
+	quotes       []string // dates read by Quote
+	volumesCalls int
+}
+
+func (s *volumeStore) Quote(code, date string) (float64, error) {
+	s.quotes = append(s.quotes, date)
+	s.volumes[date] = 400
+	return 10, nil
+}
+
+func (s *volumeStore) Volumes(code, from string) (map[string]float64, error) {
+	s.volumesCalls++
+	volumes := make(map[string]float64)
+	for date, v := range s.volumes {
+		if date >= from {
+			volumes[date] = v
+		}
+	}
+	return volumes, nil
+}
+
+func (s *volumeStore) Code(companyName, stockType string) (string, error) {
+	return "", errors.New("não encontrado")
+}
+
+func (s *volumeStore) Save(stream io.Reader, code string) (int, error) {
+	return 0, nil
+}
+
+func TestStock_AvgVolume(t *testing.T) {
+	days := lastBusinessDays(3)
+	store := &volumeStore{volumes: map[string]float64{
+		days[0]:      100,
+		days[1]:      200,
+		days[2]:      0, // no trades
+		"2000-01-03": 1000,
+	}}
+	s := &Stock{store: store}
+
+	// All days on the storage: no quotes loaded
+	avg, err := s.AvgVolume("ACME3", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avg != 150 || len(store.quotes) != 0 || store.volumesCalls != 1 {
+		t.Errorf("AvgVolume() = %v, quotes loaded %v, %d reads; want 150, none, 1 read",
+			avg, store.quotes, store.volumesCalls)
+	}
+
+	// Only the missing day is loaded
+	store.volumesCalls = 0
+	delete(store.volumes, days[1])
+	avg, err = s.AvgVolume("ACME3", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avg != 250 || len(store.quotes) != 1 || store.quotes[0] != days[1] || store.volumesCalls != 2 {
+		t.Errorf("AvgVolume() = %v, quotes loaded %v, %d reads; want 250, [%s], 2 reads",
+			avg, store.quotes, store.volumesCalls, days[1])
+	}
+}
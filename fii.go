@@ -1,15 +1,26 @@
 package rapina
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 // Dividend contains the stock 'Code', and the 'Date' for the stock dividend 'Val'.
 type Dividend struct {
 	Code        string
 	Date        string
 	PaymentDate string
 	Val         float64
+	RefDate     string // Reference date of the announcement, kept by its rectifications
 }
 
 // Monthly contains the FII monthly report fields
 type Monthly struct {
+	Code   string  // Trading code (e.g. KNIP11)
+	Date   string  // Reference month (YYYY-MM)
+	Equity float64 // Net equity (R$)
+	Quotas float64 // Number of issued quotas
+	NAV    float64 // Net asset value per quota (R$)
 }
 
 // FIIDetails details (ID field: DetailFund.CNPJ)
@@ -38,7 +49,7 @@ type FIIDetails struct {
 		QuotaDateApproved     string      `json:"quotaDateApproved"`
 		Codes                 []string    `json:"codes"`
 		CodesOther            interface{} `json:"codesOther"`
-		Segment               interface{} `json:"segment"`
+		Segment               FIISegment  `json:"segment"`
 	} `json:"detailFund"`
 	ShareHolder struct {
 		ShareHolderName           string `json:"shareHolderName"`
@@ -50,6 +61,29 @@ type FIIDetails struct {
 	} `json:"shareHolder"`
 }
 
+// FIISegment is the FII segment as informed by B3 (e.g. "Logística").
+type FIISegment string
+
+// UnmarshalJSON accepts the segment as a string, ignoring null or any
+// other type sent by the server.
+func (s *FIISegment) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = FIISegment(strings.TrimSpace(str))
+	}
+	return nil
+}
+
+// FIIFund contains the main data of a listed FII.
+type FIIFund struct {
+	Code          string // Trading code (e.g. KNIP11)
+	Acronym       string // e.g. KNIP
+	Name          string
+	CNPJ          string
+	Administrator string
+	Segment       string // Normalized segment (e.g. logística, lajes, papel)
+}
+
 // FIIStorage is the interface that contains the methods needed to parse, save and
 // retrieve FII data to/from a storage.
 type FIIStorage interface {
@@ -58,4 +92,17 @@ type FIIStorage interface {
 
 	Dividends(code, monthYear string) (*[]Dividend, error)
 	SaveDividend(dividend Dividend) error
+	ReplaceDividend(dividend Dividend) error
+
+	LastDocument(code, report string) (string, error)
+	SaveLastDocument(code, report, date string) error
+
+	CheckedMonths(code, report string) (map[string]bool, error)
+	SaveCheckedMonth(code, report, monthYear string) error
+
+	Monthly(code, monthYear string) (*Monthly, error)
+	SaveMonthly(monthly Monthly) error
+
+	Funds(segment string) ([]FIIFund, error)
+	SaveFund(fund FIIFund) error
 }
@@ -0,0 +1,144 @@
+package parsers
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// RuleHits contains the accounts of the annual reports that matched an
+// accounts mapping rule.
+type RuleHits struct {
+	Rule      *AccountRule
+	Accounts  []string // "CD_CONTA DS_CONTA"
+	Companies int
+}
+
+// rulesList returns all rules in order of precedence.
+func (m *AccountsMapping) rulesList() []*AccountRule {
+	var rules []*AccountRule
+	for _, groups := range [][]AccountsGroup{m.Companies, m.Sectors} {
+		for i := range groups {
+			for j := range groups[i].Accounts {
+				rules = append(rules, &groups[i].Accounts[j])
+			}
+		}
+	}
+	for i := range m.Accounts {
+		rules = append(rules, &m.Accounts[i])
+	}
+	return rules
+}
+
+// AccountHits returns, for each rule of the mapping 'm', the accounts of the
+// annual reports that match it. If 'company' is not empty, only the
+// companies with names containing it are considered.
+func AccountHits(db *sql.DB, m *AccountsMapping, company string) ([]RuleHits, error) {
+	query := `SELECT DISTINCT c.NAME, c.CNPJ, d.CD_CONTA, d.DS_CONTA
+	FROM dfp d JOIN companies c ON c.ID = d.ID_CIA
+	WHERE c.NAME LIKE ?;`
+
+	rows, err := db.Query(query, "%"+company+"%")
+	if err != nil {
+		return nil, errors.Wrap(err, "lendo contas do bd")
+	}
+	defer rows.Close()
+
+	accounts := make(map[*AccountRule]map[string]bool)
+	companies := make(map[*AccountRule]map[string]bool)
+	var name, cnpj, cdAccount, dsAccount string
+	for rows.Next() {
+		if err := rows.Scan(&name, &cnpj, &cdAccount, &dsAccount); err != nil {
+			return nil, err
+		}
+		_, rule := m.Match(name, cnpj, cdAccount, dsAccount)
+		if rule == nil {
+			continue
+		}
+		if accounts[rule] == nil {
+			accounts[rule] = make(map[string]bool)
+			companies[rule] = make(map[string]bool)
+		}
+		accounts[rule][cdAccount+" "+dsAccount] = true
+		companies[rule][name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var hits []RuleHits
+	for _, rule := range m.rulesList() {
+		h := RuleHits{Rule: rule, Companies: len(companies[rule])}
+		for acc := range accounts[rule] {
+			h.Accounts = append(h.Accounts, acc)
+		}
+		sort.Strings(h.Accounts)
+		hits = append(hits, h)
+	}
+
+	return hits, nil
+}
+
+// RemapAccounts updates the bookkeeping codes of the accounts stored on the
+// DB using the mapping 'm', so changes on the mapping file do not require
+// the CVM files to be imported again. Returns the number of accounts
+// updated.
+func RemapAccounts(db *sql.DB, m *AccountsMapping) (int, error) {
+	type acct struct {
+		id                   int
+		cdAccount, dsAccount string
+		code                 uint32
+	}
+
+	var count int
+	for _, table := range []string{"dfp", "itr"} {
+		if !hasTable(db, table) {
+			continue
+		}
+
+		query := fmt.Sprintf(`SELECT DISTINCT d.ID_CIA, c.NAME, c.CNPJ, d.CD_CONTA, d.DS_CONTA, d.CODE
+		FROM %s d JOIN companies c ON c.ID = d.ID_CIA;`, table)
+		rows, err := db.Query(query)
+		if err != nil {
+			return count, errors.Wrapf(err, "lendo contas da tabela %s", table)
+		}
+
+		var changed []acct
+		for rows.Next() {
+			var a acct
+			var name, cnpj string
+			if err := rows.Scan(&a.id, &name, &cnpj, &a.cdAccount, &a.dsAccount, &a.code); err != nil {
+				rows.Close()
+				return count, err
+			}
+			if code, _ := m.Match(name, cnpj, a.cdAccount, a.dsAccount); code != a.code {
+				a.code = code
+				changed = append(changed, a)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return count, err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return count, err
+		}
+		update := fmt.Sprintf(`UPDATE %s SET CODE=? WHERE ID_CIA=? AND CD_CONTA=? AND DS_CONTA=?;`, table)
+		for _, a := range changed {
+			if _, err := tx.Exec(update, a.code, a.id, a.cdAccount, a.dsAccount); err != nil {
+				_ = tx.Rollback()
+				return count, errors.Wrapf(err, "atualizando tabela %s", table)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return count, err
+		}
+		count += len(changed)
+	}
+
+	return count, nil
+}
@@ -1,12 +1,21 @@
 package parsers
 
 import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // Bookkeeping account codes
-// If you add new const values, run 'go generate'
-// to update the generated code
+// If you add new const values, add them to 'accountNames'
+// to be used on the accounts mapping file
 const (
 	UNDEF uint32 = iota
 	SPACE
@@ -61,86 +70,334 @@ const (
 
 	// Stock quote from last day of year
 	Quote
+
+	// New codes must be appended below, as the codes above are stored on
+	// the DB
+
+	// Banks and insurance companies
+	ReceitasServicos
+	DespesasPessoal
+	DespesasAdm
+	PremiosGanhos
+	Sinistros
+	CustosAquisicao
+	DespesasTributos
+
+	// Retained earnings (Altman Z-Score)
+	ReservasLucros
+	LucrosAcumulados
+
+	// Piotroski F-Score, compared with the previous year
+	FScore
 )
 
-// account code, description and bookkeeping code
-type account struct {
-	cdAccount string
-	dsAccount string
-	code      uint32
-}
-
-var _accountsTable = []account{
-	// BPA
-	{"1", "Ativo Total", AtivoTotal},
-	{"1.01", "Ativo Circulante", AtivoCirc},
-	{"1.02", "Ativo Não Circulante", AtivoNCirc},
-	{"1.01.01", "Caixa e Equivalentes de Caixa", Caixa},
-	{"1.01.02", "Aplicações Financeiras", AplicFinanceiras},
-	{"1.01.04", "Estoques", Estoque}, // or "Títulos e Créditos a Receber" for security companies
-	{"1.01.03", "Contas a Receber", ContasARecebCirc},
-	{"1.02.01.03", "Contas a Receber", ContasARecebNCirc},
-	{"1.02.01.04", "Contas a Receber", ContasARecebNCirc},
-
-	// BPP
-	{"2", "Passivo Total", PassivoTotal},
-	{"2.01", "Passivo Circulante", PassivoCirc},
-	{"2.02", "Passivo Não Circulante", PassivoNCirc},
-	{"2.*", "Patrimônio Líquido Consolidado", Equity},
-	{"2.01.04", "Empréstimos e Financiamentos", DividaCirc},
-	{"2.02.01", "Empréstimos e Financiamentos", DividaNCirc},
-	{"2.01.05.02.01", "Dividendos e JCP a Pagar", DividendosJCP},
-	{"2.01.05.02.02", "Dividendo Mínimo Obrigatório a Pagar", DividendosMin},
-
-	// DRE
-	{"3.01", "", Vendas},
-	{"3.02", "", CustoVendas},
-	{"3.04", "", DespesasOp},
-	{"3.*", "Resultado Antes do Resultado Financeiro e dos Tributos", EBIT},
-	{"3.06", "Resultado Financeiro", ResulFinanc},
-	{"3.07", "Resultado Financeiro", ResulFinanc},
-	{"3.08", "Resultado Financeiro", ResulFinanc},
-	{"3.10", "Resultado Líquido de Operações Descontinuadas", ResulOpDescont},
-	{"3.11", "Resultado Líquido de Operações Descontinuadas", ResulOpDescont},
-	{"3.12", "Resultado Líquido de Operações Descontinuadas", ResulOpDescont},
-	{"3.*", "Lucro/Prejuízo Consolidado do Período", LucLiq},
-	{"3.*", "Lucro/Prejuízo do Período", LucLiq},
+// accountNames maps the names used on the accounts mapping file to the
+// bookkeeping account codes.
+var accountNames = map[string]uint32{
+	"Caixa":             Caixa,
+	"AplicFinanceiras":  AplicFinanceiras,
+	"Estoque":           Estoque,
+	"Equity":            Equity,
+	"ContasARecebCirc":  ContasARecebCirc,
+	"ContasARecebNCirc": ContasARecebNCirc,
+	"AtivoCirc":         AtivoCirc,
+	"AtivoNCirc":        AtivoNCirc,
+	"AtivoTotal":        AtivoTotal,
+	"PassivoCirc":       PassivoCirc,
+	"PassivoNCirc":      PassivoNCirc,
+	"PassivoTotal":      PassivoTotal,
+	"DividaCirc":        DividaCirc,
+	"DividaNCirc":       DividaNCirc,
+	"DividendosJCP":     DividendosJCP,
+	"DividendosMin":     DividendosMin,
+	"Vendas":            Vendas,
+	"CustoVendas":       CustoVendas,
+	"DespesasOp":        DespesasOp,
+	"EBIT":              EBIT,
+	"ResulFinanc":       ResulFinanc,
+	"ResulOpDescont":    ResulOpDescont,
+	"LucLiq":            LucLiq,
+	"FCO":               FCO,
+	"FCI":               FCI,
+	"FCF":               FCF,
+	"Deprec":            Deprec,
+	"JurosCapProp":      JurosCapProp,
+	"Dividendos":        Dividendos,
+	"ReceitasServicos":  ReceitasServicos,
+	"DespesasPessoal":   DespesasPessoal,
+	"DespesasAdm":       DespesasAdm,
+	"PremiosGanhos":     PremiosGanhos,
+	"Sinistros":         Sinistros,
+	"CustosAquisicao":   CustosAquisicao,
+	"DespesasTributos":  DespesasTributos,
+	"ReservasLucros":    ReservasLucros,
+	"LucrosAcumulados":  LucrosAcumulados,
+}
 
-	// DFC
-	{"6.01", "", FCO},
-	{"6.02", "", FCI},
-	{"6.03", "", FCF},
+//go:embed accounts.yml
+var _defaultAccounts []byte
+
+// AccountRule maps the CVM accounts that match the account code
+// ('Account') and description ('Description') to the bookkeeping account
+// 'Code'. Both accept '*' as wildcard; empty matches any value.
+type AccountRule struct {
+	Code        string `yaml:"codigo"`
+	Account     string `yaml:"conta"`
+	Description string `yaml:"descricao"`
 
-	// DVA
-	{"7.*", "Depreciação, Amortização e Exaustão", Deprec},
-	{"7.*", "Juros sobre o Capital Próprio", JurosCapProp},
-	{"7.*", "Dividendos", Dividendos},
+	code  uint32
+	descr string // lower case description
+	group string // company or sector name, empty for the general rules
 }
 
-// acctCode returns the code based on the account code and
-// account description; if the code is not found in the table
-// returns the hash.
-func acctCode(cdAccount, dsAccount string) uint32 {
-	dsAccount = strings.ToLower(dsAccount)
+// AccountsGroup contains the rules used only for a company or for the
+// companies of a sector. Companies are selected by the CNPJ, if set, or by
+// the exact name.
+type AccountsGroup struct {
+	Name     string        `yaml:"Nome"`
+	CNPJ     string        `yaml:"CNPJ"`
+	Accounts []AccountRule `yaml:"Contas"`
+}
+
+// AccountsMapping contains the rules used to map the CVM accounts to the
+// bookkeeping account codes. Company rules take precedence over sector
+// rules, which take precedence over the general rules; within each list,
+// the first matching rule is used.
+type AccountsMapping struct {
+	Accounts  []AccountRule   `yaml:"Contas"`
+	Companies []AccountsGroup `yaml:"Empresas"`
+	Sectors   []AccountsGroup `yaml:"Setores"`
+
+	sectors map[string][]string       // companies of each sector
+	rules   map[string][]*AccountRule // cache of rules per company
+	mu      sync.Mutex
+}
+
+var _accounts = mustLoadAccountsMapping()
+
+// mustLoadAccountsMapping loads the default accounts mapping, embedded in
+// the binary, panicking if it is invalid.
+func mustLoadAccountsMapping() *AccountsMapping {
+	m, err := LoadAccountsMapping("", nil)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// SetAccountsMapping sets the mapping used to assign the bookkeeping codes
+// to the accounts imported from CVM.
+func SetAccountsMapping(m *AccountsMapping) {
+	if m != nil {
+		_accounts = m
+	}
+}
 
-	for _, acc := range _accountsTable {
-		descr := strings.ToLower(acc.dsAccount)
-		l := len(acc.cdAccount)
-		code := ""
-		if l > 1 && acc.cdAccount[l-1] == '*' {
-			code = acc.cdAccount[:l-1] // remove the '*'
+// LoadAccountsMapping loads the default accounts mapping, extended by the
+// rules from 'filename' (YAML or JSON), if the file exists. The rules of the
+// file take precedence over the default ones. The sectors stored on 'db'
+// (if not nil) are used to find the companies of the sectors listed on the
+// file.
+func LoadAccountsMapping(filename string, db *sql.DB) (*AccountsMapping, error) {
+	m := &AccountsMapping{}
+	if err := yaml.Unmarshal(_defaultAccounts, m); err != nil {
+		return nil, errors.Wrap(err, "mapeamento de contas padrão")
+	}
+
+	if filename != "" {
+		y, err := os.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "lendo arquivo %s", filename)
+		}
+		if err == nil {
+			user := AccountsMapping{}
+			if err := yaml.Unmarshal(y, &user); err != nil {
+				return nil, errors.Wrapf(err, "arquivo %s inválido", filename)
+			}
+			m.Accounts = append(user.Accounts, m.Accounts...)
+			m.Companies = append(user.Companies, m.Companies...)
+			m.Sectors = append(user.Sectors, m.Sectors...)
+		}
+	}
+
+	if err := m.compile(); err != nil {
+		return nil, errors.Wrapf(err, "mapeamento de contas %s", filename)
+	}
+
+	if len(m.Sectors) > 0 && db != nil {
+		sectors, err := sectorCompanies(db)
+		if err != nil {
+			return nil, err
+		}
+		m.sectors = make(map[string][]string)
+		for _, g := range m.Sectors {
+			m.sectors[g.Name] = sectors[strings.ToLower(g.Name)]
+		}
+	}
+
+	return m, nil
+}
+
+// compile validates the rules and converts the code names.
+func (m *AccountsMapping) compile() error {
+	compile := func(rules []AccountRule, group string) error {
+		for i := range rules {
+			r := &rules[i]
+			code, ok := accountNames[r.Code]
+			if !ok {
+				return fmt.Errorf("código inválido: %q", r.Code)
+			}
+			r.code = code
+			r.descr = strings.ToLower(strings.TrimSpace(r.Description))
+			r.group = group
 		}
+		return nil
+	}
+
+	if err := compile(m.Accounts, ""); err != nil {
+		return err
+	}
+	for _, groups := range [][]AccountsGroup{m.Companies, m.Sectors} {
+		for i := range groups {
+			if err := compile(groups[i].Accounts, groups[i].Name); err != nil {
+				return errors.Wrap(err, groups[i].Name)
+			}
+		}
+	}
 
-		if code != "" && strings.HasPrefix(cdAccount, code) {
-			if descr == "" || descr == dsAccount {
-				return acc.code
+	m.rules = make(map[string][]*AccountRule)
+	return nil
+}
+
+// companyRules returns the rules that apply to the 'company' (name and
+// CNPJ), in order of precedence.
+func (m *AccountsMapping) companyRules(company, cnpj string) []*AccountRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := company + "|" + cnpj
+	if rules, ok := m.rules[key]; ok {
+		return rules
+	}
+
+	var rules []*AccountRule
+	for _, g := range m.Companies {
+		if g.matches(company, cnpj) {
+			for i := range g.Accounts {
+				rules = append(rules, &g.Accounts[i])
 			}
-		} else if acc.cdAccount == "" || acc.cdAccount == cdAccount {
-			if descr == "" || descr == dsAccount {
-				return acc.code
+		}
+	}
+	for _, g := range m.Sectors {
+		if company != "" && containsFold(m.sectors[g.Name], company) {
+			for i := range g.Accounts {
+				rules = append(rules, &g.Accounts[i])
 			}
 		}
 	}
+	for i := range m.Accounts {
+		rules = append(rules, &m.Accounts[i])
+	}
+
+	m.rules[key] = rules
+	return rules
+}
+
+// matches returns true if the group refers to the 'company': same CNPJ, if
+// set on the group, or else the same name (ignoring case).
+func (g AccountsGroup) matches(company, cnpj string) bool {
+	if g.CNPJ != "" {
+		return cnpj != "" && onlyDigits(g.CNPJ) == onlyDigits(cnpj)
+	}
+	return company != "" && strings.EqualFold(strings.TrimSpace(g.Name), company)
+}
+
+// containsFold returns true if 'list' contains 's', ignoring the case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the code of the first rule that matches the account code
+// and description of the company (name and CNPJ), and the rule itself. If no
+// rule matches, returns the hash of the account and a nil rule.
+func (m *AccountsMapping) Match(company, cnpj, cdAccount, dsAccount string) (uint32, *AccountRule) {
+	dsAccount = strings.ToLower(dsAccount)
+
+	for _, r := range m.companyRules(company, cnpj) {
+		if r.Account != "" && !wildcardMatch(r.Account, cdAccount) {
+			continue
+		}
+		if r.descr != "" && !wildcardMatch(r.descr, dsAccount) {
+			continue
+		}
+		return r.code, r
+	}
+
+	return Hash(cdAccount + dsAccount), nil
+}
+
+// String returns the rule as shown to the user.
+func (r AccountRule) String() string {
+	s := fmt.Sprintf("%s: %s %q", r.Code, r.Account, r.Description)
+	if r.group != "" {
+		s += " [" + r.group + "]"
+	}
+	return s
+}
+
+// wildcardMatch reports whether 's' matches 'pattern', where '*' matches
+// any sequence of characters.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// acctCode returns the code based on the account code and
+// account description; if the code is not found in the mapping
+// returns the hash.
+func acctCode(company, cnpj, cdAccount, dsAccount string) uint32 {
+	code, _ := _accounts.Match(company, cnpj, cdAccount, dsAccount)
+	return code
+}
 
-	return Hash(cdAccount + dsAccount)
+// AccountNames returns the names of the bookkeeping accounts that can be
+// used on the accounts mapping file.
+func AccountNames() []string {
+	names := make([]string, 0, len(accountNames))
+	for name := range accountNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AccountCode returns the bookkeeping account code by its name (e.g.,
+// "Vendas"), ignoring case.
+func AccountCode(name string) (uint32, bool) {
+	for n, code := range accountNames {
+		if strings.EqualFold(n, name) {
+			return code, true
+		}
+	}
+	return 0, false
 }
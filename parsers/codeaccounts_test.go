@@ -0,0 +1,140 @@
+package parsers
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_wildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"2.01", "2.01", true},
+		{"2.01", "2.01.04", false},
+		{"2.*", "2.03", true},
+		{"2.*", "1.03", false},
+		{"receita*", "receita de vendas", true},
+		{"*vendas", "receita de vendas", true},
+		{"*de*", "receita de vendas", true},
+		{"*de*", "receita", false},
+		{"3.*.01", "3.04.01", true},
+		{"3.*.01", "3.04.02", false},
+		{"*", "", true},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func Test_mustLoadAccountsMapping(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("embedded accounts mapping: %v", r)
+		}
+	}()
+	if m := mustLoadAccountsMapping(); m == nil || len(m.Accounts) == 0 {
+		t.Errorf("mustLoadAccountsMapping() = %+v, want the default rules", m)
+	}
+}
+
+func TestDefaultAccountsMapping(t *testing.T) {
+	m, err := LoadAccountsMapping("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cdAccount string
+		dsAccount string
+		want      uint32
+	}{
+		{"1", "Ativo Total", AtivoTotal},
+		{"1.01.01", "CAIXA E EQUIVALENTES DE CAIXA", Caixa},
+		{"2.03", "Patrimônio Líquido Consolidado", Equity},
+		{"2.01.04", "Empréstimos e Financiamentos", DividaCirc},
+		{"3.01", "Receita de Venda de Bens e/ou Serviços", Vendas},
+		{"3.05", "Resultado Antes do Resultado Financeiro e dos Tributos", EBIT},
+		{"3.11", "Lucro/Prejuízo Consolidado do Período", LucLiq},
+		{"6.01", "Caixa Líquido Atividades Operacionais", FCO},
+		{"7.08.04.02", "Dividendos", Dividendos},
+		{"1.01.09", "Outros Ativos", Hash("1.01.09" + "outros ativos")},
+	}
+	for _, tt := range tests {
+		if got, _ := m.Match("", "", tt.cdAccount, tt.dsAccount); got != tt.want {
+			t.Errorf("Match(%q, %q) = %d, want %d", tt.cdAccount, tt.dsAccount, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAccountsMapping(t *testing.T) {
+	tempDir, _ := os.MkdirTemp("", "rapina-test")
+	defer os.RemoveAll(tempDir)
+
+	db, _ := sectorsDB(t)
+
+	filename := tempDir + "/contas.yml"
+	yaml := []byte(`
+Contas:
+  - {codigo: Vendas, conta: "3.01", descricao: "Receitas da Intermediação*"}
+Empresas:
+  - Nome: TECHNOS S.A.
+    Contas:
+      - {codigo: Estoque, conta: "1.01.05"}
+  - Nome: Grendene
+    CNPJ: 89.850.341/0001-60
+    Contas:
+      - {codigo: Caixa, conta: "1.01.09"}
+Setores:
+  - Nome: Calçados
+    Contas:
+      - {codigo: Estoque, conta: "1.01.04", descricao: "Produtos*"}
+`)
+	if err := os.WriteFile(filename, yaml, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadAccountsMapping(filename, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		company   string
+		cnpj      string
+		cdAccount string
+		dsAccount string
+		want      uint32
+	}{
+		{"", "", "3.01", "Receitas da Intermediação Financeira", Vendas},
+		{"TECHNOS S.A.", "", "1.01.05", "Outros", Estoque},
+		{"TECHNOS", "", "1.01.05", "Outros", Hash("1.01.05" + "outros")},      // similar name
+		{"TECNISA S.A.", "", "1.01.05", "Outros", Hash("1.01.05" + "outros")}, // similar name
+		{"GRENDENE SA", "89850341000160", "1.01.09", "Outros", Caixa},
+		{"GRENDENE", "", "1.01.09", "Outros", Hash("1.01.09" + "outros")}, // CNPJ not matched
+		{"GRENDENE SA", "", "1.01.05", "Outros", Hash("1.01.05" + "outros")},
+		{"GRENDENE SA", "", "1.01.04", "Produtos Acabados", Estoque},
+		{"GRENDENE PARTICIPACOES S.A.", "", "1.01.04", "Produtos Acabados", Hash("1.01.04" + "produtos acabados")},
+		{"TECHNOS S.A.", "", "1.01.04", "Produtos Acabados", Hash("1.01.04" + "produtos acabados")},
+		{"GRENDENE SA", "", "1.01.04", "Estoques", Estoque},
+	}
+	for _, tt := range tests {
+		if got, _ := m.Match(tt.company, tt.cnpj, tt.cdAccount, tt.dsAccount); got != tt.want {
+			t.Errorf("Match(%q, %q, %q) = %d, want %d", tt.company, tt.cdAccount, tt.dsAccount, got, tt.want)
+		}
+	}
+
+	if err := os.WriteFile(filename, []byte(`Contas: [{codigo: Xyz, conta: "1"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAccountsMapping(filename, db); err == nil {
+		t.Error("expected error for invalid code")
+	}
+
+	if _, err := LoadAccountsMapping(tempDir+"/inexistente.yml", nil); err != nil {
+		t.Errorf("missing file should use the default mapping: %v", err)
+	}
+}
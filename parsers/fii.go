@@ -1,55 +1,69 @@
 package parsers
 
-/*
-//
-// FetchFIIs downloads the list of FIIs to get their code (e.g. 'HGLG'),
-// then it uses this code to retrieve its details to get the CNPJ.
-// Original baseURL: https://sistemaswebb3-listados.b3.com.br.
-//
-func FetchFIIList(baseURL string) ([]string, error) {
-	listFundsURL := JoinURL(baseURL, `/fundsProxy/fundsCall/GetListFundDownload/eyJ0eXBlRnVuZCI6NywicGFnZU51bWJlciI6MSwicGFnZVNpemUiOjIwfQ==`)
-	// fundsDetailsURL := `https://sistemaswebb3-listados.b3.com.br/fundsProxy/fundsCall/GetDetailFundSIG`
+import (
+	"strings"
+)
 
-	tr := &http.Transport{
-		DisableCompression: true,
-		IdleConnTimeout:    30 * time.Second,
-		TLSClientConfig:    &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
+// FII segments
+const (
+	SegLogistics   = "logística"
+	SegOffices     = "lajes"
+	SegMalls       = "shoppings"
+	SegPaper       = "papel"
+	SegHybrid      = "híbrido"
+	SegFoF         = "fundo de fundos"
+	SegResidential = "residencial"
+	SegHospital    = "hospitalar"
+	SegHotel       = "hotel"
+	SegAgencies    = "agências"
+	SegDevelopment = "desenvolvimento"
+	SegOthers      = "outros"
+)
 
-	resp, err := client.Get(listFundsURL)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
-	}
+// segmentKeywords maps parts of the segment description informed by B3 (or
+// by the user) to the normalized segment. Order matters. Keywords are matched
+// without accents on the lower case description padded with spaces.
+var segmentKeywords = []struct {
+	keyword, segment string
+}{
+	{"fundo de fundo", SegFoF},
+	{" fof ", SegFoF},
+	{"papel", SegPaper},
+	{"receb", SegPaper},
+	{"titulos", SegPaper},
+	{"val. mob", SegPaper},
+	{" cri ", SegPaper},
+	{"hibrido", SegHybrid},
+	{"misto", SegHybrid},
+	{"log", SegLogistics},
+	{"galp", SegLogistics},
+	{"industrial", SegLogistics},
+	{"laje", SegOffices},
+	{"corporativ", SegOffices},
+	{"escrit", SegOffices},
+	{"shopping", SegMalls},
+	{"resid", SegResidential},
+	{"hospital", SegHospital},
+	{"saude", SegHospital},
+	{"hotel", SegHotel},
+	{"agencia", SegAgencies},
+	{"banc", SegAgencies},
+	{"desenvolv", SegDevelopment},
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	unq, err := strconv.Unquote(string(body))
-	if err != nil {
-		return nil, err
-	}
-	txt, err := base64.StdEncoding.DecodeString(unq)
-	if err != nil {
-		return nil, err
+// FIISegment normalizes the segment description into one of the
+// segments listed above (e.g. "Lajes Corporativas" => "lajes"). Returns an
+// empty string if 'segment' is empty.
+func FIISegment(segment string) string {
+	s := strings.ToLower(strings.TrimSpace(RemoveDiacritics(segment)))
+	if s == "" {
+		return ""
 	}
-
-	var codes []string
-
-	for _, line := range strings.Split(string(txt), "\n") {
-		p := strings.Split(line, ";")
-		if len(p) > 3 && len(p[3]) == 4 {
-			codes = append(codes, p[3])
+	s = " " + s + " "
+	for _, k := range segmentKeywords {
+		if strings.Contains(s, k.keyword) {
+			return k.segment
 		}
 	}
-
-	return codes, nil
+	return SegOthers
 }
-
-
-*/
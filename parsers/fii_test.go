@@ -0,0 +1,31 @@
+package parsers
+
+import "testing"
+
+func TestFIISegment(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    string
+	}{
+		{"", ""},
+		{"Logística", SegLogistics},
+		{"logistica", SegLogistics},
+		{"Lajes Corporativas", SegOffices},
+		{"Shoppings", SegMalls},
+		{"Títulos e Val. Mob.", SegPaper},
+		{"CRI", SegPaper},
+		{"Híbrido", SegHybrid},
+		{"FoF", SegFoF},
+		{"Residencial", SegResidential},
+		{"Hospital", SegHospital},
+		{"Agências de Bancos", SegAgencies},
+		{"Crédito Agro", SegOthers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			if got := FIISegment(tt.segment); got != tt.want {
+				t.Errorf("FIISegment(%q) = %q, want %q", tt.segment, got, tt.want)
+			}
+		})
+	}
+}
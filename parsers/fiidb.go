@@ -28,6 +28,10 @@ type FIIParser struct {
 // NewFII creates a new instace of FII.
 func NewFII(db *sql.DB, log rapina.Logger) (*FIIParser, error) {
 	err := createAllTables(db)
+	if err == nil {
+		// Tables created before the dividends kept the announcement reference
+		err = addColumn(db, "fii_dividends", "ref_date", "varchar(10)")
+	}
 	return &FIIParser{
 		db:  db,
 		log: log,
@@ -105,7 +109,7 @@ func (fii *FIIParser) Dividends(code, monthYear string) (*[]rapina.Dividend, err
 	fii.mu.Lock()
 	defer fii.mu.Unlock()
 
-	const s = `SELECT trading_code, base_date, value
+	const s = `SELECT trading_code, base_date, payment_date, value, ref_date
 	FROM fii_dividends 
 	WHERE trading_code=$1 
 	AND base_date LIKE $2;`
@@ -118,10 +122,11 @@ func (fii *FIIParser) Dividends(code, monthYear string) (*[]rapina.Dividend, err
 	dividends := []rapina.Dividend{}
 	var (
 		tradingCode, baseDate string
+		paymentDate, refDate  sql.NullString
 		value                 float64
 	)
 	for rows.Next() {
-		err := rows.Scan(&tradingCode, &baseDate, &value)
+		err := rows.Scan(&tradingCode, &baseDate, &paymentDate, &value, &refDate)
 		if err != nil {
 			return nil, err
 		}
@@ -129,9 +134,11 @@ func (fii *FIIParser) Dividends(code, monthYear string) (*[]rapina.Dividend, err
 		// fii.log.Debug("reading: %v %v %v", tradingCode, baseDate, value)
 
 		dividends = append(dividends, rapina.Dividend{
-			Code: tradingCode,
-			Date: baseDate,
-			Val:  value,
+			Code:        tradingCode,
+			Date:        baseDate,
+			PaymentDate: paymentDate.String,
+			Val:         value,
+			RefDate:     refDate.String,
 		})
 	}
 
@@ -156,12 +163,263 @@ func (fii *FIIParser) SaveDividend(dividend rapina.Dividend) error {
 	}
 
 	const insert = `INSERT OR IGNORE INTO fii_dividends 
-	(trading_code, base_date, payment_date, value) VALUES (?,?,?,?)`
-	_, err := fii.db.Exec(insert, dividend.Code, dividend.Date, dividend.PaymentDate, dividend.Val)
+	(trading_code, base_date, payment_date, value, ref_date) VALUES (?,?,?,?,?)`
+	_, err := fii.db.Exec(insert, dividend.Code, dividend.Date, dividend.PaymentDate, dividend.Val, dividend.RefDate)
 
 	return errors.Wrap(err, "inserting data on fii_dividends")
 }
 
+// ReplaceDividend stores the dividend from a rectified announcement,
+// replacing the dividend of the original announcement, found by its
+// reference date, even if the rectification changed the base date. Dividends
+// stored without the reference date are replaced by the base date. Other
+// dividends of the month (e.g., extra or amortization payments) are kept.
+func (fii *FIIParser) ReplaceDividend(dividend rapina.Dividend) error {
+	if !rapina.IsDate(dividend.Date) {
+		return fmt.Errorf("data-base inválida: %q", dividend.Date)
+	}
+
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	if err := createTable(fii.db, "fii_dividends"); err != nil {
+		return err
+	}
+
+	tx, err := fii.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "replacing data on fii_dividends")
+	}
+
+	const del = `DELETE FROM fii_dividends WHERE trading_code=?
+	AND (base_date=? OR (ref_date<>'' AND ref_date=?))`
+	if _, err := tx.Exec(del, dividend.Code, dividend.Date, dividend.RefDate); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "deleting data from fii_dividends")
+	}
+
+	const insert = `INSERT INTO fii_dividends
+	(trading_code, base_date, payment_date, value, ref_date) VALUES (?,?,?,?,?)`
+	_, err = tx.Exec(insert, dividend.Code, dividend.Date, dividend.PaymentDate, dividend.Val, dividend.RefDate)
+	if err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "inserting data on fii_dividends")
+	}
+
+	return errors.Wrap(tx.Commit(), "replacing data on fii_dividends")
+}
+
+// addColumn adds the 'column' to the 'table' if missing.
+func addColumn(db *sql.DB, table, column, colType string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return errors.Wrapf(err, "lendo colunas de %s", table)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, colType))
+	return errors.Wrapf(err, "adicionando coluna %s em %s", column, table)
+}
+
+// LastDocument returns the delivery date (YYYY-MM-DD HH:MM) of the latest
+// 'report' document fetched for 'code'.
+func (fii *FIIParser) LastDocument(code, report string) (string, error) {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	const s = `SELECT last_date FROM fii_documents WHERE trading_code=? AND report=?;`
+
+	var date string
+	err := fii.db.QueryRow(s, code, report).Scan(&date)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "lendo último documento do bd")
+	}
+
+	return date, nil
+}
+
+// SaveLastDocument stores the delivery date of the latest 'report' document
+// fetched for 'code'.
+func (fii *FIIParser) SaveLastDocument(code, report, date string) error {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	if err := createTable(fii.db, "fii_documents"); err != nil {
+		return err
+	}
+
+	const insert = `INSERT OR REPLACE INTO fii_documents
+	(trading_code, report, last_date) VALUES (?,?,?)`
+	_, err := fii.db.Exec(insert, code, report, date)
+
+	return errors.Wrap(err, "inserting data on fii_documents")
+}
+
+// CheckedMonths returns the months (YYYY-MM) already queried on the server
+// for the 'report' of 'code' with no document found.
+func (fii *FIIParser) CheckedMonths(code, report string) (map[string]bool, error) {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	const s = `SELECT ref_month FROM fii_checked_months WHERE trading_code=? AND report=?;`
+	rows, err := fii.db.Query(s, code, report)
+	if err != nil {
+		return nil, errors.Wrap(err, "lendo meses verificados do bd")
+	}
+	defer rows.Close()
+
+	months := make(map[string]bool)
+	for rows.Next() {
+		var month string
+		if err := rows.Scan(&month); err != nil {
+			return nil, err
+		}
+		months[month] = true
+	}
+
+	return months, rows.Err()
+}
+
+// SaveCheckedMonth stores a month (YYYY-MM) queried on the server for the
+// 'report' of 'code' with no document found, so it is not queried again.
+func (fii *FIIParser) SaveCheckedMonth(code, report, monthYear string) error {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	if err := createTable(fii.db, "fii_checked_months"); err != nil {
+		return err
+	}
+
+	const insert = `INSERT OR IGNORE INTO fii_checked_months
+	(trading_code, report, ref_month) VALUES (?,?,?)`
+	_, err := fii.db.Exec(insert, code, report, monthYear)
+
+	return errors.Wrap(err, "inserting data on fii_checked_months")
+}
+
+// Monthly returns the monthly report of 'code' for 'monthYear' (YYYY-MM)
+// from the db.
+func (fii *FIIParser) Monthly(code, monthYear string) (*rapina.Monthly, error) {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	const s = `SELECT trading_code, ref_month, equity, quotas, nav
+	FROM fii_monthly
+	WHERE trading_code=$1
+	AND ref_month=$2;`
+
+	var m rapina.Monthly
+	err := fii.db.QueryRow(s, code, monthYear).Scan(
+		&m.Code, &m.Date, &m.Equity, &m.Quotas, &m.NAV)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "lendo informe mensal do bd")
+	}
+
+	return &m, nil
+}
+
+// SaveMonthly stores the monthly report in the db, replacing the values
+// previously stored for the same code and month.
+func (fii *FIIParser) SaveMonthly(monthly rapina.Monthly) error {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	if err := createTable(fii.db, "fii_monthly"); err != nil {
+		return err
+	}
+
+	const insert = `INSERT OR REPLACE INTO fii_monthly
+	(trading_code, ref_month, equity, quotas, nav) VALUES (?,?,?,?,?)`
+	_, err := fii.db.Exec(insert, monthly.Code, monthly.Date,
+		monthly.Equity, monthly.Quotas, monthly.NAV)
+
+	return errors.Wrap(err, "inserting data on fii_monthly")
+}
+
+// Funds returns the listed FIIs from the db, filtered by the normalized
+// 'segment' if not empty.
+func (fii *FIIParser) Funds(segment string) ([]rapina.FIIFund, error) {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	s := `SELECT trading_code, acronym, name, cnpj, administrator, segment
+	FROM fii_list`
+	var args []interface{}
+	if segment != "" {
+		s += ` WHERE segment=?`
+		args = append(args, FIISegment(segment))
+	}
+	s += ` ORDER BY trading_code;`
+
+	rows, err := fii.db.Query(s, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "lendo lista de FIIs do bd")
+	}
+	defer rows.Close()
+
+	var funds []rapina.FIIFund
+	for rows.Next() {
+		var f rapina.FIIFund
+		err := rows.Scan(&f.Code, &f.Acronym, &f.Name, &f.CNPJ, &f.Administrator, &f.Segment)
+		if err != nil {
+			return nil, err
+		}
+		funds = append(funds, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(funds) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return funds, nil
+}
+
+// SaveFund stores the FII main data in the db, replacing the values
+// previously stored for the same code.
+func (fii *FIIParser) SaveFund(fund rapina.FIIFund) error {
+	fii.mu.Lock()
+	defer fii.mu.Unlock()
+
+	if err := createTable(fii.db, "fii_list"); err != nil {
+		return err
+	}
+
+	const insert = `INSERT OR REPLACE INTO fii_list
+	(trading_code, acronym, name, cnpj, administrator, segment) VALUES (?,?,?,?,?,?)`
+	_, err := fii.db.Exec(insert, fund.Code, fund.Acronym, fund.Name,
+		fund.CNPJ, fund.Administrator, FIISegment(fund.Segment))
+
+	return errors.Wrap(err, "inserting data on fii_list")
+}
+
 func (fii *FIIParser) SelectFIIDetails(code string) (*rapina.FIIDetails, error) {
 	if fii.db == nil {
 		return nil, ErrDBUnset
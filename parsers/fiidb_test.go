@@ -0,0 +1,169 @@
+package parsers
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dude333/rapina"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestFIIParser_ReplaceDividend(t *testing.T) {
+	fileDB := tempFilename(t)
+	defer os.Remove(fileDB)
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatalf("Fail to open db: %v", err)
+	}
+	defer db.Close()
+
+	fii, err := NewFII(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := rapina.Dividend{Code: "ABCD11", Date: "2021-03-31", PaymentDate: "2021-04-14", Val: 1}
+	if err := fii.SaveDividend(original); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new save does not change the original value
+	if err := fii.SaveDividend(rapina.Dividend{Code: "ABCD11", Date: "2021-03-31", Val: 2}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fii.Dividends("ABCD11", "2021-03")
+	if err != nil || !reflect.DeepEqual(*got, []rapina.Dividend{original}) {
+		t.Fatalf("Dividends() = %v, %v, want %v", got, err, original)
+	}
+
+	// Extra payment on another date of the same month
+	extra := rapina.Dividend{Code: "ABCD11", Date: "2021-03-15", PaymentDate: "2021-03-25", Val: 0.5}
+	if err := fii.SaveDividend(extra); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rectified dividend replaces only the one with the same base date
+	rectified := rapina.Dividend{Code: "ABCD11", Date: "2021-03-31", PaymentDate: "2021-04-15", Val: 1.1}
+	if err := fii.ReplaceDividend(rectified); err != nil {
+		t.Fatal(err)
+	}
+	got, err = fii.Dividends("ABCD11", "2021-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(*got, func(i, j int) bool { return (*got)[i].Date < (*got)[j].Date })
+	if want := []rapina.Dividend{extra, rectified}; !reflect.DeepEqual(*got, want) {
+		t.Errorf("Dividends() = %v, want %v", *got, want)
+	}
+}
+
+func TestFIIParser_ReplaceDividendBaseDate(t *testing.T) {
+	fileDB := tempFilename(t)
+	defer os.Remove(fileDB)
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatalf("Fail to open db: %v", err)
+	}
+	defer db.Close()
+
+	// Table created before the reference date was kept
+	_, err = db.Exec(`CREATE TABLE fii_dividends (trading_code varchar(12) NOT NULL,
+		base_date varchar(10) NOT NULL, payment_date varchar(10), value real);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fii, err := NewFII(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := rapina.Dividend{Code: "ABCD11", Date: "2021-03-31", PaymentDate: "2021-04-14", Val: 1, RefDate: "2021-03-31"}
+	extra := rapina.Dividend{Code: "ABCD11", Date: "2021-03-15", PaymentDate: "2021-03-25", Val: 0.5, RefDate: "2021-03-15"}
+	for _, d := range []rapina.Dividend{original, extra} {
+		if err := fii.SaveDividend(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The rectification moves the base date: the original must not remain
+	rectified := rapina.Dividend{Code: "ABCD11", Date: "2021-03-30", PaymentDate: "2021-04-14", Val: 1, RefDate: "2021-03-31"}
+	if err := fii.ReplaceDividend(rectified); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fii.Dividends("ABCD11", "2021-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(*got, func(i, j int) bool { return (*got)[i].Date < (*got)[j].Date })
+	if want := []rapina.Dividend{extra, rectified}; !reflect.DeepEqual(*got, want) {
+		t.Errorf("Dividends() = %v, want %v", *got, want)
+	}
+}
+
+func TestFIIParser_LastDocument(t *testing.T) {
+	fileDB := tempFilename(t)
+	defer os.Remove(fileDB)
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatalf("Fail to open db: %v", err)
+	}
+	defer db.Close()
+
+	fii, err := NewFII(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fii.LastDocument("ABCD11", "dividends"); err != ErrNotFound {
+		t.Errorf("LastDocument() error = %v, want %v", err, ErrNotFound)
+	}
+
+	for _, date := range []string{"2021-03-31 18:00", "2021-04-30 17:30"} {
+		if err := fii.SaveLastDocument("ABCD11", "dividends", date); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := fii.LastDocument("ABCD11", "dividends"); err != nil || got != "2021-04-30 17:30" {
+		t.Errorf("LastDocument() = %q, %v", got, err)
+	}
+	if _, err := fii.LastDocument("ABCD11", "monthly"); err != ErrNotFound {
+		t.Errorf("LastDocument() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestFIIParser_CheckedMonths(t *testing.T) {
+	fileDB := tempFilename(t)
+	defer os.Remove(fileDB)
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatalf("Fail to open db: %v", err)
+	}
+	defer db.Close()
+
+	fii, err := NewFII(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, month := range []string{"2021-01", "2021-02", "2021-01"} {
+		if err := fii.SaveCheckedMonth("ABCD11", "dividends", month); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]bool{"2021-01": true, "2021-02": true}
+	if got, err := fii.CheckedMonths("ABCD11", "dividends"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckedMonths() = %v, %v, want %v", got, err, want)
+	}
+	if got, err := fii.CheckedMonths("ABCD11", "monthly"); err != nil || len(got) != 0 {
+		t.Errorf("CheckedMonths() = %v, %v, want empty", got, err)
+	}
+}
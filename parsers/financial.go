@@ -8,6 +8,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,7 +41,7 @@ func ImportCsv(db *sql.DB, dataType string, file string) (err error) {
 	// Check table version, wipe it if version differs from current version, and
 	// (re)create the table
 	for _, t := range []string{dataType, "MD5"} {
-		if v, table := dbVersion(db, t); v != currentDbVersion {
+		if v, table := dbVersion(db, t); v != currentDbVersion && !upgradeTable(db, t, v) {
 			if v > 0 {
 				fmt.Printf("[i] Apagando tabela %s versão %d (versão atual: %d)\n", table, v, currentDbVersion)
 			}
@@ -208,6 +209,32 @@ func populateTable(db *sql.DB, dataType, file string) (int, error) {
 	return count, err
 }
 
+// StoredScale is the scale (ESCALA_MOEDA) of the values stored on the DB:
+// all values are converted to thousands of R$ on import, regardless of the
+// scale used by the company.
+const StoredScale = "MILHAR"
+
+// scaleFactor returns the factor to convert values on 'scale' to thousands.
+func scaleFactor(scale string) float64 {
+	switch scale {
+	case "UNIDADE":
+		return 0.001
+	case "MILHAO":
+		return 1000
+	}
+	return 1 // MIL, MILHAR
+}
+
+// normalizeValue converts 'value' on 'scale' to thousands. Returns the value
+// unchanged if it is not a number.
+func normalizeValue(value, scale string) interface{} {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	return v * scaleFactor(scale)
+}
+
 // Cache (optimization)
 var unixTime = make(map[string]int64)
 
@@ -281,19 +308,22 @@ func prepareFields(dataType string, header map[string]int, fields []string, comp
 	// Unique value to be used as PRIMARY KEY
 	hash := Hash(cnpj + val("GRUPO_DFP") + val("DT_FIM_EXERC") + val("VERSAO") + val("CD_CONTA") + val("VL_CONTA"))
 
+	// Bookkeeping code, based on the accounts mapping
+	code := acctCode(c.name, cnpj, val("CD_CONTA"), val("DS_CONTA"))
+
 	// Output -- need to follow INSERT sequence
 	f := make([]interface{}, 11)
 	f[0] = hash                                                             // ID
 	f[1] = companyID                                                        // ID_CIA
-	f[2] = acctCode(fields[header["CD_CONTA"]], fields[header["DS_CONTA"]]) // CODE
+	f[2] = code                                                             // CODE
 	f[3] = year                                                             // YEAR
 	f[4] = val("VERSAO")
 	f[5] = val("MOEDA")
-	f[6] = val("ESCALA_MOEDA")
+	f[6] = StoredScale
 	f[7] = tim("DT_FIM_EXERC")
 	f[8] = val("CD_CONTA")
 	f[9] = val("DS_CONTA")
-	f[10] = val("VL_CONTA")
+	f[10] = normalizeValue(val("VL_CONTA"), val("ESCALA_MOEDA"))
 
 	return f, nil
 }
@@ -214,3 +214,62 @@ func BenchmarkPrepareFields(b *testing.B) {
 		}
 	}
 }
+
+func Test_normalizeValue(t *testing.T) {
+	tests := []struct {
+		value string
+		scale string
+		want  interface{}
+	}{
+		{"1234.00", "MILHAR", 1234.0},
+		{"1234.00", "MIL", 1234.0},
+		{"1234000", "UNIDADE", 1234.0},
+		{"1.5", "MILHAO", 1500.0},
+		{"", "UNIDADE", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeValue(tt.value, tt.scale); got != tt.want {
+			t.Errorf("normalizeValue(%q, %q) = %v, want %v", tt.value, tt.scale, got, tt.want)
+		}
+	}
+}
+
+func Test_upgradeTable(t *testing.T) {
+	fileDB := tempFilename(t)
+	defer os.Remove(fileDB)
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatalf("Fail to open db: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTable(db, "STATUS"); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTable(db, "dfp"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO dfp (ID, ESCALA_MOEDA, VL_CONTA, VERSAO)
+		VALUES (1, 'UNIDADE', 5000, 1), (2, 'MILHAR', 7, 1), (3, 'UNIDADE', 6000, 2);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if upgradeTable(db, "BPA", 200101) {
+		t.Error("upgradeTable: unknown version should not be upgraded")
+	}
+	if !upgradeTable(db, "BPA", 210514) {
+		t.Fatal("upgradeTable: expected upgrade")
+	}
+
+	var count int
+	var total float64
+	err = db.QueryRow(`SELECT COUNT(*), SUM(VL_CONTA) FROM dfp WHERE ESCALA_MOEDA = ?`, StoredScale).Scan(&count, &total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || total != 18 {
+		t.Errorf("upgradeTable: got %d rows totaling %f, want 3 rows totaling 18", count, total)
+	}
+}
@@ -0,0 +1,114 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed ipca.csv
+var _defaultIPCA []byte
+
+// Inflation contains the yearly inflation rates (e.g., 0.0462 for 4.62%).
+type Inflation map[int]float64
+
+// LoadInflation loads the yearly IPCA rates bundled with rapina, replaced
+// by the rates found on 'filename' (if it exists). The file has one year per
+// line, in the format "ano;ipca", with the rate in % (e.g., "2023;4,62").
+func LoadInflation(filename string) (Inflation, error) {
+	infl := make(Inflation)
+	if err := infl.read(bytes.NewReader(_defaultIPCA)); err != nil {
+		return nil, errors.Wrap(err, "tabela do IPCA padrão")
+	}
+
+	if filename != "" {
+		f, err := os.Open(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "lendo arquivo %s", filename)
+		}
+		if err == nil {
+			defer f.Close()
+			if err := infl.read(f); err != nil {
+				return nil, errors.Wrapf(err, "arquivo %s inválido", filename)
+			}
+		}
+	}
+
+	return infl, nil
+}
+
+// read parses the csv rates, skipping comments and the header.
+func (infl Inflation) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || strings.HasPrefix(strings.ToLower(line), "ano") {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		if len(fields) != 2 {
+			return fmt.Errorf("linha %d: formato esperado ano;ipca", n)
+		}
+		year, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return fmt.Errorf("linha %d: ano inválido: %s", n, fields[0])
+		}
+		rate := strings.ReplaceAll(strings.TrimSpace(fields[1]), ",", ".")
+		v, err := strconv.ParseFloat(strings.TrimSuffix(rate, "%"), 64)
+		if err != nil {
+			return fmt.Errorf("linha %d: IPCA inválido: %s", n, fields[1])
+		}
+		infl[year] = v / 100
+	}
+	return scanner.Err()
+}
+
+// LastYear returns the last year with a known rate.
+func (infl Inflation) LastYear() int {
+	last := 0
+	for y := range infl {
+		if y > last {
+			last = y
+		}
+	}
+	return last
+}
+
+// Deflator returns the factor used to convert the nominal values of 'year'
+// to the currency of 'base' (values at the end of each year). Rates after
+// the last known year are taken as zero, so the values of the current year
+// can be reported before the IPCA is published.
+func (infl Inflation) Deflator(year, base int) (float64, error) {
+	if _, ok := infl[base]; !ok {
+		return 0, fmt.Errorf("IPCA de %d não disponível", base)
+	}
+
+	last := infl.LastYear()
+	from, to := year, base
+	if year > base {
+		from, to = base, year
+	}
+
+	f := 1.0
+	for y := from + 1; y <= to; y++ {
+		rate, ok := infl[y]
+		if !ok && y <= last {
+			return 0, fmt.Errorf("IPCA de %d não disponível", y)
+		}
+		f *= 1 + rate
+	}
+
+	if year > base {
+		return 1 / f, nil
+	}
+	return f, nil
+}
@@ -0,0 +1,66 @@
+package parsers
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadInflation(t *testing.T) {
+	infl, err := LoadInflation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := infl[2023]; math.Abs(got-0.0462) > 1e-9 {
+		t.Errorf("IPCA 2023 = %v, want 0.0462", got)
+	}
+
+	file := filepath.Join(t.TempDir(), "ipca.csv")
+	data := "ano;ipca\n2023;5\n2099;3,5%\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	infl, err = LoadInflation(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infl[2023] != 0.05 || infl[2099] != 0.035 || infl[2010] == 0 {
+		t.Errorf("unexpected rates: 2023=%v 2099=%v 2010=%v", infl[2023], infl[2099], infl[2010])
+	}
+
+	if err := os.WriteFile(file, []byte("2023;abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadInflation(file); err == nil {
+		t.Error("expected error for invalid rate")
+	}
+}
+
+func TestInflation_Deflator(t *testing.T) {
+	infl := Inflation{2020: 0.10, 2021: 0.20, 2022: 0.05}
+
+	tests := []struct {
+		year, base int
+		want       float64
+		wantErr    bool
+	}{
+		{2022, 2022, 1, false},
+		{2020, 2022, 1.2 * 1.05, false},
+		{2021, 2022, 1.05, false},
+		{2022, 2020, 1 / (1.2 * 1.05), false},
+		{2023, 2022, 1, false}, // rate after the last known year is zero
+		{2018, 2022, 0, true},  // 2019 is unknown
+		{2020, 2023, 0, true},  // unknown base year
+	}
+	for _, tt := range tests {
+		got, err := infl.Deflator(tt.year, tt.base)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Deflator(%d, %d) error = %v, wantErr %v", tt.year, tt.base, err, tt.wantErr)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Deflator(%d, %d) = %v, want %v", tt.year, tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package parsers
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LinkCompanies returns the names (as stored on the companies table) of the
+// companies on 'list', identified by the CNPJ, the trading code or the
+// name (ignoring case, diacritics, spaces and punctuation), and the items
+// that were not found.
+func LinkCompanies(db *sql.DB, list []string) (names, missing []string, err error) {
+	l, err := newCompanyLinker(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, item := range list {
+		if cnpj, ok := l.find(item); ok {
+			names = append(names, l.cvm[cnpj])
+		} else {
+			missing = append(missing, item)
+		}
+	}
+
+	return names, missing, nil
+}
+
+// find returns the CNPJ of the company identified by its CNPJ, trading code
+// or name.
+func (l *companyLinker) find(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	c := SectorCompany{Name: s}
+	switch {
+	case len(onlyDigits(s)) == 14:
+		c.CNPJ = s
+	case l.tickers[strings.ToUpper(s)] != "":
+		c.Ticker = s
+	}
+
+	cnpj, _, ok := l.link(c)
+	return cnpj, ok
+}
+
+// AddPeers adds the companies (CNPJ, trading code or name) to the peer
+// 'group', creating the group if necessary. Returns the companies that were
+// not found on the DB.
+func AddPeers(db *sql.DB, group string, companies []string) (missing []string, err error) {
+	group = peerGroupName(group)
+	if group == "" {
+		return nil, errors.New("nome do grupo inválido")
+	}
+	for _, t := range []string{"status", "peer_groups"} {
+		if err := createTable(db, t); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := newCompanyLinker(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, co := range companies {
+		cnpj, ok := l.find(co)
+		if !ok {
+			missing = append(missing, co)
+			continue
+		}
+		_, err := db.Exec(`INSERT OR IGNORE INTO peer_groups (GROUP_NAME, CNPJ) VALUES (?,?);`, group, cnpj)
+		if err != nil {
+			return nil, errors.Wrap(err, "falha ao inserir empresa no grupo")
+		}
+	}
+
+	return missing, nil
+}
+
+// RemovePeers removes the companies (CNPJ, trading code or name) from the
+// peer 'group', or the whole group if 'companies' is empty.
+func RemovePeers(db *sql.DB, group string, companies []string) error {
+	group = peerGroupName(group)
+	if !hasTable(db, "peer_groups") {
+		return errors.Errorf("grupo '%s' não encontrado", group)
+	}
+
+	if len(companies) == 0 {
+		res, err := db.Exec(`DELETE FROM peer_groups WHERE GROUP_NAME = ?;`, group)
+		if err != nil {
+			return errors.Wrap(err, "falha ao apagar grupo")
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errors.Errorf("grupo '%s' não encontrado", group)
+		}
+		return nil
+	}
+
+	l, err := newCompanyLinker(db)
+	if err != nil {
+		return err
+	}
+	for _, co := range companies {
+		cnpj, ok := l.find(co)
+		if !ok {
+			return errors.Errorf("empresa '%s' não encontrada", co)
+		}
+		_, err := db.Exec(`DELETE FROM peer_groups WHERE GROUP_NAME = ? AND CNPJ = ?;`, group, cnpj)
+		if err != nil {
+			return errors.Wrap(err, "falha ao remover empresa do grupo")
+		}
+	}
+
+	return nil
+}
+
+// PeerGroups returns the peer groups stored on the DB with their companies
+// (names as stored on the companies table, sorted).
+func PeerGroups(db *sql.DB) (map[string][]string, error) {
+	groups := make(map[string][]string)
+	if !hasTable(db, "peer_groups") {
+		return groups, nil
+	}
+
+	rows, err := db.Query(`SELECT p.GROUP_NAME, c.NAME
+	FROM peer_groups p JOIN companies c ON c.CNPJ = p.CNPJ;`)
+	if err != nil {
+		return nil, errors.Wrap(err, "falha ao ler grupos")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group, name string
+		if err := rows.Scan(&group, &name); err != nil {
+			return nil, err
+		}
+		groups[group] = append(groups[group], name)
+	}
+	for _, list := range groups {
+		sort.Strings(list)
+	}
+
+	return groups, rows.Err()
+}
+
+// peerGroupName returns the group name in lower case, as the keys of the
+// config file.
+func peerGroupName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,70 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPeerGroups(t *testing.T) {
+	db, _ := sectorsDB(t)
+
+	missing, err := AddPeers(db, "Calçados Premium", []string{
+		"Grendene S.A.",      // name
+		"61079117000105",     // CNPJ
+		"tecn3",              // trading code
+		"GRENDENE PARTICIP.", // not found (no fuzzy match)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"GRENDENE PARTICIP."}; !reflect.DeepEqual(missing, exp) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", exp, missing)
+	}
+	if _, err := AddPeers(db, "calçados premium", []string{"GRENDENE SA"}); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := PeerGroups(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"calçados premium": {"ALPARGATAS S.A.", "GRENDENE SA", "TECHNOS RELOGIOS S.A."},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", expected, groups)
+	}
+
+	if err := RemovePeers(db, "calçados premium", []string{"TECN3"}); err != nil {
+		t.Fatal(err)
+	}
+	groups, _ = PeerGroups(db)
+	if exp := []string{"ALPARGATAS S.A.", "GRENDENE SA"}; !reflect.DeepEqual(groups["calçados premium"], exp) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", exp, groups["calçados premium"])
+	}
+
+	if err := RemovePeers(db, "calçados premium", nil); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ = PeerGroups(db); len(groups) != 0 {
+		t.Errorf("expected no groups, got %v", groups)
+	}
+	if err := RemovePeers(db, "calçados premium", nil); err == nil {
+		t.Error("expected error removing inexistent group")
+	}
+}
+
+func TestLinkCompanies(t *testing.T) {
+	db, _ := sectorsDB(t)
+
+	names, missing, err := LinkCompanies(db, []string{"89.850.341/0001-60", "Vulcabras/Azaleia S.A.", "XPTO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"GRENDENE SA", "VULCABRAS AZALEIA S.A."}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", exp, names)
+	}
+	if exp := []string{"XPTO"}; !reflect.DeepEqual(missing, exp) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", exp, missing)
+	}
+}
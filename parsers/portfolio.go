@@ -0,0 +1,185 @@
+package parsers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// transactions is the layout of the YAML transactions file.
+type transactions struct {
+	Transactions []rapina.Transaction `yaml:"Transacoes"`
+}
+
+// ReadTransactions reads the buy and sell transactions from a CSV or YAML
+// file, returning them sorted by date.
+//
+// CSV header (separated by ',' or ';'):
+//
+//	data,codigo,operacao,quantidade,preco,taxas
+//
+// YAML layout:
+//
+//	Transacoes:
+//	  - data: 2021-03-01
+//	    codigo: KNIP11
+//	    operacao: C
+//	    quantidade: 10
+//	    preco: 115.50
+//	    taxas: 0.35
+func ReadTransactions(filename string) ([]rapina.Transaction, error) {
+	var list []rapina.Transaction
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yml", ".yaml":
+		list, err = transactionsFromYaml(filename)
+	default:
+		list, err = transactionsFromCsv(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		if err := fixTransaction(&list[i]); err != nil {
+			return nil, errors.Wrapf(err, "transação %d", i+1)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Date < list[j].Date
+	})
+
+	return list, nil
+}
+
+func transactionsFromYaml(filename string) ([]rapina.Transaction, error) {
+	y, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lendo arquivo %s", filename)
+	}
+
+	var t transactions
+	if err := yaml.Unmarshal(y, &t); err != nil {
+		return nil, errors.Wrapf(err, "arquivo %s inválido", filename)
+	}
+
+	return t.Transactions, nil
+}
+
+func transactionsFromCsv(filename string) ([]rapina.Transaction, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lendo arquivo %s", filename)
+	}
+	defer fh.Close()
+
+	var list []rapina.Transaction
+	header := make(map[string]int)
+	sep := ","
+	n := 0
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if len(header) == 0 { // HEADER
+			if strings.Contains(line, ";") {
+				sep = ";"
+			}
+			for i, h := range strings.Split(line, sep) {
+				header[strings.ToLower(strings.TrimSpace(h))] = i
+			}
+			for _, h := range []string{"data", "codigo", "operacao", "quantidade", "preco"} {
+				if _, ok := header[h]; !ok {
+					return nil, fmt.Errorf("coluna '%s' não encontrada no cabeçalho de %s", h, filename)
+				}
+			}
+			continue
+		}
+
+		fields := strings.Split(line, sep)
+		val := func(key string) string {
+			v, ok := header[key]
+			if !ok || v >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[v])
+		}
+
+		t := rapina.Transaction{
+			Date:      val("data"),
+			Code:      val("codigo"),
+			Operation: val("operacao"),
+		}
+		if t.Quantity, err = parseNumber(val("quantidade")); err != nil {
+			return nil, errors.Wrapf(err, "linha %d: quantidade", n)
+		}
+		if t.Price, err = parseNumber(val("preco")); err != nil {
+			return nil, errors.Wrapf(err, "linha %d: preço", n)
+		}
+		if t.Fees, err = parseNumber(val("taxas")); err != nil {
+			return nil, errors.Wrapf(err, "linha %d: taxas", n)
+		}
+
+		list = append(list, t)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "lendo arquivo %s", filename)
+	}
+
+	return list, nil
+}
+
+// fixTransaction validates and normalizes the transaction fields.
+func fixTransaction(t *rapina.Transaction) error {
+	t.Code = strings.ToUpper(strings.TrimSpace(t.Code))
+	if t.Code == "" {
+		return errors.New("código não informado")
+	}
+
+	if !rapina.IsDate(t.Date) {
+		return errors.Wrapf(rapina.ErrInvalidDate, "%s %q", t.Code, t.Date)
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(t.Operation)) {
+	case "C", "COMPRA", "BUY":
+		t.Operation = rapina.Buy
+	case "V", "VENDA", "SELL":
+		t.Operation = rapina.Sell
+	default:
+		return fmt.Errorf("%s: operação inválida %q (use C ou V)", t.Code, t.Operation)
+	}
+
+	if t.Quantity <= 0 {
+		return fmt.Errorf("%s: quantidade inválida %v", t.Code, t.Quantity)
+	}
+
+	return nil
+}
+
+// parseNumber parses numbers formatted as "1234.56" or "1.234,56".
+func parseNumber(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	return strconv.ParseFloat(s, 64)
+}
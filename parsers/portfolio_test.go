@@ -0,0 +1,79 @@
+package parsers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dude333/rapina"
+)
+
+func TestReadTransactions(t *testing.T) {
+	tempDir, _ := os.MkdirTemp("", "rapina-test")
+	defer os.RemoveAll(tempDir)
+
+	want := []rapina.Transaction{
+		{Date: "2021-01-15", Code: "KNIP11", Operation: rapina.Buy, Quantity: 10, Price: 1115.5, Fees: 0.35},
+		{Date: "2021-02-10", Code: "PETR4", Operation: rapina.Buy, Quantity: 100, Price: 25.1},
+		{Date: "2021-03-01", Code: "KNIP11", Operation: rapina.Sell, Quantity: 5, Price: 120},
+	}
+
+	files := map[string]string{
+		"carteira.csv": `data,codigo,operacao,quantidade,preco,taxas
+2021-03-01,knip11,V,5,120,
+2021-01-15,KNIP11,C,10,1115.50,0.35
+2021-02-10,PETR4,compra,100,25.10,0
+`,
+		"carteira_br.csv": `data;codigo;operacao;quantidade;preco;taxas
+2021-01-15;KNIP11;C;10;1.115,50;0,35
+2021-02-10;PETR4;C;100;25,10;
+2021-03-01;KNIP11;venda;5;120;0
+`,
+		"carteira.yml": `Transacoes:
+  - data: 2021-01-15
+    codigo: KNIP11
+    operacao: C
+    quantidade: 10
+    preco: 1115.50
+    taxas: 0.35
+  - data: 2021-03-01
+    codigo: KNIP11
+    operacao: V
+    quantidade: 5
+    preco: 120
+  - data: 2021-02-10
+    codigo: petr4
+    operacao: C
+    quantidade: 100
+    preco: 25.10
+`,
+		"invalid.csv": `data,codigo,operacao,quantidade,preco
+2021-13-01,KNIP11,C,10,100
+`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"carteira.csv", "carteira_br.csv", "carteira.yml"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ReadTransactions(filepath.Join(tempDir, name))
+			if err != nil {
+				t.Fatalf("ReadTransactions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadTransactions() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid.csv", func(t *testing.T) {
+		if _, err := ReadTransactions(filepath.Join(tempDir, "invalid.csv")); err == nil {
+			t.Error("ReadTransactions() expected error")
+		}
+	})
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/dude333/rapina"
 	"github.com/gocolly/colly/v2"
 	"github.com/pkg/errors"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // SectorsToYaml grab data from B3 website and prints out to a yaml file
@@ -156,36 +155,8 @@ type Subsector struct {
 
 // Segment contains companies from the same sector/subsector/segment
 type Segment struct {
-	Name      string   `yaml:"Segmento"`
-	Companies []string `yaml:"Empresas"`
-}
-
-// FromSector returns all companies from the same sector as the 'company'
-func FromSector(company, yamlFile string) (companies []string, sectorName string, err error) {
-	y, err := os.ReadFile(yamlFile)
-	if err != nil {
-		err = errors.Wrapf(err, "ReadFile: %v", err)
-		return
-	}
-
-	s := S{}
-	if err := yaml.Unmarshal(y, &s); err != nil {
-		return nil, "", err
-	}
-
-	for _, sector := range s.Sectors {
-		for _, subsector := range sector.Subsectors {
-			for _, segment := range subsector.Segments {
-				if FuzzyMatch(company, segment.Companies, 2) {
-					companies = segment.Companies
-					sectorName = strings.Join([]string{sector.Name, subsector.Name, segment.Name}, " > ")
-					return
-				}
-			}
-		}
-	}
-
-	return
+	Name      string          `yaml:"Segmento"`
+	Companies []SectorCompany `yaml:"Empresas"`
 }
 
 // removeYamlInvalidChar removes yaml invalid characters
@@ -1,28 +1,146 @@
 package parsers
 
 import (
+	"database/sql"
 	"os"
+	"reflect"
 	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func TestFromSector(t *testing.T) {
-	tempDir, _ := os.MkdirTemp("", "rapina-test")
-	filename := tempDir + "/test_sectors.yml"
+	db, missing := sectorsDB(t)
 
-	createYaml(filename)
-	s, _, _ := FromSector("GRENDENE S.A.", filename)
-	expected := [...]string{"ALPARGATAS S.A.", "CAMBUCI S.A.", "GRENDENE S.A.", "VULCABRAS/AZALEIA S.A."}
-	if len(s) != 4 {
+	expMissing := []string{"MINASMAQUINAS S.A.", "WLM PART. E COMÉRCIO DE MÁQUINAS E VEÍCULOS S.A."}
+	if !reflect.DeepEqual(missing, expMissing) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", expMissing, missing)
+	}
+
+	s, name, err := FromSector(db, "GRENDENE SA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"ALPARGATAS S.A.", "CAMBUCI S.A.", "GRENDENE SA", "VULCABRAS AZALEIA S.A."}
+	if !reflect.DeepEqual(s, expected) {
 		t.Errorf("\n- Expected:  %v\n- Got:       %v", expected, s)
 	}
+	if exp := "Consumo Cíclico > Tecidos. Vestuário e Calçados > Calçados"; name != exp {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", exp, name)
+	}
 
-	var arr [4]string
-	copy(arr[:], s)
-	if arr != expected {
+	// Linked by the trading code
+	s, _, _ = FromSector(db, "TECHNOS RELOGIOS S.A.")
+	expected = []string{"MUNDIAL S.A. PRODUTOS DE CONSUMO", "TECHNOS RELOGIOS S.A."}
+	if !reflect.DeepEqual(s, expected) {
 		t.Errorf("\n- Expected:  %v\n- Got:       %v", expected, s)
 	}
 
+	// Similar name, not listed on any segment
+	if s, _, err := FromSector(db, "GRENDENE PARTICIPACOES S.A."); len(s) != 0 || err != nil {
+		t.Errorf("expected no sector, got %v (%v)", s, err)
+	}
+}
+
+func TestSectorCompanies(t *testing.T) {
+	db, _ := sectorsDB(t)
+
+	s, err := SectorCompanies(db, "calçados")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"ALPARGATAS S.A.", "CAMBUCI S.A.", "GRENDENE SA", "VULCABRAS AZALEIA S.A."}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", expected, s)
+	}
+
+	if _, err := SectorCompanies(db, "XPTO"); err == nil {
+		t.Error("expected error for unknown sector")
+	}
+}
+
+func TestExportSectors(t *testing.T) {
+	db, _ := sectorsDB(t)
+
+	filename := tempFilename(t)
 	os.Remove(filename)
+	defer os.Remove(filename)
+
+	if err := ExportSectors(db, filename); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := loadSectors(db)
+
+	missing, err := ImportSectors(db, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 2 {
+		t.Errorf("expected 2 companies not linked, got %v", missing)
+	}
+	after, _ := loadSectors(db)
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("\n- Expected:  %v\n- Got:       %v", before, after)
+	}
+
+	seg := after.Sectors[1].Subsectors[0].Segments[1]
+	if c := seg.Companies[2]; c.CNPJ != "89.850.341/0001-60" || c.Ticker != "GRND3" {
+		t.Errorf("unexpected company on exported file: %+v", c)
+	}
+}
+
+// sectorsDB returns a DB with the sectors imported from the file created by
+// createYaml, and the companies that could not be linked.
+func sectorsDB(t *testing.T) (*sql.DB, []string) {
+	fileDB := tempFilename(t)
+	t.Cleanup(func() { os.Remove(fileDB) })
+
+	db, err := sql.Open("sqlite3", fileDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, table := range []string{"status", "companies", "stock_codes"} {
+		if err := createTable(db, table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stmts := []string{
+		`INSERT INTO companies (ID, CNPJ, NAME) VALUES
+			(100, '61.079.117/0001-05', 'ALPARGATAS S.A.'),
+			(101, '61.088.894/0001-08', 'CAMBUCI S.A.'),
+			(102, '89.850.341/0001-60', 'GRENDENE SA'),
+			(103, '50.926.955/0001-42', 'VULCABRAS AZALEIA S.A.'),
+			(104, '09.295.063/0001-97', 'TECHNOS RELOGIOS S.A.'),
+			(105, '88.610.191/0001-54', 'MUNDIAL S.A. PRODUTOS DE CONSUMO'),
+			(106, '01.234.567/0001-89', 'GRENDENE PARTICIPACOES S.A.');`,
+		`INSERT INTO stock_codes (trading_code, company_name, SpcfctnCd) VALUES
+			('GRND3', 'GRENDENE S.A.', 'ON NM'),
+			('TECN3', 'TECHNOS RELOGIOS S.A.', 'ON NM');`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filename := tempFilename(t)
+	t.Cleanup(func() { os.Remove(filename) })
+	createYaml(filename)
+
+	if HasSectors(db) {
+		t.Fatal("HasSectors() = true before the import")
+	}
+	missing, err := ImportSectors(db, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasSectors(db) {
+		t.Fatal("HasSectors() = false after the import")
+	}
+
+	return db, missing
 }
 
 func createYaml(filename string) {
@@ -43,11 +161,13 @@ func createYaml(filename string) {
         - Segmento: Acessórios
           Empresas:
           - MUNDIAL S.A. - PRODUTOS DE CONSUMO
-          - TECHNOS S.A.
+          - Nome: TECHNOS S.A.
+            Codigo: TECN3
         - Segmento: Calçados
           Empresas:
             - ALPARGATAS S.A.
-            - CAMBUCI S.A.
+            - Nome: CAMBUCI S.A.
+              CNPJ: "61088894000108"
             - GRENDENE S.A.
             - VULCABRAS/AZALEIA S.A.`)
 
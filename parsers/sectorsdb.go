@@ -0,0 +1,394 @@
+package parsers
+
+import (
+	"database/sql"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dude333/rapina"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SectorCompany is a company listed on a B3 segment. The company is linked
+// to the CVM statements by its CNPJ; if not set, by its trading code or by
+// its name when the sectors file is imported.
+type SectorCompany struct {
+	Name   string `yaml:"Nome"`
+	CNPJ   string `yaml:"CNPJ,omitempty"`
+	Ticker string `yaml:"Codigo,omitempty"`
+}
+
+// UnmarshalYAML reads the company either as a name (as written by
+// SectorsToYaml) or as a mapping with the name, CNPJ and trading code.
+func (c *SectorCompany) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&c.Name); err == nil {
+		return nil
+	}
+	type plain SectorCompany
+	return unmarshal((*plain)(c))
+}
+
+// ImportSectors replaces the sectors stored on the DB by the ones from
+// 'yamlFile' (setores.yml), linking each company to the CVM companies by
+// its CNPJ, its name or its trading code, in this order. Returns the
+// companies that could not be linked; they are kept on the DB (and on the
+// exported file) but are ignored by the sector lookups.
+func ImportSectors(db *sql.DB, yamlFile string) (missing []string, err error) {
+	y, err := os.ReadFile(yamlFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lendo arquivo %s", yamlFile)
+	}
+
+	s := S{}
+	if err := yaml.Unmarshal(y, &s); err != nil {
+		return nil, errors.Wrapf(err, "arquivo %s inválido", yamlFile)
+	}
+
+	return saveSectors(db, &s)
+}
+
+// saveSectors replaces the sectors table by the sectors in 's'.
+func saveSectors(db *sql.DB, s *S) (missing []string, err error) {
+	for _, t := range []string{"status", "sectors"} {
+		if err := createTable(db, t); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := newCompanyLinker(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, errors.Wrap(err, "erro ao atualizar setores")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM sectors;`); err != nil {
+		return nil, errors.Wrap(err, "erro ao apagar setores")
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO sectors
+	(SECTOR, SUBSECTOR, SEGMENT, NAME, CNPJ, TICKER) VALUES (?,?,?,?,?,?);`)
+	if err != nil {
+		return nil, errors.Wrap(err, "erro ao preparar insert dos setores")
+	}
+	defer stmt.Close()
+
+	linked := make(map[string]bool)
+	for _, sector := range s.Sectors {
+		for _, subsector := range sector.Subsectors {
+			for _, segment := range subsector.Segments {
+				for _, c := range segment.Companies {
+					cnpj, ticker, ok := l.link(c)
+					if !ok || linked[cnpj] {
+						missing = append(missing, c.Name)
+						if c.CNPJ == "" {
+							cnpj = ""
+						}
+					}
+					linked[cnpj] = true
+
+					var cnpjValue interface{} // NULL if not linked
+					if cnpj != "" {
+						cnpjValue = cnpj
+					}
+					_, err = stmt.Exec(sector.Name, subsector.Name, segment.Name, c.Name, cnpjValue, ticker)
+					if err != nil {
+						return nil, errors.Wrap(err, "falha ao inserir setor")
+					}
+				}
+			}
+		}
+	}
+
+	return missing, tx.Commit()
+}
+
+// companyLinker finds the CVM company of each company listed on the
+// sectors file.
+type companyLinker struct {
+	cnpjs   map[string]string // CNPJ digits => CNPJ
+	cvm     map[string]string // CNPJ => CVM name
+	names   map[string]string // normalized CVM name => CNPJ ("" if ambiguous)
+	tickers map[string]string // trading code => normalized B3 name
+	codes   map[string]string // normalized B3 name => first trading code
+}
+
+func newCompanyLinker(db *sql.DB) (*companyLinker, error) {
+	l := &companyLinker{
+		cnpjs:   make(map[string]string),
+		cvm:     make(map[string]string),
+		names:   make(map[string]string),
+		tickers: make(map[string]string),
+		codes:   make(map[string]string),
+	}
+
+	if err := createTable(db, "companies"); err != nil {
+		return nil, err
+	}
+	companies, err := loadCompanies(db)
+	if err != nil {
+		return nil, err
+	}
+	for cnpj, c := range companies {
+		l.cnpjs[onlyDigits(cnpj)] = cnpj
+		l.cvm[cnpj] = c.name
+		name := normalizeName(c.name)
+		if _, ok := l.names[name]; ok {
+			l.names[name] = ""
+			continue
+		}
+		l.names[name] = cnpj
+	}
+
+	if !hasTable(db, "stock_codes") {
+		return l, nil
+	}
+	rows, err := db.Query(`SELECT trading_code, company_name FROM stock_codes ORDER BY trading_code;`)
+	if err != nil {
+		return nil, errors.Wrap(err, "falha ao ler banco de dados")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var code, name string
+		if err := rows.Scan(&code, &name); err != nil {
+			return nil, err
+		}
+		name = normalizeName(name)
+		l.tickers[code] = name
+		if _, ok := l.codes[name]; !ok {
+			l.codes[name] = code
+		}
+	}
+
+	return l, rows.Err()
+}
+
+// link returns the CNPJ and the trading code of the company, and whether
+// the company was found among the CVM companies.
+func (l *companyLinker) link(c SectorCompany) (cnpj, ticker string, ok bool) {
+	ticker = strings.ToUpper(strings.TrimSpace(c.Ticker))
+	if ticker == "" {
+		ticker = l.codes[normalizeName(c.Name)]
+	}
+
+	if c.CNPJ != "" {
+		cnpj, ok = l.cnpjs[onlyDigits(c.CNPJ)]
+		if !ok {
+			return strings.TrimSpace(c.CNPJ), ticker, false
+		}
+		return cnpj, ticker, true
+	}
+
+	if cnpj = l.names[normalizeName(c.Name)]; cnpj == "" && ticker != "" {
+		cnpj = l.names[l.tickers[ticker]]
+	}
+
+	return cnpj, ticker, cnpj != ""
+}
+
+// normalizeName returns the name in upper case, without diacritics, spaces
+// and punctuation (e.g., "Vulcabras/Azaleia S.A." => "VULCABRASAZALEIASA").
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.ToUpper(RemoveDiacritics(name)))
+}
+
+// onlyDigits returns the digits of 's'.
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// ExportSectors writes the sectors stored on the DB to 'yamlFile', with the
+// CNPJ and the trading code of each company, so that the file can be edited
+// and imported back with ImportSectors.
+func ExportSectors(db *sql.DB, yamlFile string) error {
+	s, err := loadSectors(db)
+	if err != nil {
+		return err
+	}
+	if len(s.Sectors) == 0 {
+		return errors.New("setores não encontrados no banco de dados")
+	}
+
+	y, err := yaml.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "erro ao gerar yaml dos setores")
+	}
+
+	if !overwritePrompt(yamlFile) {
+		return rapina.ErrFileNotUpdated
+	}
+	if err := os.WriteFile(yamlFile, y, 0644); err != nil {
+		return errors.Wrapf(err, "falha ao criar arquivo %s", yamlFile)
+	}
+
+	return nil
+}
+
+// loadSectors returns the sectors stored on the DB, in the order they were
+// imported.
+func loadSectors(db *sql.DB) (*S, error) {
+	s := &S{}
+	if !hasTable(db, "sectors") {
+		return s, nil
+	}
+
+	rows, err := db.Query(`SELECT SECTOR, SUBSECTOR, SEGMENT, NAME, IFNULL(CNPJ, ''), IFNULL(TICKER, '')
+	FROM sectors ORDER BY rowid;`)
+	if err != nil {
+		return nil, errors.Wrap(err, "falha ao ler setores")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sector, subsector, segment string
+		var c SectorCompany
+		if err := rows.Scan(&sector, &subsector, &segment, &c.Name, &c.CNPJ, &c.Ticker); err != nil {
+			return nil, err
+		}
+
+		if n := len(s.Sectors); n == 0 || s.Sectors[n-1].Name != sector {
+			s.Sectors = append(s.Sectors, Sector{Name: sector})
+		}
+		sec := &s.Sectors[len(s.Sectors)-1]
+		if n := len(sec.Subsectors); n == 0 || sec.Subsectors[n-1].Name != subsector {
+			sec.Subsectors = append(sec.Subsectors, Subsector{Name: subsector})
+		}
+		sub := &sec.Subsectors[len(sec.Subsectors)-1]
+		if n := len(sub.Segments); n == 0 || sub.Segments[n-1].Name != segment {
+			sub.Segments = append(sub.Segments, Segment{Name: segment})
+		}
+		seg := &sub.Segments[len(sub.Segments)-1]
+		seg.Companies = append(seg.Companies, c)
+	}
+
+	return s, rows.Err()
+}
+
+// HasSectors returns true if the sectors were imported into the DB (see
+// ImportSectors).
+func HasSectors(db *sql.DB) bool {
+	if !hasTable(db, "sectors") {
+		return false
+	}
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sectors;`).Scan(&n)
+	return err == nil && n > 0
+}
+
+// FromSector returns the companies (names as stored on the companies table)
+// from the same segment as the 'company' and the segment name ("sector >
+// subsector > segment"). Returns an empty list if the company is not linked
+// to a segment.
+func FromSector(db *sql.DB, company string) (companies []string, sectorName string, err error) {
+	if !hasTable(db, "sectors") {
+		return nil, "", errors.New("setores não encontrados no banco de dados (execute 'rapina update -s')")
+	}
+
+	var sector, subsector, segment string
+	err = db.QueryRow(`SELECT s.SECTOR, s.SUBSECTOR, s.SEGMENT
+	FROM sectors s JOIN companies c ON c.CNPJ = s.CNPJ
+	WHERE c.NAME = ?;`, company).Scan(&sector, &subsector, &segment)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "falha ao ler setores")
+	}
+
+	rows, err := db.Query(`SELECT DISTINCT c.NAME
+	FROM sectors s JOIN companies c ON c.CNPJ = s.CNPJ
+	WHERE s.SECTOR = ? AND s.SUBSECTOR = ? AND s.SEGMENT = ?
+	ORDER BY c.NAME;`, sector, subsector, segment)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "falha ao ler setores")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, "", err
+		}
+		companies = append(companies, name)
+	}
+
+	return companies, strings.Join([]string{sector, subsector, segment}, " > "), rows.Err()
+}
+
+// SectorCompanies returns the companies (names as stored on the companies
+// table) of the sector, subsector or segment 'name' (case insensitive).
+func SectorCompanies(db *sql.DB, name string) ([]string, error) {
+	if !hasTable(db, "sectors") {
+		return nil, errors.New("setores não encontrados no banco de dados (execute 'rapina update -s')")
+	}
+
+	sectors, err := sectorCompanies(db)
+	if err != nil {
+		return nil, err
+	}
+	companies, ok := sectors[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, errors.Errorf("setor '%s' não encontrado", name)
+	}
+
+	seen := make(map[string]bool)
+	var list []string
+	for _, co := range companies {
+		if !seen[co] {
+			seen[co] = true
+			list = append(list, co)
+		}
+	}
+	sort.Strings(list)
+
+	return list, nil
+}
+
+// sectorCompanies returns the companies (names as stored on the companies
+// table) of each sector, subsector and segment (lower case names).
+func sectorCompanies(db *sql.DB) (map[string][]string, error) {
+	companies := make(map[string][]string)
+	if !hasTable(db, "sectors") {
+		return companies, nil
+	}
+
+	rows, err := db.Query(`SELECT s.SECTOR, s.SUBSECTOR, s.SEGMENT, c.NAME
+	FROM sectors s JOIN companies c ON c.CNPJ = s.CNPJ;`)
+	if err != nil {
+		return nil, errors.Wrap(err, "falha ao ler setores")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sector, subsector, segment, company string
+		if err := rows.Scan(&sector, &subsector, &segment, &company); err != nil {
+			return nil, err
+		}
+		for _, name := range []string{sector, subsector, segment} {
+			name = strings.ToLower(name)
+			companies[name] = append(companies[name], company)
+		}
+	}
+
+	return companies, rows.Err()
+}
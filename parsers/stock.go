@@ -78,6 +78,31 @@ func (s *StockParser) Quote(code, date string) (float64, error) {
 	return close, nil
 }
 
+//
+// Volumes returns the daily volumes of 'code' stored on the DB since 'from'
+// (YYYY-MM-DD), by date.
+//
+func (s *StockParser) Volumes(code, from string) (map[string]float64, error) {
+	query := `SELECT date, volume FROM stock_quotes WHERE stock=$1 AND date>=$2;`
+	rows, err := s.db.Query(query, code, from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lendo volume de %s do bd", code)
+	}
+	defer rows.Close()
+
+	volumes := make(map[string]float64)
+	for rows.Next() {
+		var date string
+		var volume sql.NullFloat64
+		if err := rows.Scan(&date, &volume); err != nil {
+			return nil, errors.Wrapf(err, "lendo volume de %s do bd", code)
+		}
+		volumes[date] = volume.Float64
+	}
+
+	return volumes, rows.Err()
+}
+
 //
 // Quote returns the company ON stock code, where stockType is:
 // ON, PN, UNT, CI [CI = FII]
@@ -392,6 +417,30 @@ func parseYahoo(line, code string) (*stockQuote, error) {
 	}, nil
 }
 
+// YahooDividends parses the dividends per share (JCP included) of 'code'
+// downloaded from Yahoo Finance API server (columns: Date,Dividends), where
+// Date is the ex-dividend date.
+func YahooDividends(r io.Reader, code string) ([]rapina.Dividend, error) {
+	var list []rapina.Dividend
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ",")
+		if len(fields) != 2 || !rapina.IsDate(fields[0]) {
+			continue // header and lines with error
+		}
+		val, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || val <= 0 {
+			continue
+		}
+		list = append(list, rapina.Dividend{Code: code, Date: fields[0], Val: val})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "lendo dividendos")
+	}
+
+	return list, nil
+}
+
 // parseB3Quote parses the line based on this layout:
 // http://www.b3.com.br/data/files/33/67/B9/50/D84057102C784E47AC094EA8/SeriesHistoricas_Layout.pdf
 //
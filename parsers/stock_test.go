@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/dude333/rapina"
 )
 
 func Test_parseB3(t *testing.T) {
@@ -97,3 +99,20 @@ func Test_parseB3Code(t *testing.T) {
 		})
 	}
 }
+
+func TestYahooDividends(t *testing.T) {
+	const file = `Date,Dividends
+2021-03-02,0.5
+2021-06-01,null
+2021-08-13,1.25
+`
+	want := []rapina.Dividend{
+		{Code: "PETR4", Date: "2021-03-02", Val: 0.5},
+		{Code: "PETR4", Date: "2021-08-13", Val: 1.25},
+	}
+
+	got, err := YahooDividends(strings.NewReader(file), "PETR4")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("YahooDividends() = %+v, %v, want %+v", got, err, want)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-const currentDbVersion = 210514
+const currentDbVersion = 261019
 const currentFIIDbVersion = 210426
 const currentStockCodesVersion = 210518
 const currentStockQuotesVersion = 210305
@@ -80,6 +80,23 @@ var createTableMap = map[string]string{
 		"CorpGovnLvlNm" VARCHAR
 	);`,
 
+	"sectors": `CREATE TABLE IF NOT EXISTS sectors
+	(
+		"SECTOR"    varchar(100) NOT NULL,
+		"SUBSECTOR" varchar(100) NOT NULL,
+		"SEGMENT"   varchar(100) NOT NULL,
+		"NAME"      varchar(100) NOT NULL,
+		"CNPJ"      varchar(20),
+		"TICKER"    varchar(12)
+	);`,
+
+	"peer_groups": `CREATE TABLE IF NOT EXISTS peer_groups
+	(
+		"GROUP_NAME" varchar(100) NOT NULL,
+		"CNPJ"       varchar(20) NOT NULL,
+		PRIMARY KEY (GROUP_NAME, CNPJ)
+	);`,
+
 	"md5": `CREATE TABLE IF NOT EXISTS md5
 	(
 		md5 NOT NULL PRIMARY KEY
@@ -109,7 +126,43 @@ var createTableMap = map[string]string{
 		trading_code varchar(12) NOT NULL, 
 		base_date varchar(10) NOT NULL, 
 		payment_date varchar(10), 
-		value real
+		value real,
+		ref_date varchar(10)
+	);`,
+
+	"fii_documents": `CREATE TABLE IF NOT EXISTS fii_documents
+	(
+		trading_code varchar(12) NOT NULL,
+		report varchar(20) NOT NULL,
+		last_date varchar(16),
+		PRIMARY KEY (trading_code, report)
+	);`,
+
+	"fii_checked_months": `CREATE TABLE IF NOT EXISTS fii_checked_months
+	(
+		trading_code varchar(12) NOT NULL,
+		report varchar(20) NOT NULL,
+		ref_month varchar(7) NOT NULL,
+		PRIMARY KEY (trading_code, report, ref_month)
+	);`,
+
+	"fii_list": `CREATE TABLE IF NOT EXISTS fii_list
+	(
+		trading_code varchar(12) NOT NULL PRIMARY KEY,
+		acronym varchar(4),
+		name varchar,
+		cnpj varchar(20),
+		administrator varchar,
+		segment varchar
+	);`,
+
+	"fii_monthly": `CREATE TABLE IF NOT EXISTS fii_monthly
+	(
+		trading_code varchar(12) NOT NULL,
+		ref_month varchar(7) NOT NULL,
+		equity real,
+		quotas real,
+		nav real
 	);`,
 
 	"status": `CREATE TABLE IF NOT EXISTS status
@@ -152,8 +205,20 @@ func whatTable(dataType string) (table string, err error) {
 		table = dataType
 	case "fii_dividends":
 		table = dataType
+	case "fii_monthly":
+		table = dataType
+	case "fii_list":
+		table = dataType
+	case "fii_documents":
+		table = dataType
+	case "fii_checked_months":
+		table = dataType
 	case "stock_codes":
 		table = dataType
+	case "sectors":
+		table = dataType
+	case "peer_groups":
+		table = dataType
 	case "stock_quotes":
 		table = dataType
 	default:
@@ -193,6 +258,14 @@ func createTable(db *sql.DB, dataType string) (err error) {
 		version = currentFIIDbVersion
 	case "fii_dividends":
 		version = currentFIIDbVersion
+	case "fii_monthly":
+		version = currentFIIDbVersion
+	case "fii_list":
+		version = currentFIIDbVersion
+	case "fii_documents":
+		version = currentFIIDbVersion
+	case "fii_checked_months":
+		version = currentFIIDbVersion
 	case "stock_codes":
 		version = currentStockCodesVersion
 	case "stock_quotes":
@@ -257,6 +330,50 @@ func wipeDB(db *sql.DB, dataType string) (err error) {
 	return
 }
 
+//
+// upgradeTable upgrades the table from version 'v' to the current version
+// without wiping it (keeping the previous versions of the statements).
+// Returns false if the table cannot be upgraded.
+//
+func upgradeTable(db *sql.DB, dataType string, v int) bool {
+	table, err := whatTable(dataType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case v == 210514 && (table == "dfp" || table == "itr"):
+		// Values were stored on the company's scale
+		err = normalizeScale(db, table)
+	case v == 210514 && table == "md5":
+	default:
+		return false
+	}
+	if err != nil {
+		fmt.Printf("[x] Erro ao atualizar tabela %s: %v\n", table, err)
+		return false
+	}
+
+	fmt.Printf("[i] Tabela %s atualizada da versão %d para %d\n", table, v, currentDbVersion)
+	return true
+}
+
+//
+// normalizeScale converts the values of the table to thousands of R$.
+//
+func normalizeScale(db *sql.DB, table string) error {
+	update := fmt.Sprintf(`UPDATE %s SET
+		VL_CONTA = CASE ESCALA_MOEDA
+			WHEN 'UNIDADE' THEN VL_CONTA / 1000
+			WHEN 'MILHAO' THEN VL_CONTA * 1000
+			ELSE VL_CONTA END,
+		ESCALA_MOEDA = '%s'
+	WHERE ESCALA_MOEDA <> '%s';`, table, StoredScale, StoredScale)
+
+	_, err := db.Exec(update)
+	return err
+}
+
 //
 // createIndexes create indexes based on table name
 //
@@ -282,6 +399,19 @@ func createIndexes(db *sql.DB, table string) error {
 		indexes = []string{
 			"CREATE UNIQUE INDEX IF NOT EXISTS fii_dividends_pk ON fii_dividends (trading_code, base_date);",
 		}
+	case "fii_monthly":
+		indexes = []string{
+			"CREATE UNIQUE INDEX IF NOT EXISTS fii_monthly_pk ON fii_monthly (trading_code, ref_month);",
+		}
+	case "fii_list":
+		indexes = []string{
+			"CREATE INDEX IF NOT EXISTS fii_list_segment ON fii_list (segment);",
+		}
+	case "sectors":
+		indexes = []string{
+			"CREATE INDEX IF NOT EXISTS sectors_cnpj ON sectors (CNPJ);",
+			"CREATE INDEX IF NOT EXISTS sectors_segment ON sectors (SECTOR, SUBSECTOR, SEGMENT);",
+		}
 	}
 
 	for _, idx := range indexes {
@@ -0,0 +1,18 @@
+package rapina
+
+// Operations on a transaction.
+const (
+	Buy  = "C" // Compra
+	Sell = "V" // Venda
+)
+
+// Transaction contains a buy or sell of 'Quantity' units of the stock or FII
+// 'Code' on 'Date' (YYYY-MM-DD) at 'Price', plus 'Fees'.
+type Transaction struct {
+	Date      string  `yaml:"data"`
+	Code      string  `yaml:"codigo"`
+	Operation string  `yaml:"operacao"`
+	Quantity  float64 `yaml:"quantidade"`
+	Price     float64 `yaml:"preco"`
+	Fees      float64 `yaml:"taxas"`
+}
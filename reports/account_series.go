@@ -0,0 +1,243 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// AccountSeries contains the yearly values of an account, with the
+// year-over-year change and the compound annual growth rate (CAGR).
+type AccountSeries struct {
+	Account string        `json:"account"`
+	Descr   string        `json:"description"`
+	Level   int           `json:"level"` // 0: requested account, 1: its children, etc.
+	Unit    string        `json:"unit"`
+	Values  []AccountYear `json:"values"`
+	CAGR    *float64      `json:"cagr,omitempty"`
+}
+
+// AccountYear contains the value of an account on a year and the change
+// from the previous year (nil if not comparable).
+type AccountYear struct {
+	Year  int      `json:"year"`
+	Value float64  `json:"value"`
+	YoY   *float64 `json:"yoy,omitempty"`
+}
+
+// AccountSeries returns the time series of the 'account' of the 'company',
+// followed by the series of its children, from 'begin' to 'end' (0 for the
+// years available on the DB). 'account' can be a CVM account (e.g., "3.01")
+// or a bookkeeping account name (e.g., "Vendas"). Values are converted to
+// 'unit'.
+func (r Report) AccountSeries(company, account string, begin, end int, unit Unit) ([]AccountSeries, error) {
+	cid, err := r.getCid(company)
+	if err != nil {
+		return nil, fmt.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+
+	var code uint32
+	if !isCVMAccount(account) {
+		var ok bool
+		code, ok = parsers.AccountCode(account)
+		if !ok {
+			return nil, fmt.Errorf("conta inválida: %s", account)
+		}
+	}
+
+	first, last, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	if begin < first {
+		begin = first
+	}
+	if end == 0 || end > last {
+		end = last
+	}
+
+	var values []AccountValue
+	for y := begin; y <= end; y++ {
+		v, err := r.RawAccounts(cid, y)
+		if err != nil {
+			return nil, err
+		}
+		for i := range v {
+			v[i].value *= unit.factor()
+		}
+		values = append(values, v...)
+	}
+
+	series := accountSeries(values, account, code)
+	if len(series) == 0 {
+		return nil, fmt.Errorf("conta %s não encontrada para %s entre %d e %d", account, company, begin, end)
+	}
+	for i := range series {
+		series[i].Unit = unit.String()
+	}
+
+	return series, nil
+}
+
+// isCVMAccount returns true if 'account' is a CVM account code (e.g., 3.01).
+func isCVMAccount(account string) bool {
+	return account != "" && account[0] >= '0' && account[0] <= '9'
+}
+
+// accountSeries groups the 'values' by account, selecting the account
+// 'account' (or the accounts mapped to the bookkeeping 'code', if not zero)
+// and its children. The requested account comes first, followed by the
+// children in the accounts order.
+func accountSeries(values []AccountValue, account string, code uint32) []AccountSeries {
+	// Requested account on each year
+	roots := make(map[int]string)
+	for _, v := range values {
+		if _, ok := roots[v.year]; ok {
+			continue
+		}
+		if (code == 0 && v.accItem.cdConta == account) || (code != 0 && v.accItem.code == code) {
+			roots[v.year] = v.accItem.cdConta
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].year < values[j].year
+	})
+
+	series := make(map[string]*AccountSeries)
+	for _, v := range values {
+		root, ok := roots[v.year]
+		if !ok {
+			continue
+		}
+
+		cd := v.accItem.cdConta
+		key := cd
+		level := strings.Count(cd, ".") - strings.Count(root, ".")
+		switch {
+		case cd == root:
+			key = "" // requested account, even if its CVM code changed over the years
+		case !strings.HasPrefix(cd, root+"."):
+			continue
+		}
+
+		s, ok := series[key]
+		if !ok {
+			s = &AccountSeries{Account: cd, Level: level}
+			if key == "" && code != 0 {
+				s.Account = account
+			}
+			series[key] = s
+		}
+		s.Descr = v.accItem.dsConta // description from the last year
+		s.Values = append(s.Values, AccountYear{Year: v.year, Value: float64(v.value)})
+	}
+
+	keys := make([]string, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	list := make([]AccountSeries, 0, len(keys))
+	for _, k := range keys {
+		s := series[k]
+		s.growth()
+		list = append(list, *s)
+	}
+
+	return list
+}
+
+// growth sets the year-over-year change of the values and the CAGR of the
+// series.
+func (s *AccountSeries) growth() {
+	for i := 1; i < len(s.Values); i++ {
+		prev, cur := s.Values[i-1], &s.Values[i]
+		if cur.Year-prev.Year == 1 {
+			cur.YoY = growthRate(prev.Value, cur.Value, 1)
+		}
+	}
+
+	if n := len(s.Values); n > 1 {
+		first, last := s.Values[0], s.Values[n-1]
+		s.CAGR = growthRate(first.Value, last.Value, last.Year-first.Year)
+	}
+}
+
+// growthRate returns the compound growth rate from 'v0' to 'vn' in 'n'
+// periods, or nil if any value is zero or the sign changed (flagged by
+// signChange). Negative values are compared by their magnitude, so an
+// expense or a loss going from -10 to -50 grows 400%.
+func growthRate(v0, vn float64, n int) *float64 {
+	if n <= 0 || v0 == 0 || vn == 0 || (v0 < 0) != (vn < 0) {
+		return nil
+	}
+	r := math.Pow(math.Abs(vn)/math.Abs(v0), 1/float64(n)) - 1
+	return &r
+}
+
+// PrintAccountSeries prints the accounts time series on the terminal, as a
+// table or csv.
+func PrintAccountSeries(series []AccountSeries, format string) {
+	if format == "csv" {
+		fmt.Print(csvAccountSeries(series))
+		return
+	}
+	fmt.Print(printAccountSeries(series))
+}
+
+func printAccountSeries(series []AccountSeries) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	for _, s := range series {
+		ident := strings.Repeat("  ", s.Level)
+		p.Fprintln(buf, line)
+		p.Fprintf(buf, "%s%s %s (%s)\n", ident, s.Account, s.Descr, s.Unit)
+		p.Fprintln(buf, line)
+		p.Fprintln(buf, "  ANO                     VALOR         VAR.")
+		p.Fprintln(buf, "  ----     ---------------------     --------")
+		for _, v := range s.Values {
+			p.Fprintf(buf, "  %s     %21.0f     %s\n", strconv.Itoa(v.Year), v.Value, fmtRate(p, v.YoY))
+		}
+		p.Fprintf(buf, "  CAGR     %21s     %s\n", "", fmtRate(p, s.CAGR))
+		buf.WriteByte('\n')
+	}
+
+	return buf
+}
+
+func csvAccountSeries(series []AccountSeries) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	buf.WriteString("Conta,Descrição,Ano,Valor,Var.\n")
+	for _, s := range series {
+		for _, v := range s.Values {
+			p.Fprintf(buf, `%s,"%s",%s,"%f",`, s.Account, s.Descr, strconv.Itoa(v.Year), v.Value)
+			if v.YoY != nil {
+				p.Fprintf(buf, `"%f%%"`, 100**v.YoY)
+			} else {
+				buf.WriteString(`""`)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf
+}
+
+// fmtRate formats the rate as percentage, or "-" if nil.
+func fmtRate(p *message.Printer, rate *float64) string {
+	if rate == nil {
+		return fmt.Sprintf("%8s", "-")
+	}
+	return p.Sprintf("%7.1f%%", 100**rate)
+}
@@ -0,0 +1,89 @@
+package reports
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func accValue(year int, cd, ds string, code uint32, value float32) AccountValue {
+	return AccountValue{
+		accItem: accItems{code: code, cdConta: cd, dsConta: ds},
+		value:   value,
+		year:    year,
+	}
+}
+
+func TestAccountSeries(t *testing.T) {
+	values := []AccountValue{
+		accValue(2019, "3.01", "Receita", p.Vendas, 100),
+		accValue(2019, "3.01.01", "Mercado Interno", 0, 60),
+		accValue(2019, "3.01.02", "Mercado Externo", 0, 40),
+		accValue(2019, "3.02", "Custo", p.CustoVendas, -50),
+		accValue(2020, "3.01", "Receita", p.Vendas, 110),
+		accValue(2020, "3.01.01", "Mercado Interno", 0, 70),
+		accValue(2020, "3.01.02", "Mercado Externo", 0, 40),
+		accValue(2021, "3.01", "Receita Líquida", p.Vendas, 121),
+		accValue(2021, "3.01.01", "Mercado Interno", 0, 80),
+		accValue(2021, "3.01.02", "Mercado Externo", 0, -1),
+		accValue(2021, "3.010", "Outra", 0, 1),
+	}
+
+	series := accountSeries(values, "3.01", 0)
+	if len(series) != 3 {
+		t.Fatalf("accountSeries: got %d series, want 3", len(series))
+	}
+
+	s := series[0]
+	if s.Account != "3.01" || s.Descr != "Receita Líquida" || s.Level != 0 || len(s.Values) != 3 {
+		t.Errorf("accountSeries: wrong account series: %+v", s)
+	}
+	if s.Values[0].YoY != nil {
+		t.Errorf("accountSeries: first YoY should be nil")
+	}
+	if s.Values[1].YoY == nil || math.Abs(*s.Values[1].YoY-0.1) > 1e-6 {
+		t.Errorf("accountSeries: wrong YoY: %v", s.Values[1].YoY)
+	}
+	if s.CAGR == nil || math.Abs(*s.CAGR-0.1) > 1e-6 {
+		t.Errorf("accountSeries: wrong CAGR: %v", s.CAGR)
+	}
+
+	if series[1].Account != "3.01.01" || series[1].Level != 1 {
+		t.Errorf("accountSeries: wrong child: %+v", series[1])
+	}
+	ext := series[2]
+	if ext.Values[2].YoY != nil || ext.CAGR != nil {
+		t.Errorf("accountSeries: rates with sign change should be nil: %+v", ext)
+	}
+
+	// Bookkeeping account
+	series = accountSeries(values, "Vendas", p.Vendas)
+	if len(series) != 3 || series[0].Account != "Vendas" {
+		t.Errorf("accountSeries by code: %+v", series)
+	}
+
+	if len(accountSeries(values, "9.99", 0)) != 0 {
+		t.Errorf("accountSeries: expected no series")
+	}
+}
+
+func TestPrintAccountSeries(t *testing.T) {
+	series := accountSeries([]AccountValue{
+		accValue(2020, "3.01", "Receita", p.Vendas, 1000),
+		accValue(2021, "3.01", "Receita", p.Vendas, 1500),
+	}, "3.01", 0)
+
+	out := printAccountSeries(series).String()
+	for _, want := range []string{"3.01 Receita", "  2021 ", "1.500", "50,0%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printAccountSeries: %q not found in:\n%s", want, out)
+		}
+	}
+
+	out = csvAccountSeries(series).String()
+	if !strings.Contains(out, `3.01,"Receita",2021,"1.500,000000","50,000000%"`) {
+		t.Errorf("csvAccountSeries: unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,36 @@
+package reports
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+)
+
+// AccountRules shows the accounts of the annual reports matched by each rule
+// of the accounts mapping. If 'verbose', all matched accounts are listed.
+func AccountRules(db *sql.DB, m *parsers.AccountsMapping, company string, verbose bool) error {
+	hits, err := parsers.AccountHits(db, m, company)
+	if err != nil {
+		return errors.Wrap(err, "regras do mapeamento de contas")
+	}
+
+	for _, h := range hits {
+		fmt.Println(line)
+		fmt.Println(h.Rule)
+		if len(h.Accounts) == 0 {
+			fmt.Println("  nenhuma conta encontrada")
+			continue
+		}
+		fmt.Printf("  %d contas em %d empresas\n", len(h.Accounts), h.Companies)
+		if verbose {
+			for _, acc := range h.Accounts {
+				fmt.Println("    " + acc)
+			}
+		}
+	}
+	fmt.Println(line)
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	p "github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Severity of an alert
+type Severity int
+
+// Alert severities
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+)
+
+// ParseSeverity returns the severity from the command line option: baixa,
+// media or alta.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(p.RemoveDiacritics(strings.TrimSpace(s))) {
+	case "", "baixa":
+		return SeverityLow, nil
+	case "media":
+		return SeverityMedium, nil
+	case "alta":
+		return SeverityHigh, nil
+	}
+	return SeverityLow, fmt.Errorf("severidade inválida: %s (opções: baixa|media|alta)", s)
+}
+
+// String returns the severity label.
+func (s Severity) String() string {
+	switch s {
+	case SeverityMedium:
+		return "média"
+	case SeverityHigh:
+		return "alta"
+	}
+	return "baixa"
+}
+
+// Alert checks
+const (
+	alertIncomeFCO     = "lucro-fco"
+	alertReceivables   = "recebiveis"
+	alertInventoryDays = "estoques"
+	alertEquity        = "pl-negativo"
+	alertDividends     = "proventos-fco"
+	alertOthers        = "outros"
+)
+
+// Alert is a red flag on the financial statements of a company.
+type Alert struct {
+	Company  string
+	Year     int
+	Check    string // alertX constants
+	Severity Severity
+	Message  string
+}
+
+// Alerts checks the financial statements of the companies with names
+// containing 'company' (all companies if empty) from 'begin' to 'end' (0 for
+// the years available on the DB), returning the alerts with severity equal or
+// above 'min'. The last year is checked with the trailing twelve months
+// (ITR) if more recent than the annual report (DFP).
+func (r Report) Alerts(company string, begin, end int, min Severity) ([]Alert, error) {
+	first, last, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	if begin < first {
+		begin = first
+	}
+	if end == 0 || end > last {
+		end = last
+	}
+
+	list, err := r.alertCompanies(company)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+	for _, co := range list {
+		lastYear, isITR, err := r.lastYear(co.id)
+		if err != nil {
+			continue
+		}
+		found, err := r.companyAlerts(co.id, begin, end, lastYear, isITR)
+		if err != nil {
+			// Skip the company, so the others are still checked
+			fmt.Fprintf(os.Stderr, "[x] %s: %v\n", co.name, err)
+			continue
+		}
+		for _, a := range found {
+			if a.Severity < min {
+				continue
+			}
+			a.Company = co.name
+			alerts = append(alerts, a)
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.Company != b.Company {
+			return a.Company < b.Company
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return a.Severity > b.Severity
+	})
+
+	return alerts, nil
+}
+
+// alertCompanies returns the companies with names containing 'company'.
+func (r Report) alertCompanies(company string) ([]CompanyInfo, error) {
+	rows, err := r.db.Query(`SELECT ID, NAME FROM companies WHERE NAME LIKE ? ORDER BY NAME;`, "%"+company+"%")
+	if err != nil {
+		return nil, errors.Wrap(err, "falha ao ler banco de dados")
+	}
+	defer rows.Close()
+
+	var list []CompanyInfo
+	for rows.Next() {
+		var info CompanyInfo
+		if err := rows.Scan(&info.id, &info.name); err == nil {
+			list = append(list, info)
+		}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+
+	return list, rows.Err()
+}
+
+// companyAlerts returns the alerts of the company with id 'cid' from
+// 'begin' to 'end', up to its 'lastYear' (trailing twelve months, if
+// 'isITR'), with the year set.
+func (r Report) companyAlerts(cid, begin, end, lastYear int, isITR bool) ([]Alert, error) {
+	var alerts []Alert
+	for y := begin; y <= end && y <= lastYear; y++ {
+		var err error
+		v := make(map[uint32]float32)
+		if y == lastYear && isITR {
+			err = r.ttm(cid, v)
+		} else {
+			err = r.dfp(cid, y, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sum(v) == 0 {
+			continue
+		}
+		prev := make(map[uint32]float32)
+		if err := r.dfp(cid, y-1, prev); err != nil {
+			return nil, err
+		}
+		accounts, err := r.RawAccounts(cid, y)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range append(checkAlerts(v, prev), checkOthers(accounts, v)...) {
+			a.Year = y
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts, nil
+}
+
+// checkAlerts returns the red flags found comparing the values of the year
+// ('v') and of the previous year ('prev').
+func checkAlerts(v, prev map[uint32]float32) []Alert {
+	var alerts []Alert
+	add := func(check string, s Severity, format string, a ...interface{}) {
+		pt := message.NewPrinter(language.BrazilianPortuguese)
+		alerts = append(alerts, Alert{Check: check, Severity: s, Message: pt.Sprintf(format, a...)})
+	}
+
+	// Net income not backed by the operating cash flow
+	ni, fco := v[p.LucLiq], v[p.FCO]
+	switch {
+	case ni > 0 && fco < 0:
+		add(alertIncomeFCO, SeverityHigh,
+			"Lucro líquido positivo (%.0f) com FCO negativo (%.0f)", ni, fco)
+	case ni > 0 && fco < 0.5*ni:
+		add(alertIncomeFCO, SeverityMedium,
+			"FCO (%.0f) equivale a apenas %.0f%% do lucro líquido (%.0f)", fco, 100*fco/ni, ni)
+	}
+
+	// Receivables growing faster than sales
+	rec, prevRec := v[p.ContasARecebCirc], prev[p.ContasARecebCirc]
+	sales, prevSales := v[p.Vendas], prev[p.Vendas]
+	if rec > 0 && prevRec > 0 && sales > 0 && prevSales > 0 {
+		recGrowth, salesGrowth := rec/prevRec-1, sales/prevSales-1
+		format := "Contas a receber cresceram %.0f%% e as vendas %.0f%%"
+		switch diff := recGrowth - salesGrowth; {
+		case diff > 0.5:
+			add(alertReceivables, SeverityHigh, format, 100*recGrowth, 100*salesGrowth)
+		case diff > 0.2:
+			add(alertReceivables, SeverityMedium, format, 100*recGrowth, 100*salesGrowth)
+		}
+	}
+
+	// Rising inventory days
+	days, prevDays := inventoryDays(v), inventoryDays(prev)
+	if days > 0 && prevDays > 0 {
+		format := "Prazo médio de estocagem subiu de %.0f para %.0f dias"
+		switch increase := days/prevDays - 1; {
+		case increase > 0.5 && days-prevDays > 15:
+			add(alertInventoryDays, SeverityMedium, format, prevDays, days)
+		case increase > 0.2 && days-prevDays > 10:
+			add(alertInventoryDays, SeverityLow, format, prevDays, days)
+		}
+	}
+
+	// Negative equity
+	if v[p.Equity] < 0 {
+		add(alertEquity, SeverityHigh, "Patrimônio líquido negativo (%.0f)", v[p.Equity])
+	}
+
+	// Dividends not backed by the operating cash flow
+	if proventos := v[p.Dividendos] + v[p.JurosCapProp]; proventos > 0 && proventos > fco {
+		s := SeverityMedium
+		if fco < 0 {
+			s = SeverityHigh
+		}
+		add(alertDividends, s, "Proventos (%.0f) acima do FCO (%.0f)", proventos, fco)
+	}
+
+	return alerts
+}
+
+// inventoryDays returns the inventory days (based on a 360-day year), or 0 if
+// not available.
+func inventoryDays(v map[uint32]float32) float32 {
+	return safeDiv(v[p.Estoque], -v[p.CustoVendas]/360)
+}
+
+// checkOthers returns the "outros" (other) lines from the balance sheet and
+// income statement that are large compared with the total assets or with the
+// net revenue.
+func checkOthers(accounts []AccountValue, v map[uint32]float32) []Alert {
+	var alerts []Alert
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+
+	for _, a := range accounts {
+		cd := a.accItem.cdConta
+		descr := strings.ToLower(p.RemoveDiacritics(a.accItem.dsConta))
+		if !strings.HasPrefix(descr, "outr") || !strings.Contains(cd, ".") {
+			continue
+		}
+
+		var base float32
+		var baseDescr string
+		switch cd[0] {
+		case '1', '2':
+			base, baseDescr = v[p.AtivoTotal], "do ativo total"
+		case '3':
+			base, baseDescr = float32(math.Abs(float64(v[p.Vendas]))), "da receita líquida"
+		default:
+			continue
+		}
+		if base <= 0 {
+			continue
+		}
+
+		ratio := float32(math.Abs(float64(a.value))) / base
+		var s Severity
+		switch {
+		case ratio > 0.25:
+			s = SeverityMedium
+		case ratio > 0.10:
+			s = SeverityLow
+		default:
+			continue
+		}
+		alerts = append(alerts, Alert{
+			Check:    alertOthers,
+			Severity: s,
+			Message:  pt.Sprintf("Conta %s %s equivale a %.0f%% %s", cd, a.accItem.dsConta, 100*ratio, baseDescr),
+		})
+	}
+
+	return alerts
+}
+
+// PrintAlerts prints the alerts, as a table or csv.
+func PrintAlerts(alerts []Alert, format string) {
+	if format == "csv" {
+		fmt.Print(csvAlerts(alerts))
+		return
+	}
+	fmt.Print(printAlerts(alerts))
+}
+
+func printAlerts(alerts []Alert) *strings.Builder {
+	buf := &strings.Builder{}
+
+	var last string
+	for _, a := range alerts {
+		if a.Company != last {
+			if last != "" {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintln(buf, line)
+			fmt.Fprintln(buf, a.Company)
+			fmt.Fprintln(buf, line)
+			last = a.Company
+		}
+		fmt.Fprintf(buf, "  %d  %-5s  %-13s  %s\n", a.Year, a.Severity, a.Check, a.Message)
+	}
+
+	return buf
+}
+
+func csvAlerts(alerts []Alert) *strings.Builder {
+	buf := &strings.Builder{}
+
+	buf.WriteString("Empresa,Ano,Severidade,Alerta,Descrição\n")
+	for _, a := range alerts {
+		fmt.Fprintf(buf, `"%s",%s,%s,%s,"%s"`+"\n",
+			a.Company, strconv.Itoa(a.Year), a.Severity, a.Check, strings.ReplaceAll(a.Message, `"`, `""`))
+	}
+
+	return buf
+}
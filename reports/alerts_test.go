@@ -0,0 +1,197 @@
+package reports
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func alertChecks(alerts []Alert) map[string]Severity {
+	m := make(map[string]Severity)
+	for _, a := range alerts {
+		m[a.Check] = a.Severity
+	}
+	return m
+}
+
+func TestCheckAlerts(t *testing.T) {
+	prev := map[uint32]float32{
+		p.Vendas:           1000,
+		p.CustoVendas:      -720,
+		p.ContasARecebCirc: 100,
+		p.Estoque:          100, // 50 days
+	}
+
+	tests := []struct {
+		name string
+		v    map[uint32]float32
+		want map[string]Severity
+	}{
+		{
+			name: "healthy",
+			v: map[uint32]float32{
+				p.Vendas: 1100, p.CustoVendas: -792, p.ContasARecebCirc: 110, p.Estoque: 110,
+				p.LucLiq: 100, p.FCO: 120, p.Equity: 500, p.Dividendos: 50,
+			},
+			want: map[string]Severity{},
+		},
+		{
+			name: "all flags",
+			v: map[uint32]float32{
+				p.Vendas: 1100, p.CustoVendas: -720, p.ContasARecebCirc: 200, p.Estoque: 180,
+				p.LucLiq: 100, p.FCO: -10, p.Equity: -50, p.JurosCapProp: 20,
+			},
+			want: map[string]Severity{
+				alertIncomeFCO:     SeverityHigh,
+				alertReceivables:   SeverityHigh,
+				alertInventoryDays: SeverityMedium,
+				alertEquity:        SeverityHigh,
+				alertDividends:     SeverityHigh,
+			},
+		},
+		{
+			name: "medium flags",
+			v: map[uint32]float32{
+				p.Vendas: 1000, p.CustoVendas: -720, p.ContasARecebCirc: 130, p.Estoque: 125,
+				p.LucLiq: 100, p.FCO: 40, p.Equity: 500, p.Dividendos: 60,
+			},
+			want: map[string]Severity{
+				alertIncomeFCO:     SeverityMedium,
+				alertReceivables:   SeverityMedium,
+				alertInventoryDays: SeverityLow,
+				alertDividends:     SeverityMedium,
+			},
+		},
+	}
+	for _, tt := range tests {
+		alerts := checkAlerts(tt.v, prev)
+		got := alertChecks(alerts)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for check, s := range tt.want {
+			if got[check] != s {
+				t.Errorf("%s: %s severity = %v, want %v", tt.name, check, got[check], s)
+			}
+		}
+		for _, a := range alerts {
+			if a.Message == "" {
+				t.Errorf("%s: %s without message", tt.name, a.Check)
+			}
+		}
+	}
+}
+
+func TestCheckOthers(t *testing.T) {
+	v := map[uint32]float32{p.AtivoTotal: 1000, p.Vendas: 500}
+	accounts := []AccountValue{
+		{accItem: accItems{cdConta: "1.01.08", dsConta: "Outros Ativos Circulantes"}, value: 50},
+		{accItem: accItems{cdConta: "1.02.01.10", dsConta: "Outros Ativos Não Circulantes"}, value: 150},
+		{accItem: accItems{cdConta: "3.04.05", dsConta: "Outras Despesas Operacionais"}, value: -200},
+		{accItem: accItems{cdConta: "6.01.02", dsConta: "Outros"}, value: 900},
+		{accItem: accItems{cdConta: "3.01", dsConta: "Receita"}, value: 500},
+	}
+
+	alerts := checkOthers(accounts, v)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2: %+v", len(alerts), alerts)
+	}
+	if alerts[0].Severity != SeverityLow || !strings.Contains(alerts[0].Message, "1.02.01.10") {
+		t.Errorf("unexpected alert %+v", alerts[0])
+	}
+	if alerts[1].Severity != SeverityMedium || !strings.Contains(alerts[1].Message, "receita líquida") {
+		t.Errorf("unexpected alert %+v", alerts[1])
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for s, want := range map[string]Severity{"": SeverityLow, "média": SeverityMedium, "ALTA": SeverityHigh} {
+		if got, err := ParseSeverity(s); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("grave"); err == nil {
+		t.Error("expected error for invalid severity")
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	f, err := os.CreateTemp("", "rapina-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE companies (ID INTEGER PRIMARY KEY, CNPJ varchar(20), NAME varchar(100));`,
+		`CREATE TABLE dfp (ID PRIMARY KEY, ID_CIA integer, CODE integer, YEAR string, DATA_TYPE string,
+			VERSAO integer, MOEDA varchar(4), ESCALA_MOEDA varchar(7), DT_FIM_EXERC integer,
+			CD_CONTA varchar(18), DS_CONTA varchar(100), VL_CONTA real);`,
+		`INSERT INTO companies VALUES (1, '1', 'ACME S.A.'), (2, '2', 'BETA S.A.');`,
+	}
+	rows := []struct {
+		cid    int
+		code   uint32
+		year   string
+		cd, ds string
+		value  float64
+	}{
+		{1, p.Equity, "2020", "2.03", "Patrimônio Líquido Consolidado", 100},
+		{1, p.Equity, "2021", "2.03", "Patrimônio Líquido Consolidado", -20},
+		{2, p.Equity, "2021", "2.03", "Patrimônio Líquido Consolidado", 300},
+		{2, p.LucLiq, "2021", "3.11", "Lucro/Prejuízo Consolidado do Período", 100},
+		{2, p.FCO, "2021", "6.01", "Caixa Líquido Atividades Operacionais", 30},
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, r := range rows {
+		_, err := db.Exec(`INSERT INTO dfp (ID, ID_CIA, CODE, YEAR, VERSAO, CD_CONTA, DS_CONTA, VL_CONTA)
+			VALUES (?, ?, ?, ?, 1, ?, ?, ?)`, i, r.cid, r.code, r.year, r.cd, r.ds, r.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := Report{db: db}
+	alerts, err := r.Alerts("", 0, 0, SeverityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2: %+v", len(alerts), alerts)
+	}
+	if a := alerts[0]; a.Company != "ACME S.A." || a.Year != 2021 || a.Check != alertEquity {
+		t.Errorf("unexpected alert %+v", a)
+	}
+	if a := alerts[1]; a.Company != "BETA S.A." || a.Check != alertIncomeFCO || a.Severity != SeverityMedium {
+		t.Errorf("unexpected alert %+v", a)
+	}
+
+	alerts, _ = r.Alerts("BETA", 0, 0, SeverityHigh)
+	if len(alerts) != 0 {
+		t.Errorf("got %+v, want no alerts", alerts)
+	}
+	if _, err := r.Alerts("GAMA", 0, 0, SeverityLow); err == nil {
+		t.Error("expected error for unknown company")
+	}
+
+	out := printAlerts([]Alert{{Company: "ACME S.A.", Year: 2021, Check: alertEquity, Severity: SeverityHigh, Message: "PL"}}).String()
+	if !strings.Contains(out, "2021  alta   pl-negativo") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,144 @@
+package reports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// BatchReport is the report of a company on a batch: the company name (as
+// stored on the DB), the xlsx file and the result of the report.
+type BatchReport struct {
+	Company  string
+	Filename string
+	Err      error
+}
+
+// BatchToXlsx creates the reports of the companies on 'list', one xlsx file
+// per company, with 'workers' reports running concurrently. The reports
+// share the DB connection and the quotes. The result of each report is set
+// on its Err field; items with Err already set are skipped.
+func BatchToXlsx(parms map[string]interface{}, list []BatchReport, workers int) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+	r.quiet = true
+
+	runBatch(list, workers, func(b *BatchReport) error {
+		rc := *r
+		rc.company = b.Company
+		e := newExcel()
+		if err := rc.workbook(e, "", true); err != nil {
+			return err
+		}
+		return e.saveAndCloseExcel(b.Filename)
+	})
+
+	return nil
+}
+
+// CombinedToXlsx creates a single xlsx file (filename set on 'parms') with
+// one sheet per company on 'list', containing the accounts and the metrics.
+// The charts, valuation and sector sheets are not added. The result of each
+// report is set on its Err field; items with Err already set are skipped.
+func CombinedToXlsx(parms map[string]interface{}, list []BatchReport, workers int) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+	r.quiet = true
+
+	e := newExcel()
+	var mu sync.Mutex // the workbook is shared by the workers
+	used := make(map[string]bool)
+
+	runBatch(list, workers, func(b *BatchReport) error {
+		rc := *r
+		rc.company = b.Company
+		d, err := rc.workbookData()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return rc.printWorkbook(e, sheetName(b.Company, used), d, false)
+	})
+
+	for _, b := range list {
+		if b.Err == nil {
+			return e.saveAndCloseExcel(r.filename)
+		}
+	}
+	return errors.New("nenhum relatório gerado")
+}
+
+// runBatch runs 'fn' for each item of the list not failed yet, using
+// 'workers' goroutines, and prints the result as each item is done.
+func runBatch(list []BatchReport, workers int, fn func(b *BatchReport) error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan *BatchReport)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				b.Err = safeRun(fn, b)
+				if b.Err != nil {
+					fmt.Printf("[x] %s: %v\n", b.Company, b.Err)
+				} else {
+					fmt.Printf("[√] %s\n", b.Company)
+				}
+			}
+		}()
+	}
+
+	for i := range list {
+		if list[i].Err == nil {
+			jobs <- &list[i]
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// safeRun runs 'fn', returning a panic as an error, so a single company
+// does not abort the whole batch.
+func safeRun(fn func(b *BatchReport) error, b *BatchReport) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("erro interno: %v", p)
+		}
+	}()
+	return fn(b)
+}
+
+// sheetName returns a valid and unique sheet name (up to 31 characters,
+// without []:*?/\) for the company.
+func sheetName(company string, used map[string]bool) string {
+	const max = 31
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, company)
+	name = strings.TrimSpace(truncate(name, max))
+
+	unique := name
+	for n := 2; used[unique]; n++ {
+		suffix := " (" + strconv.Itoa(n) + ")"
+		unique = truncate(name, max-utf8.RuneCountInString(suffix)) + suffix
+	}
+	used[unique] = true
+
+	return unique
+}
@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dude333/rapina"
+	"github.com/pkg/errors"
+)
+
+func TestBatchToXlsx(t *testing.T) {
+	db := companiesDB(t)
+	dir := t.TempDir()
+	parms := map[string]interface{}{
+		"db":        db,
+		"dataDir":   dir,
+		"SpcfctnCd": "ON",
+		"reports":   map[string]bool{"PrintSector": false},
+		"filename":  filepath.Join(dir, "todas.xlsx"),
+	}
+
+	// Stock codes and quotes on the DB, so nothing is fetched online
+	if _, err := New(parms); err != nil {
+		t.Fatal(err)
+	}
+	date := rapina.LastBusinessDayOfYear(2021)
+	for _, co := range []string{"ACME", "BETA", "GAMA"} {
+		if _, err := db.Exec(`INSERT INTO stock_codes VALUES (?, ?, 'ON', '')`, co+"3", co+" S.A."); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO stock_quotes (stock, date, close) VALUES (?, ?, 10)`, co+"3", date); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func() []BatchReport {
+		return []BatchReport{
+			{Company: "ACME S.A.", Filename: filepath.Join(dir, "acme.xlsx")},
+			{Company: "BETA S.A.", Filename: filepath.Join(dir, "beta.xlsx")},
+			{Company: "XPTO S.A.", Filename: filepath.Join(dir, "xpto.xlsx")},
+			{Company: "GAMA S.A.", Err: errors.New("não encontrada")},
+		}
+	}
+
+	batch := list()
+	if err := BatchToXlsx(parms, batch, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range batch[:2] {
+		if b.Err != nil {
+			t.Errorf("%s: %v", b.Company, b.Err)
+		}
+		if _, err := os.Stat(b.Filename); err != nil {
+			t.Error(err)
+		}
+	}
+	if batch[2].Err == nil {
+		t.Error("expected error for unknown company")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gama.xlsx")); err == nil {
+		t.Error("failed item should be skipped")
+	}
+
+	combined := list()
+	if err := CombinedToXlsx(parms, combined, 2); err != nil {
+		t.Fatal(err)
+	}
+	if combined[0].Err != nil || combined[1].Err != nil || combined[2].Err == nil {
+		t.Errorf("unexpected results: %+v", combined)
+	}
+	if _, err := os.Stat(parms["filename"].(string)); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_sheetName(t *testing.T) {
+	used := make(map[string]bool)
+	tests := []struct {
+		company, want string
+	}{
+		{"ACME S.A.", "ACME S.A."},
+		{"ACME S.A.", "ACME S.A. (2)"},
+		{"A/B [C]", "A B  C"},
+		{"COMPANHIA DE SANEAMENTO BASICO DO ESTADO", "COMPANHIA DE SANEAMENTO BASICO"},
+		{"COMPANHIA DE SANEAMENTO BASICO DO PARANA", "COMPANHIA DE SANEAMENTO BAS (2)"},
+	}
+	for _, tt := range tests {
+		if got := sheetName(tt.company, used); got != tt.want {
+			t.Errorf("sheetName(%q) = %q, want %q", tt.company, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// canvas is a drawing surface with the origin on the top left corner, used
+// to draw the tearsheet charts as SVG (HTML) or on a PDF page.
+type canvas interface {
+	rect(x, y, w, h float64, color string)
+	line(x1, y1, x2, y2, width float64, color string)
+	polyline(points []float64, width float64, color string)
+	text(x, y, size float64, anchor string, bold bool, color, s string)
+}
+
+// Text anchors
+const (
+	anchorStart  = "start"
+	anchorMiddle = "middle"
+	anchorEnd    = "end"
+)
+
+// Chart colors, the same accent colors used on the xlsx charts
+var chartColors = []string{"#4472C4", "#ED7D31", "#A5A5A5", "#FFC000", "#5B9BD5", "#70AD47"}
+
+// svgCanvas draws on a SVG image.
+type svgCanvas struct {
+	buf  strings.Builder
+	w, h float64
+}
+
+func newSVGCanvas(w, h float64) *svgCanvas {
+	c := &svgCanvas{w: w, h: h}
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" width="%g" height="%g" font-family="Helvetica, Arial, sans-serif">`, w, h, w, h)
+	return c
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, color string) {
+	fmt.Fprintf(&c.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, x, y, w, h, color)
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2, width float64, color string) {
+	fmt.Fprintf(&c.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%g"/>`, x1, y1, x2, y2, color, width)
+}
+
+func (c *svgCanvas) polyline(points []float64, width float64, color string) {
+	c.buf.WriteString(`<polyline points="`)
+	for i := 0; i+1 < len(points); i += 2 {
+		fmt.Fprintf(&c.buf, "%.1f,%.1f ", points[i], points[i+1])
+	}
+	fmt.Fprintf(&c.buf, `" fill="none" stroke="%s" stroke-width="%g"/>`, color, width)
+}
+
+func (c *svgCanvas) text(x, y, size float64, anchor string, bold bool, color, s string) {
+	weight := ""
+	if bold {
+		weight = ` font-weight="bold"`
+	}
+	fmt.Fprintf(&c.buf, `<text x="%.1f" y="%.1f" font-size="%g" text-anchor="%s" fill="%s"%s>%s</text>`,
+		x, y, size, anchor, color, weight, html.EscapeString(s))
+}
+
+// String returns the SVG image.
+func (c *svgCanvas) String() string {
+	return c.buf.String() + "</svg>"
+}
+
+// drawChart draws the chart on the canvas, inside the box (x, y, w, h): the
+// title, the Y axis with the grid, the columns or lines of each series, the
+// years and the legend.
+func drawChart(c canvas, ch TearsheetChart, x, y, w, h float64) {
+	const (
+		titleH  = 14.0
+		legendH = 12.0
+		labelW  = 44.0
+		labelH  = 10.0
+	)
+	c.text(x+w/2, y+9, 8, anchorMiddle, true, "#333333", ch.Title)
+
+	// Plot area
+	px, py := x+labelW, y+titleH
+	pw, ph := w-labelW-4, h-titleH-labelH-legendH
+	n := len(ch.Labels)
+	if n == 0 || pw <= 0 || ph <= 0 {
+		return
+	}
+
+	min, max := 0.0, 0.0
+	for _, s := range ch.Series {
+		for _, v := range s.Values {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	ticks := chartTicks(min, max)
+	min, max = ticks[0], ticks[len(ticks)-1]
+	yPos := func(v float64) float64 { return py + ph - (v-min)/(max-min)*ph }
+
+	// Grid and Y axis labels
+	for _, t := range ticks {
+		color := "#E0E0E0"
+		if t == 0 {
+			color = "#808080"
+		}
+		c.line(px, yPos(t), px+pw, yPos(t), 0.5, color)
+		c.text(px-3, yPos(t)+2, 6, anchorEnd, false, "#555555", chartLabel(t, ch.format))
+	}
+
+	// Series
+	slot := pw / float64(n)
+	for i, s := range ch.Series {
+		color := chartColors[i%len(chartColors)]
+		switch ch.kind {
+		case chartCol:
+			barW := slot * 0.7 / float64(len(ch.Series))
+			for j, v := range s.Values {
+				bx := px + float64(j)*slot + slot*0.15 + float64(i)*barW
+				top, bottom := yPos(math.Max(v, 0)), yPos(math.Min(v, 0))
+				c.rect(bx, top, barW, bottom-top, color)
+			}
+		default:
+			points := make([]float64, 0, 2*len(s.Values))
+			for j, v := range s.Values {
+				points = append(points, px+(float64(j)+0.5)*slot, yPos(v))
+			}
+			c.polyline(points, 1.5, color)
+		}
+	}
+
+	// Years
+	for j, label := range ch.Labels {
+		c.text(px+(float64(j)+0.5)*slot, py+ph+8, 6, anchorMiddle, false, "#555555", label)
+	}
+
+	// Legend
+	lx, ly := px, y+h-3
+	for i, s := range ch.Series {
+		c.rect(lx, ly-5, 5, 5, chartColors[i%len(chartColors)])
+		c.text(lx+7, ly, 6, anchorStart, false, "#333333", s.Name)
+		lx += 7 + textWidth(s.Name, 6, false) + 10
+	}
+}
+
+// chartTicks returns the values of the Y axis ticks, about 5, covering the
+// range from 'min' to 'max'.
+func chartTicks(min, max float64) []float64 {
+	if max-min == 0 {
+		max = min + 1
+	}
+	step := niceNum((max - min) / 4)
+	first := math.Floor(min/step) * step
+	var ticks []float64
+	for t := first; t < max+step/2; t += step {
+		if math.Abs(t) < step/1e6 {
+			t = 0
+		}
+		ticks = append(ticks, t)
+	}
+	if len(ticks) < 2 {
+		ticks = append(ticks, first+step)
+	}
+	return ticks
+}
+
+// niceNum returns a "round" number (1, 2 or 5 times a power of 10) close to
+// 'x'.
+func niceNum(x float64) float64 {
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+	switch {
+	case f <= 1:
+		f = 1
+	case f <= 2:
+		f = 2
+	case f <= 5:
+		f = 5
+	default:
+		f = 10
+	}
+	return f * math.Pow(10, exp)
+}
+
+// chartLabel returns the value formatted for the Y axis.
+func chartLabel(v float64, format int) string {
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+	switch format {
+	case PERCENT:
+		return pt.Sprintf("%.0f%%", 100*v)
+	case INDEX:
+		return pt.Sprintf("%.1f", v)
+	}
+	return pt.Sprintf("%.0f", v)
+}
@@ -0,0 +1,173 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Chart types (excelize)
+const (
+	chartCol     = "col"
+	chartLine    = "line"
+	chartScatter = "scatter"
+)
+
+// maxSeries is the number of series with distinct colors on a chart (the
+// accent colors of the default theme).
+const maxSeries = 6
+
+// chartSeries contains the references to the cells with the name, the
+// categories (X axis) and the values of a chart series.
+type chartSeries struct {
+	name       string
+	categories string
+	values     string
+}
+
+// addChart adds a chart of 'kind' (chartX constants) on 'cell'. Only the
+// first maxSeries series are plotted.
+func (s *Sheet) addChart(kind, cell, title string, series []chartSeries) error {
+	type jsonSeries struct {
+		Name       string `json:"name"`
+		Categories string `json:"categories"`
+		Values     string `json:"values"`
+	}
+	if len(series) > maxSeries {
+		series = series[:maxSeries]
+	}
+	list := make([]jsonSeries, len(series))
+	for i, ser := range series {
+		list[i] = jsonSeries{Name: ser.name, Categories: ser.categories, Values: ser.values}
+	}
+
+	format, err := json.Marshal(map[string]interface{}{
+		"type":      kind,
+		"series":    list,
+		"title":     map[string]string{"name": title},
+		"legend":    map[string]string{"position": "bottom"},
+		"dimension": map[string]int{"width": 640, "height": 300},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.xlsx.AddChart(s.name, cell, string(format))
+}
+
+// ref returns the absolute reference to the cells of the sheet used on the
+// chart series, e.g., "B2:F2" => "'SHEET'!$B$2:$F$2". Multiple ranges
+// (e.g., "B2 F2") are returned as a union.
+func (s *Sheet) ref(cells string) string {
+	name := "'" + strings.ReplaceAll(s.name, "'", "''") + "'!"
+
+	var refs []string
+	for _, r := range strings.Fields(cells) {
+		parts := strings.Split(r, ":")
+		for i, c := range parts {
+			n := strings.IndexFunc(c, unicode.IsDigit)
+			if n > 0 {
+				parts[i] = "$" + c[:n] + "$" + c[n:]
+			}
+		}
+		refs = append(refs, name+strings.Join(parts, ":"))
+	}
+	if len(refs) == 1 {
+		return refs[0]
+	}
+	return "(" + strings.Join(refs, ",") + ")"
+}
+
+// companyChartList contains the charts of the main metrics of the company,
+// used on the xlsx (GRAFICOS sheet) and on the tearsheet.
+var companyChartList = []struct {
+	kind    string
+	title   string
+	metrics []string
+}{
+	{chartCol, "Receita, EBITDA e Lucro Líquido", []string{"Receita Líquida", "EBITDA", "Lucro Líquido"}},
+	{chartLine, "Margens", []string{"Marg. EBITDA", "Marg. EBIT", "Marg. Líq."}},
+	{chartLine, "Dívida Líquida/EBITDA", []string{"Dív.Líq./EBITDA"}},
+	{chartCol, "Proventos", []string{"Proventos"}},
+	{chartLine, "Payout", []string{"Payout"}},
+}
+
+// companyCharts adds to 'sheet' the charts of the main metrics of the
+// company, using the values printed on the 'data' sheet: the metrics on
+// 'rows' (by description), with the descriptions on column B, from column
+// 'first' to 'last', and the years on 'titleRow'.
+func companyCharts(sheet, data *Sheet, rows map[string]int, titleRow, first, last int) {
+	categories := data.ref(axis(first, titleRow) + ":" + axis(last, titleRow))
+	n := 0
+	for _, c := range companyChartList {
+		var series []chartSeries
+		for _, descr := range c.metrics {
+			row, ok := rows[descr]
+			if !ok {
+				continue
+			}
+			series = append(series, chartSeries{
+				name:       data.ref(axis(1, row)),
+				categories: categories,
+				values:     data.ref(axis(first, row) + ":" + axis(last, row)),
+			})
+		}
+		if len(series) == 0 {
+			continue // e.g., EBITDA on banks
+		}
+
+		// Two charts per row
+		cell := axis(1+(n%2)*11, 2+(n/2)*17)
+		_ = sheet.addChart(c.kind, cell, c.title, series)
+		n++
+	}
+}
+
+// scatter adds to the sector sheet, on (col, row), a chart of the ROE versus
+// the P/L of the companies on the last year with both metrics available for
+// at least 2 companies, highlighting the 'company' of the report.
+func (d *sectorData) scatter(sheet *Sheet, company string, col, row int) {
+	for y := d.end; y >= d.begin; y-- {
+		pl, roe := fmt.Sprintf("%d|P/L", y), fmt.Sprintf("%d|ROE", y)
+
+		// Same company order on both ranges, to keep the (x, y) pairs
+		companies := make([]string, 0, len(d.sheetCells))
+		for co := range d.sheetCells {
+			companies = append(companies, co)
+		}
+		sort.Strings(companies)
+		var x, v []string
+		for _, co := range companies {
+			cells := d.sheetCells[co]
+			if cells[pl] != "" && cells[roe] != "" && co != company {
+				x, v = append(x, cells[pl]), append(v, cells[roe])
+			}
+		}
+		main := d.sheetCells[company]
+		hasMain := main[pl] != "" && main[roe] != ""
+		if len(x) == 0 || (len(x) < 2 && !hasMain) {
+			continue
+		}
+
+		// Series name, covered by the chart
+		label := axis(col, row)
+		sheet.printCell(row, col, "Demais empresas", 0)
+
+		series := []chartSeries{{
+			name:       sheet.ref(label),
+			categories: sheet.ref(strings.Join(x, " ")),
+			values:     sheet.ref(strings.Join(v, " ")),
+		}}
+		if hasMain {
+			series = append(series, chartSeries{
+				name:       sheet.ref(d.titles[company]),
+				categories: sheet.ref(main[pl]),
+				values:     sheet.ref(main[roe]),
+			})
+		}
+		_ = sheet.addChart(chartScatter, label, fmt.Sprintf("ROE x P/L [%d]", y), series)
+		return
+	}
+}
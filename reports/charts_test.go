@@ -0,0 +1,92 @@
+package reports
+
+import (
+	"archive/zip"
+	"html"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSheet_ref(t *testing.T) {
+	tests := []struct {
+		sheet, cells, want string
+	}{
+		{"GRAFICOS", "B2:F2", "'GRAFICOS'!$B$2:$F$2"},
+		{"GRAFICOS", "AB10", "'GRAFICOS'!$AB$10"},
+		{"D'OR", "B2", "'D''OR'!$B$2"},
+		{"SETOR", "C5 F5", "('SETOR'!$C$5,'SETOR'!$F$5)"},
+	}
+	for _, tt := range tests {
+		s := &Sheet{name: tt.sheet}
+		if got := s.ref(tt.cells); got != tt.want {
+			t.Errorf("ref(%q) = %q, want %q", tt.cells, got, tt.want)
+		}
+	}
+}
+
+func Test_scatter(t *testing.T) {
+	e := newExcel()
+	sheet, err := e.newSheet("SETOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &sectorData{
+		begin: 2020,
+		end:   2021,
+		sheetCells: map[string]map[string]string{
+			"ACME": {"2020|P/L": "C10", "2020|ROE": "C11", "2021|ROE": "C21"},
+			"BETA": {"2020|P/L": "K10", "2020|ROE": "F11"},
+			"GAMA": {"2020|P/L": "I10", "2020|ROE": "I11", "2021|P/L": "I20"},
+		},
+		titles: map[string]string{"ACME": "C2"},
+	}
+	d.scatter(sheet, "ACME", 12, 2)
+
+	filename := filepath.Join(t.TempDir(), "setor.xlsx")
+	if err := e.saveAndCloseExcel(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	chart := html.UnescapeString(readZipFile(t, filename, "xl/charts/chart1.xml"))
+	for _, s := range []string{
+		"ROE x P/L [2020]",              // 2021 has no company with both metrics
+		"('SETOR'!$K$10,'SETOR'!$I$10)", // pairs in the same company order
+		"('SETOR'!$F$11,'SETOR'!$I$11)",
+		"'SETOR'!$C$10",
+		"'SETOR'!$C$2",
+	} {
+		if !strings.Contains(chart, s) {
+			t.Errorf("missing %q on chart", s)
+		}
+	}
+}
+
+// readZipFile returns the contents of 'name' inside the xlsx file.
+func readZipFile(t *testing.T, filename, name string) string {
+	z, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	t.Fatalf("%s not found on %s", name, filename)
+	return ""
+}
@@ -0,0 +1,269 @@
+package reports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// CompareToXlsx reports the metrics of the 'companies' side by side, on the
+// last 'years' available on the DB (all years if zero), into the xlsx file
+// set on 'parms'. If 'charts' is set, creates a sheet with the monetary
+// metrics of each company normalized to 100 on the first year.
+func CompareToXlsx(parms map[string]interface{}, companies []string, years int, charts bool) error {
+	r, sd, err := newComparison(parms, companies, years)
+	if err != nil {
+		return err
+	}
+
+	e := newExcel()
+	sheet, err := e.newSheet("COMPARATIVO")
+	if err != nil {
+		return err
+	}
+	_ = sheet.xlsx.SetSheetViewOptions(sheet.name, 0,
+		excelize.ShowGridLines(false),
+		excelize.ZoomScale(80),
+	)
+
+	fTitle := newFormat(DEFAULT, LEFT, false)
+	fTitle.size(14)
+	sheet.printCell(1, 1, "COMPARATIVO ("+r.unitLabel()+")", fTitle.newStyle(sheet.xlsx))
+
+	// Companies side by side, with the descriptions on the first column
+	row, col := 2, 0
+	printed := 0
+	for _, co := range companies {
+		row = 2
+		col++
+		empty, err := r.companySummary(sheet, &row, &col, co, "", printed == 0, blockCompany, sd)
+		if err != nil || empty {
+			fmt.Printf("[x] %s: sem dados\n", co)
+			col--
+			continue
+		}
+		fmt.Println("[√]", co)
+		printed++
+	}
+	if printed == 0 {
+		return errors.New("nenhuma empresa com dados")
+	}
+	sd.colorScales(sheet)
+	sheet.setColWidth(0, 2)
+
+	if charts {
+		sheet2, err := e.newSheet("GRAFICOS")
+		if err == nil {
+			r.normalizedCharts(sheet2, companies, sd)
+		}
+	}
+
+	err = e.saveAndCloseExcel(r.filename)
+	if err == nil {
+		fmt.Printf("[√] Dados salvos em %s\n", r.filename)
+	}
+
+	return err
+}
+
+// CompareToStdout prints the metrics of the 'companies' side by side, on the
+// last 'years' available on the DB (all years if zero).
+func CompareToStdout(parms map[string]interface{}, companies []string, years int) error {
+	r, sd, err := newComparison(parms, companies, years)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(r.printComparison(companies, sd))
+
+	return nil
+}
+
+// newComparison returns the report and the metrics of the 'companies' on
+// the last 'years' available on the DB.
+func newComparison(parms map[string]interface{}, companies []string, years int) (*Report, *sectorData, error) {
+	if len(companies) == 0 {
+		return nil, nil, errors.New("nenhuma empresa informada")
+	}
+
+	r, err := New(parms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if years > 0 && end-years+1 > begin {
+		begin = end - years + 1
+	}
+	if err = r.setDeflators(begin, end); err != nil {
+		return nil, nil, err
+	}
+
+	return r, r.sectorData(companies, begin, end), nil
+}
+
+// comparedMetrics returns the metrics reported for the companies, in the
+// order of the reports, without repetition. Metrics not available for any
+// company are skipped.
+func (d *sectorData) comparedMetrics(companies []string) []metric {
+	var list []metric
+	seen := make(map[string]bool)
+	available := make(map[string]bool)
+	for _, co := range companies {
+		for y := d.begin; y <= d.end; y++ {
+			for _, m := range d.companies[co][y] {
+				if !seen[m.descr] {
+					seen[m.descr] = true
+					list = append(list, metric{descr: m.descr, format: m.format, group: m.group})
+				}
+				available[m.descr] = available[m.descr] || valid(m.val)
+			}
+		}
+	}
+
+	metrics := list[:0]
+	for _, m := range list {
+		if available[m.descr] {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// companyMetric returns the metric of the company on 'year', if available.
+func (d *sectorData) companyMetric(company string, year int, descr string) (metric, bool) {
+	for _, m := range d.companies[company][year] {
+		if m.descr == descr {
+			return m, valid(m.val)
+		}
+	}
+	return metric{}, false
+}
+
+// printComparison prints a table for each metric, with one line per company
+// and one column per year.
+func (r Report) printComparison(companies []string, d *sectorData) *strings.Builder {
+	buf := &strings.Builder{}
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+
+	fmt.Fprintln(buf, line)
+	fmt.Fprintf(buf, "COMPARATIVO (%s)\n", r.unitLabel())
+	fmt.Fprintln(buf, line)
+
+	for _, m := range d.comparedMetrics(companies) {
+		fmt.Fprintf(buf, "\n%-36s", m.descr)
+		for y := d.begin; y <= d.end; y++ {
+			fmt.Fprintf(buf, " %14s", "["+strconv.Itoa(y)+"]")
+		}
+		buf.WriteByte('\n')
+
+		for _, co := range companies {
+			if len(d.accounts[co]) == 0 {
+				continue
+			}
+			fmt.Fprintf(buf, "  %-34s", truncate(co, 34))
+			for y := d.begin; y <= d.end; y++ {
+				v, ok := d.companyMetric(co, y, m.descr)
+				if !ok {
+					fmt.Fprintf(buf, " %14s", "-")
+					continue
+				}
+				val := r.display(v, y)
+				switch v.format {
+				case PERCENT:
+					pt.Fprintf(buf, " %13.1f%%", 100*val)
+				case INDEX:
+					pt.Fprintf(buf, " %14.2f", val)
+				default:
+					pt.Fprintf(buf, " %14.0f", val)
+				}
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf
+}
+
+// truncate returns the first 'n' characters of 's'.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// normalizedCharts prints, for each monetary metric, the values of the
+// companies normalized to 100 on the first year with a positive value,
+// followed by a line chart.
+func (r Report) normalizedCharts(sheet *Sheet, companies []string, d *sectorData) {
+	sTitle := newFormat(DEFAULT, LEFT, true).newStyle(sheet.xlsx)
+	sVal := newFormat(INDEX, DEFAULT, false).newStyle(sheet.xlsx)
+
+	// Rows used by each metric: title, years, companies, and the chart height
+	height := len(companies) + 3
+	if height < 16 {
+		height = 16
+	}
+	sheet.setColWidth(0, 2)
+	sheet.setColWidth(1, 36)
+
+	top := 1
+	for _, m := range d.comparedMetrics(companies) {
+		if m.format != NUMBER {
+			continue
+		}
+
+		sheet.printCell(top, 1, m.descr+" (base 100)", sTitle)
+		for y := d.begin; y <= d.end; y++ {
+			_ = sheet.printTitle(axis(2+y-d.begin, top+1), strconv.Itoa(y))
+		}
+
+		var series []chartSeries
+		row := top + 2
+		for _, co := range companies {
+			var base float32
+			values := make(map[int]float32)
+			for y := d.begin; y <= d.end; y++ {
+				v, ok := d.companyMetric(co, y, m.descr)
+				if !ok {
+					continue
+				}
+				val := r.display(v, y) // constant currency, if set
+				if base == 0 && val > 0 {
+					base = val
+				}
+				if base != 0 {
+					values[y] = 100 * val / base
+				}
+			}
+			if len(values) < 2 {
+				continue
+			}
+			sheet.printCell(row, 1, co, 0)
+			for y, v := range values {
+				sheet.printCell(row, 2+y-d.begin, v, sVal)
+			}
+			series = append(series, chartSeries{
+				name:       sheet.ref(axis(1, row)),
+				categories: sheet.ref(axis(2, top+1) + ":" + axis(2+d.end-d.begin, top+1)),
+				values:     sheet.ref(axis(2, row) + ":" + axis(2+d.end-d.begin, row)),
+			})
+			row++
+		}
+		if len(series) == 0 {
+			continue
+		}
+
+		_ = sheet.addChart(chartLine, axis(4+d.end-d.begin, top), m.descr, series)
+		top += height
+	}
+}
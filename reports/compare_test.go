@@ -0,0 +1,53 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestCompare(t *testing.T) {
+	db := companiesDB(t)
+	for cid, equity := range []float64{50, 400} {
+		_, err := db.Exec(`INSERT INTO dfp (ID, ID_CIA, CODE, YEAR, VERSAO, CD_CONTA, DS_CONTA, VL_CONTA)
+			VALUES (?, ?, ?, '2020', 1, '2.03', 'Patrimônio Líquido Consolidado', ?)`, 10+cid, cid+1, p.Equity, equity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := Report{db: db, groups: map[int]bool{grpAccts: true}}
+	companies := []string{"ACME S.A.", "BETA S.A.", "GAMA S.A.", "XPTO S.A."}
+	d := r.sectorData(companies, 2020, 2021)
+
+	out := r.printComparison(companies, d).String()
+	for _, s := range []string{
+		"Patrimônio Líquido                           [2020]         [2021]",
+		"  ACME S.A.                                      50            100",
+		"  GAMA S.A.                                       -          1.000",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q on:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "XPTO") {
+		t.Errorf("company without data on:\n%s", out)
+	}
+
+	filename := filepath.Join(t.TempDir(), "comparativo.xlsx")
+	parms := map[string]interface{}{
+		"db":       db,
+		"dataDir":  t.TempDir(),
+		"filename": filename,
+		"reports":  map[string]bool{},
+	}
+	if err := CompareToXlsx(parms, companies, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Error(err)
+	}
+}
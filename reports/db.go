@@ -3,6 +3,7 @@ package reports
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -104,10 +105,21 @@ func (r Report) accountsValues(year int) (map[uint32]float32, error) {
 		values[parsers.EquityAvg] = avg(values[parsers.Equity], v)
 	}
 
+	// Piotroski F-Score (compared with last year)
+	prev := make(map[uint32]float32)
+	if err = r.dfp(r.cid, year-1, prev); err == nil && sum(prev) != 0 {
+		_ = r.shares(r.cid, year-1, prev)
+		values[parsers.FScore] = piotroski(values, prev)
+	}
+
 	// Stock code
 	if r.code != "" {
 		date := rapina.LastBusinessDayOfYear(year)
-		q, err := r.fetchStock.Quote(r.code, date)
+		quote := r.fetchStock.Quote
+		if r.storedQuotes {
+			quote = r.fetchStock.StoredQuote
+		}
+		q, err := quote(r.code, date)
 		if err == nil {
 			values[parsers.Quote] = float32(q)
 		}
@@ -539,7 +551,7 @@ func (r Report) accountsAverage(company string, year int) (map[uint32]float32, e
 
 	companies, _, err := r.fromSector(company)
 	if len(companies) <= 1 || err != nil {
-		err = errors.Wrap(err, "erro ao ler arquivo de setores "+r.yamlFile)
+		err = errors.Wrap(err, "erro ao ler setores")
 		return nil, err
 	}
 
@@ -555,6 +567,64 @@ func (r Report) accountsAverage(company string, year int) (map[uint32]float32, e
 		}
 	}
 
+	values, err := r.sectorValues(cids, year)
+	if err != nil {
+		return nil, err
+	}
+
+	var err1, err2 error
+	values[parsers.EstoqueMedio], err1 = r.movingAvg(cids, year, parsers.Estoque)
+	values[parsers.EquityAvg], err2 = r.movingAvg(cids, year, parsers.Equity)
+
+	if err1 == nil && err2 == nil {
+		_ = r.sharesAvg(cids, year, values)
+	}
+
+	// Piotroski F-Score (compared with last year)
+	if score, ok := r.sectorFScore(cids, year); ok {
+		values[parsers.FScore] = score
+	}
+
+	return values, nil
+}
+
+//
+// sectorFScore returns the average Piotroski F-Score of the companies listed
+// on 'cids' in 'year', scoring each company against its own previous year.
+// Companies without data on both years are not counted. Returns false if no
+// company could be scored.
+//
+func (r Report) sectorFScore(cids []string, year int) (float32, bool) {
+	var total float32
+	var n int
+	for _, cid := range cids {
+		if cid == "" {
+			continue
+		}
+		v, err := r.sectorValues([]string{cid}, year)
+		if err != nil || sum(v) == 0 {
+			continue
+		}
+		prev, err := r.sectorValues([]string{cid}, year-1)
+		if err != nil || sum(prev) == 0 {
+			continue
+		}
+		_ = r.sharesAvg([]string{cid}, year, v)
+		_ = r.sharesAvg([]string{cid}, year-1, prev)
+		total += piotroski(v, prev)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return total / float32(n), true
+}
+
+//
+// sectorValues returns the average value of each account of the companies
+// listed on 'cids' in 'year'.
+//
+func (r Report) sectorValues(cids []string, year int) (map[uint32]float32, error) {
 	selectReport := fmt.Sprintf(`
 	SELECT
 		CODE, AVG(VL_CONTA)
@@ -587,14 +657,6 @@ func (r Report) accountsAverage(company string, year int) (map[uint32]float32, e
 		}
 	}
 
-	var err1, err2 error
-	values[parsers.EstoqueMedio], err1 = r.movingAvg(cids, year, parsers.Estoque)
-	values[parsers.EquityAvg], err2 = r.movingAvg(cids, year, parsers.Equity)
-
-	if err1 == nil && err2 == nil {
-		_ = r.sharesAvg(cids, year, values)
-	}
-
 	return values, nil
 }
 
@@ -627,29 +689,19 @@ func (r Report) movingAvg(cids []string, year int, code uint32) (float32, error)
 }
 
 func (r Report) fromSector(company string) (companies []string, sectorName string, err error) {
-	// Companies from the same sector
-	secCo, secName, err := parsers.FromSector(company, r.yamlFile)
-	if len(secCo) <= 1 || err != nil {
-		err = errors.Wrap(err, "erro ao ler arquivo dos setores "+r.yamlFile)
-		return
+	// User-defined peer group, including the company
+	if len(r.peers) > 0 {
+		companies = removeDuplicates(append(append(companies, r.peers...), company))
+		sort.Strings(companies)
+		return companies, "Grupo: " + r.peersName, nil
 	}
 
-	// All companies stored on db
-	list, err := ListCompanies(r.db)
+	// Companies from the same segment, linked by CNPJ
+	companies, sectorName, err = parsers.FromSector(r.db, company)
 	if err != nil {
-		err = errors.Wrap(err, "erro ao listar empresas")
-		return
-	}
-
-	// Translate company names to match the name stored on db
-	for _, s := range secCo {
-		z := parsers.FuzzyFind(s, list, 3)
-		if len(z) > 0 {
-			companies = append(companies, z)
-		}
+		err = errors.Wrap(err, "erro ao ler setores")
 	}
-
-	return removeDuplicates(companies), secName, nil
+	return
 }
 
 // CompanyInfo contains the company name and CNPJ
@@ -745,6 +797,7 @@ func (r *Report) setCompanyAndTicker(company string, spcfctnCd string) error {
 	r.cid = 0
 	r.cnpj = ""
 	r.code = ""
+	r.storedQuotes = false
 
 	query := `SELECT DISTINCT ID, NAME, CNPJ FROM companies WHERE NAME LIKE ?`
 	var cid int
@@ -756,16 +809,90 @@ func (r *Report) setCompanyAndTicker(company string, spcfctnCd string) error {
 	r.cid = cid
 	r.company = name // reset company name to match the name stored on db
 	r.cnpj = cnpj
+	r.layout = r.statementsLayout(cid)
 
 	// Stock code
 	r.code, err = r.fetchStock.Code(r.company, spcfctnCd)
 	if err != nil {
-		fmt.Printf("\n[x] Erro obtendo código negociação: %v\n", err)
+		r.printf("\n[x] Erro obtendo código negociação: %v\n", err)
+	}
+
+	return nil
+}
+
+//
+// setPeer sets the company ID, CNPJ and layout of a company of the sector,
+// selected by its exact name. The stock code is only set if found on the
+// sectors table, and the quotes of the peers are only read from the
+// database (never downloaded).
+//
+func (r *Report) setPeer(company string) error {
+	query := `SELECT ID, NAME, CNPJ FROM companies WHERE NAME = ?`
+	var cid int
+	var name, cnpj string
+	err := r.db.QueryRow(query, company).Scan(&cid, &name, &cnpj)
+	if err != nil {
+		return errors.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+	r.cid = cid
+	r.company = name
+	r.cnpj = cnpj
+	r.code = ""
+	r.storedQuotes = true
+	r.layout = r.statementsLayout(cid)
+
+	// Trading code from the sectors table, to avoid fetching it online
+	if r.fetchStock != nil {
+		var ticker sql.NullString
+		err = r.db.QueryRow(`SELECT TICKER FROM sectors WHERE CNPJ = ?`, cnpj).Scan(&ticker)
+		if err == nil {
+			r.code = ticker.String
+		}
 	}
 
 	return nil
 }
 
+//
+// statementsLayout returns the layout of the company statements, based on
+// the accounts of its income statement (banks and insurance companies have
+// their own charts of accounts) or, if not conclusive, on its segment on the
+// sectors file.
+//
+func (r Report) statementsLayout(cid int) int {
+	query := `SELECT
+		COUNT(CASE WHEN CD_CONTA = '3.01' AND DS_CONTA LIKE 'Receitas da Intermedia%' THEN 1 END),
+		COUNT(CASE WHEN CD_CONTA LIKE '3.%' AND DS_CONTA = 'Prêmios Ganhos' THEN 1 END)
+	FROM dfp WHERE ID_CIA = ?;`
+	var bank, insurer int
+	err := r.db.QueryRow(query, cid).Scan(&bank, &insurer)
+	if err == nil && bank > 0 {
+		return layoutBank
+	}
+	if err == nil && insurer > 0 {
+		return layoutInsurer
+	}
+
+	_, sectorName, err := parsers.FromSector(r.db, r.company)
+	if err != nil {
+		return layoutStd
+	}
+	return segmentLayout(sectorName)
+}
+
+// segmentLayout returns the statements layout used by the companies of the
+// segment ("sector > subsector > segment").
+func segmentLayout(sectorName string) int {
+	names := strings.Split(sectorName, " > ")
+	switch strings.ToLower(names[len(names)-1]) {
+	case "bancos":
+		return layoutBank
+	case "seguradoras":
+		return layoutInsurer
+	}
+	return layoutStd
+}
+
 func (r *Report) getCid(companyName string) (int, error) {
 	selectID := `SELECT DISTINCT ID FROM companies WHERE NAME LIKE ?`
 	var cid int
@@ -774,6 +901,18 @@ func (r *Report) getCid(companyName string) (int, error) {
 	return cid, err
 }
 
+// companyName returns the name of the company containing 'company', giving
+// preference to the exact match and to the shortest name.
+func (r Report) companyName(company string) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT NAME FROM companies WHERE NAME LIKE ?
+		ORDER BY NAME = ? DESC, LENGTH(NAME) LIMIT 1`, "%"+company+"%", company).Scan(&name)
+	if err != nil {
+		return "", fmt.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+	return name, nil
+}
+
 //
 // scale returns the financial scale used on the values (unit or thousands).
 //
@@ -791,7 +930,7 @@ func (r Report) scale(cid, year int, table string) float32 {
 	switch scale {
 	case "UNIDADE":
 		return 1
-	case "MIL":
+	case "MIL", "MILHAR":
 		return 1000
 	case "MILHAO":
 		return 1000000
@@ -800,6 +939,13 @@ func (r Report) scale(cid, year int, table string) float32 {
 	return 1000
 }
 
+//
+// TimeRange returns the first and the last years stored on the DB
+//
+func TimeRange(db *sql.DB) (int, int, error) {
+	return timeRange(db)
+}
+
 //
 // timeRange returns the begin=min(year) and end=max(year)
 //
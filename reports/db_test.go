@@ -1,6 +1,13 @@
 package reports
 
-import "testing"
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+	_ "github.com/mattn/go-sqlite3"
+)
 
 func Test_avg(t *testing.T) {
 	type args struct {
@@ -24,3 +31,82 @@ func Test_avg(t *testing.T) {
 		})
 	}
 }
+
+func TestFromSectorPeers(t *testing.T) {
+	r := Report{peers: []string{"BETA S.A.", "ACME S.A.", "BETA S.A."}, peersName: "teste"}
+
+	companies, name, err := r.fromSector("GAMA S.A.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"ACME S.A.", "BETA S.A.", "GAMA S.A."}
+	if len(companies) != len(expected) {
+		t.Fatalf("fromSector() = %v, want %v", companies, expected)
+	}
+	for i := range expected {
+		if companies[i] != expected[i] {
+			t.Errorf("fromSector() = %v, want %v", companies, expected)
+		}
+	}
+	if name != "Grupo: teste" {
+		t.Errorf("fromSector() name = %q, want %q", name, "Grupo: teste")
+	}
+}
+
+func TestSectorFScore(t *testing.T) {
+	f, err := os.CreateTemp("", "rapina-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE dfp (ID PRIMARY KEY, ID_CIA integer, CODE integer, YEAR string,
+		VERSAO integer, VL_CONTA real);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[int]map[int]map[uint32]float32{
+		1: { // improving company
+			2020: {p.AtivoTotal: 1000, p.LucLiq: 50, p.FCO: 40, p.Vendas: 500, p.CustoVendas: -300},
+			2021: {p.AtivoTotal: 1000, p.LucLiq: 100, p.FCO: 150, p.Vendas: 600, p.CustoVendas: -300},
+		},
+		2: { // worsening company
+			2020: {p.AtivoTotal: 1000, p.LucLiq: 100, p.FCO: 150, p.Vendas: 600, p.CustoVendas: -300},
+			2021: {p.AtivoTotal: 1000, p.LucLiq: -50, p.FCO: -40, p.Vendas: 500, p.CustoVendas: -300},
+		},
+		3: { // no previous year
+			2021: {p.AtivoTotal: 1000, p.LucLiq: 100, p.FCO: 150, p.Vendas: 600},
+		},
+	}
+	id := 0
+	for cid, years := range values {
+		for year, v := range years {
+			for code, value := range v {
+				id++
+				_, err := db.Exec(`INSERT INTO dfp VALUES (?, ?, ?, ?, 1, ?)`, id, cid, code, year, value)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	r := Report{db: db}
+	got, ok := r.sectorFScore([]string{"1", "2", "3", ""}, 2021)
+	want := (piotroski(values[1][2021], values[1][2020]) + piotroski(values[2][2021], values[2][2020])) / 2
+	if !ok || got != want {
+		t.Errorf("sectorFScore() = %v, %v, want %v", got, ok, want)
+	}
+
+	if _, ok := r.sectorFScore([]string{"3"}, 2021); ok {
+		t.Error("expected no score without the previous year")
+	}
+}
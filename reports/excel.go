@@ -50,7 +50,7 @@ func (e *Excel) newSheet(name string) (s *Sheet, err error) {
 	// Create a new sheet.
 	// Avoid duplicated sheet
 	if index := e.xlsx.GetSheetIndex(name); index > 0 {
-		return nil, errors.Wrapf(err, "erro ao criar planilha %s", name)
+		return nil, errors.Errorf("erro ao criar planilha %s: nome duplicado", name)
 	}
 
 	e.xlsx.NewSheet(name)
@@ -0,0 +1,242 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/progress"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Ranking order
+const (
+	OrderYield       = "dy"
+	OrderPVP         = "pvp"
+	OrderConsistency = "consistencia"
+	OrderVolume      = "liquidez"
+)
+
+// FIIRank contains the metrics used to rank a FII.
+type FIIRank struct {
+	Code      string
+	Quote     float64
+	Dividends float64 // sum of the dividends paid on the last 12 months
+	Yield     float64 // trailing 12 months dividend yield (%)
+	StdDev    float64 // standard deviation of the monthly payouts
+	CV        float64 // StdDev / average monthly payout (%)
+	PVP       float64
+	Volume    float64 // average daily volume (R$)
+}
+
+// FIIRankFilter contains the filters and the order of the ranking. Zero
+// values are ignored.
+type FIIRankFilter struct {
+	MinYield  float64
+	MaxPVP    float64
+	MaxCV     float64
+	MinVolume float64
+	OrderBy   string
+}
+
+// Ranking prints the FII 'codes' ranked by the trailing 12 months dividend
+// yield, dividend consistency, P/VP or liquidity.
+func (t FIITerminal) Ranking(codes []string, filter FIIRankFilter) error {
+	const months = 12
+	const volumeDays = 21
+
+	// Dividends and NAV are fetched concurrently
+	type fiiData struct {
+		dividends []rapina.Dividend
+		nav       float64
+	}
+	data := sync.Map{}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for _, code := range codes {
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			div, err := t.fetchFII.Dividends(code, months)
+			if err != nil {
+				progress.ErrorMsg("%s: %v", code, err)
+				return
+			}
+			d := fiiData{dividends: *div}
+			if m, err := t.fetchFII.Monthly(code, 1); err == nil && len(*m) > 0 {
+				d.nav = (*m)[0].NAV
+			}
+			data.Store(code, d)
+		}(code)
+	}
+	wg.Wait()
+
+	var ranking []FIIRank
+	for _, code := range codes {
+		v, ok := data.Load(code)
+		if !ok {
+			continue
+		}
+		d := v.(fiiData)
+
+		r := FIIRank{Code: code}
+		r.Dividends, r.StdDev, r.CV = payoutStats(d.dividends, months)
+
+		q, err := lastQuote(t.fetchStock, code)
+		if err != nil {
+			progress.ErrorMsg("%s: %v", code, err)
+		}
+		r.Quote = q
+		if q > 0 {
+			r.Yield = 100 * r.Dividends / q
+			if d.nav > 0 {
+				r.PVP = q / d.nav
+			}
+		}
+
+		if vol, err := t.fetchStock.AvgVolume(code, volumeDays); err == nil {
+			r.Volume = vol
+		}
+
+		ranking = append(ranking, r)
+	}
+
+	ranking = filterRanking(ranking, filter)
+	sortRanking(ranking, filter.OrderBy)
+
+	if t.reportFormat == Rcsv {
+		fmt.Print(csvRanking(ranking))
+	} else {
+		fmt.Print(printRanking(ranking))
+	}
+
+	return nil
+}
+
+// payoutStats returns the sum of the 'dividends', and the standard deviation
+// and coefficient of variation (%) of the monthly payouts over a window of
+// 'months' months. Months without dividends count as zero payouts.
+func payoutStats(dividends []rapina.Dividend, months int) (sum, stdDev, cv float64) {
+	monthly := make(map[string]float64)
+	for _, d := range dividends {
+		if len(d.Date) < 7 {
+			continue
+		}
+		monthly[d.Date[:7]] += d.Val
+		sum += d.Val
+	}
+	if len(monthly) == 0 {
+		return
+	}
+	if months < len(monthly) {
+		months = len(monthly)
+	}
+
+	mean := sum / float64(months)
+	sq := float64(months-len(monthly)) * mean * mean // months without dividends
+	for _, v := range monthly {
+		sq += (v - mean) * (v - mean)
+	}
+	stdDev = math.Sqrt(sq / float64(months))
+	if mean > 0 {
+		cv = 100 * stdDev / mean
+	}
+
+	return
+}
+
+// filterRanking removes the FIIs that do not match the filter.
+func filterRanking(ranking []FIIRank, f FIIRankFilter) []FIIRank {
+	filtered := ranking[:0]
+	for _, r := range ranking {
+		if f.MinYield > 0 && r.Yield < f.MinYield {
+			continue
+		}
+		if f.MaxPVP > 0 && (r.PVP <= 0 || r.PVP > f.MaxPVP) {
+			continue
+		}
+		if f.MaxCV > 0 && r.CV > f.MaxCV {
+			continue
+		}
+		if f.MinVolume > 0 && r.Volume < f.MinVolume {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// sortRanking sorts the ranking by 'orderBy' (dividend yield by default).
+// FIIs without P/VP are placed at the end when sorted by P/VP.
+func sortRanking(ranking []FIIRank, orderBy string) {
+	var less func(a, b FIIRank) bool
+	switch orderBy {
+	case OrderPVP:
+		less = func(a, b FIIRank) bool {
+			if a.PVP <= 0 || b.PVP <= 0 {
+				return a.PVP > b.PVP
+			}
+			return a.PVP < b.PVP
+		}
+	case OrderConsistency:
+		less = func(a, b FIIRank) bool { return a.CV < b.CV }
+	case OrderVolume:
+		less = func(a, b FIIRank) bool { return a.Volume > b.Volume }
+	default:
+		less = func(a, b FIIRank) bool { return a.Yield > b.Yield }
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return less(ranking[i], ranking[j])
+	})
+}
+
+func printRanking(ranking []FIIRank) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "RANKING")
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "   #  CÓDIGO     COTAÇÃO   REND. 12M   DY 12M   DESV.%   P/VP   LIQUIDEZ/DIA")
+	p.Fprintln(buf, "  --  ------  ----------  ----------  -------  -------  -----  -------------")
+
+	for i, r := range ranking {
+		p.Fprintf(buf, "  %2d  %-6s  ", i+1, r.Code)
+		if r.Quote > 0 {
+			p.Fprintf(buf, "R$%8.2f  ", r.Quote)
+		} else {
+			p.Fprintf(buf, "%10s  ", "-")
+		}
+		p.Fprintf(buf, "R$%8.2f  %6.2f%%  %6.1f%%  ", r.Dividends, r.Yield, r.CV)
+		if r.PVP > 0 {
+			p.Fprintf(buf, "%5.2f  ", r.PVP)
+		} else {
+			p.Fprintf(buf, "%5s  ", "-")
+		}
+		p.Fprintf(buf, "R$%11.0f\n", r.Volume)
+	}
+	buf.WriteByte('\n')
+
+	return buf
+}
+
+func csvRanking(ranking []FIIRank) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	buf.WriteString("Posição,Código,Cotação,Rendimentos 12M,DY 12M,Desvio Padrão,Desvio %,P/VP,Liquidez Diária\n")
+	for i, r := range ranking {
+		p.Fprintf(buf, `%d,%s,"%f","%f","%f%%","%f","%f%%","%f","%f"`,
+			i+1, r.Code, r.Quote, r.Dividends, r.Yield, r.StdDev, r.CV, r.PVP, r.Volume)
+		buf.WriteByte('\n')
+	}
+
+	return buf
+}
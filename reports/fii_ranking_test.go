@@ -0,0 +1,79 @@
+package reports
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dude333/rapina"
+)
+
+func Test_payoutStats(t *testing.T) {
+	dividends := []rapina.Dividend{
+		{Code: "ABCD11", Date: "2021-01-29", Val: 1},
+		{Code: "ABCD11", Date: "2021-02-26", Val: 0.5},
+		{Code: "ABCD11", Date: "2021-02-10", Val: 0.5},
+		{Code: "ABCD11", Date: "2021-03-31", Val: 2},
+		{Code: "ABCD11", Date: "", Val: 10},
+	}
+
+	sum, stdDev, cv := payoutStats(dividends, 3)
+
+	// monthly: 1, 1, 2 => mean 4/3
+	wantStdDev := math.Sqrt((2*math.Pow(1-4.0/3, 2) + math.Pow(2-4.0/3, 2)) / 3)
+	const e = 1e-9
+	if math.Abs(sum-4) > e || math.Abs(stdDev-wantStdDev) > e ||
+		math.Abs(cv-100*wantStdDev/(4.0/3)) > e {
+		t.Errorf("payoutStats() = %v, %v, %v", sum, stdDev, cv)
+	}
+
+	// Missing months count as zero: monthly 1, 1, 2, 0 => mean 1
+	sum, stdDev, cv = payoutStats(dividends, 4)
+	wantStdDev = math.Sqrt((0 + 0 + 1 + 1) / 4.0)
+	if math.Abs(sum-4) > e || math.Abs(stdDev-wantStdDev) > e || math.Abs(cv-100*wantStdDev) > e {
+		t.Errorf("payoutStats() with a missing month = %v, %v, %v", sum, stdDev, cv)
+	}
+
+	if sum, stdDev, cv := payoutStats(nil, 12); sum != 0 || stdDev != 0 || cv != 0 {
+		t.Errorf("payoutStats(nil) = %v, %v, %v", sum, stdDev, cv)
+	}
+}
+
+func Test_rankingOrder(t *testing.T) {
+	ranking := func() []FIIRank {
+		return []FIIRank{
+			{Code: "AAAA11", Yield: 8, CV: 10, PVP: 1.1, Volume: 1e6},
+			{Code: "BBBB11", Yield: 12, CV: 30, PVP: 0, Volume: 5e5},
+			{Code: "CCCC11", Yield: 10, CV: 5, PVP: 0.9, Volume: 2e6},
+		}
+	}
+	codes := func(rr []FIIRank) (c []string) {
+		for _, r := range rr {
+			c = append(c, r.Code)
+		}
+		return
+	}
+
+	tests := []struct {
+		name   string
+		filter FIIRankFilter
+		want   []string
+	}{
+		{"yield", FIIRankFilter{}, []string{"BBBB11", "CCCC11", "AAAA11"}},
+		{"pvp", FIIRankFilter{OrderBy: OrderPVP}, []string{"CCCC11", "AAAA11", "BBBB11"}},
+		{"consistency", FIIRankFilter{OrderBy: OrderConsistency}, []string{"CCCC11", "AAAA11", "BBBB11"}},
+		{"volume", FIIRankFilter{OrderBy: OrderVolume}, []string{"CCCC11", "AAAA11", "BBBB11"}},
+		{"min yield", FIIRankFilter{MinYield: 9}, []string{"BBBB11", "CCCC11"}},
+		{"max pvp", FIIRankFilter{MaxPVP: 1}, []string{"CCCC11"}},
+		{"max cv and min volume", FIIRankFilter{MaxCV: 20, MinVolume: 1.5e6}, []string{"CCCC11"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterRanking(ranking(), tt.filter)
+			sortRanking(got, tt.filter.OrderBy)
+			if c := codes(got); !reflect.DeepEqual(c, tt.want) {
+				t.Errorf("ranking = %v, want %v", c, tt.want)
+			}
+		})
+	}
+}
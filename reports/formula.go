@@ -0,0 +1,396 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	p "github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+)
+
+// valueNames maps the names of the values that are not on the accounts
+// mapping file (see parsers.AccountNames) to their codes.
+var valueNames = map[string]uint32{
+	"Shares":       p.Shares,
+	"FreeFloat":    p.FreeFloat,
+	"Quote":        p.Quote,
+	"EquityAvg":    p.EquityAvg,
+	"EstoqueMedio": p.EstoqueMedio,
+	"Escala":       p.Escala,
+	"FScore":       p.FScore,
+}
+
+// valueCode returns the code of an account or value by its name, ignoring
+// case.
+func valueCode(name string) (uint32, bool) {
+	if code, ok := p.AccountCode(name); ok {
+		return code, true
+	}
+	for n, code := range valueNames {
+		if strings.EqualFold(n, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// yearValues contains the account values by year.
+type yearValues map[int]map[uint32]float32
+
+// formulaEnv returns the account values of the year being evaluated
+// (offset 0) or of the previous years (offsets -1, -2...).
+type formulaEnv func(offset int) map[uint32]float32
+
+// env returns the formula environment of 'year'. Years not available return
+// an empty map.
+func (y yearValues) env(year int) formulaEnv {
+	return func(offset int) map[uint32]float32 {
+		return y[year+offset]
+	}
+}
+
+// expr is a node of a formula over the account values.
+type expr interface {
+	eval(env formulaEnv) float32
+}
+
+type number float32
+
+func (n number) eval(formulaEnv) float32 { return float32(n) }
+
+// account is the value of an account on the year, or on a previous year
+// (offset < 0), e.g., "LucLiq[-1]".
+type account struct {
+	code   uint32
+	offset int
+}
+
+// eval returns the account value. Accounts of previous years not available
+// return NaN, so the metric is left blank instead of computed with zeros.
+func (a account) eval(env formulaEnv) float32 {
+	values := env(a.offset)
+	if a.offset < 0 && len(values) == 0 {
+		return float32(math.NaN())
+	}
+	return values[a.code]
+}
+
+type negative struct{ x expr }
+
+func (n negative) eval(env formulaEnv) float32 { return -n.x.eval(env) }
+
+type binary struct {
+	op   rune
+	x, y expr
+}
+
+// eval returns the result of the operation; divisions by zero return zero,
+// as safeDiv.
+func (b binary) eval(env formulaEnv) float32 {
+	x, y := b.x.eval(env), b.y.eval(env)
+	switch b.op {
+	case '+':
+		return x + y
+	case '-':
+		return x - y
+	case '*':
+		return x * y
+	}
+	return safeDiv(x, y)
+}
+
+// parseFormula parses a formula with the four operations, parentheses,
+// numbers and account names (e.g., "(Vendas + CustoVendas) / Vendas"),
+// optionally referring to previous years (e.g., "LucLiq / LucLiq[-1] - 1").
+func parseFormula(s string) (expr, error) {
+	f := &formulaParser{src: []rune(s)}
+	e, err := f.sum()
+	if err != nil {
+		return nil, errors.Wrapf(err, "fórmula %q", s)
+	}
+	if f.skip(); f.pos < len(f.src) {
+		return nil, fmt.Errorf("fórmula %q: %q inesperado na posição %d", s, f.src[f.pos], f.pos+1)
+	}
+	return e, nil
+}
+
+// formulaParser is a recursive descent parser of formulas:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | "(" sum ")" | number | name [ "[" "-" digits "]" ]
+type formulaParser struct {
+	src []rune
+	pos int
+}
+
+// skip skips the spaces.
+func (f *formulaParser) skip() {
+	for f.pos < len(f.src) && unicode.IsSpace(f.src[f.pos]) {
+		f.pos++
+	}
+}
+
+// next returns the next non-space character, or 0 at the end.
+func (f *formulaParser) next() rune {
+	if f.skip(); f.pos < len(f.src) {
+		return f.src[f.pos]
+	}
+	return 0
+}
+
+func (f *formulaParser) sum() (expr, error) {
+	x, err := f.product()
+	for err == nil {
+		op := f.next()
+		if op != '+' && op != '-' {
+			break
+		}
+		f.pos++
+		var y expr
+		if y, err = f.product(); err == nil {
+			x = binary{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (f *formulaParser) product() (expr, error) {
+	x, err := f.unary()
+	for err == nil {
+		op := f.next()
+		if op != '*' && op != '/' {
+			break
+		}
+		f.pos++
+		var y expr
+		if y, err = f.unary(); err == nil {
+			x = binary{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (f *formulaParser) unary() (expr, error) {
+	c := f.next()
+	switch {
+	case c == 0:
+		return nil, errors.New("expressão incompleta")
+
+	case c == '-':
+		f.pos++
+		x, err := f.unary()
+		return negative{x}, err
+
+	case c == '(':
+		f.pos++
+		x, err := f.sum()
+		if err != nil {
+			return nil, err
+		}
+		if f.next() != ')' {
+			return nil, errors.New("falta fechar parênteses")
+		}
+		f.pos++
+		return x, nil
+
+	case unicode.IsDigit(c) || c == '.':
+		start := f.pos
+		for f.pos < len(f.src) && (unicode.IsDigit(f.src[f.pos]) || f.src[f.pos] == '.') {
+			f.pos++
+		}
+		n, err := strconv.ParseFloat(string(f.src[start:f.pos]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("número inválido: %s", string(f.src[start:f.pos]))
+		}
+		return number(n), nil
+
+	case unicode.IsLetter(c) || c == '_':
+		start := f.pos
+		for f.pos < len(f.src) && (unicode.IsLetter(f.src[f.pos]) || unicode.IsDigit(f.src[f.pos]) || f.src[f.pos] == '_') {
+			f.pos++
+		}
+		name := string(f.src[start:f.pos])
+		code, ok := valueCode(name)
+		if !ok {
+			return nil, fmt.Errorf("conta desconhecida: %s", name)
+		}
+		offset, err := f.offset()
+		if err != nil {
+			return nil, errors.Wrap(err, name)
+		}
+		return account{code, offset}, nil
+	}
+
+	return nil, fmt.Errorf("%q inesperado na posição %d", c, f.pos+1)
+}
+
+// offset parses the reference to a previous year, e.g., "[-1]", if any.
+func (f *formulaParser) offset() (int, error) {
+	if f.next() != '[' {
+		return 0, nil
+	}
+	f.pos++
+	start := f.pos
+	for f.pos < len(f.src) && f.src[f.pos] != ']' {
+		f.pos++
+	}
+	if f.pos == len(f.src) {
+		return 0, errors.New("falta fechar colchetes")
+	}
+	s := strings.TrimSpace(string(f.src[start:f.pos]))
+	f.pos++
+	n, err := strconv.Atoi(s)
+	if err != nil || n > 0 {
+		return 0, fmt.Errorf("ano inválido: [%s] (use [-1] para o ano anterior)", s)
+	}
+	return n, nil
+}
+
+// maxOffset returns the number of previous years used by the formula.
+func maxOffset(e expr) int {
+	switch e := e.(type) {
+	case account:
+		return -e.offset
+	case negative:
+		return maxOffset(e.x)
+	case binary:
+		x, y := maxOffset(e.x), maxOffset(e.y)
+		if x > y {
+			return x
+		}
+		return y
+	}
+	return 0
+}
+
+// formulasOffset returns the number of previous years used by the custom
+// metrics and by the formulas of the 'template' (if not nil).
+func formulasOffset(template *Template) int {
+	n := 0
+	for _, f := range _formulas {
+		if o := maxOffset(f.expr); o > n {
+			n = o
+		}
+	}
+	if template != nil {
+		for _, it := range template.Metrics {
+			if it.expr == nil {
+				continue
+			}
+			if o := maxOffset(it.expr); o > n {
+				n = o
+			}
+		}
+	}
+	return n
+}
+
+// usesAccount returns true if the formula uses the account 'code'.
+func usesAccount(e expr, code uint32) bool {
+	switch e := e.(type) {
+	case account:
+		return e.code == code
+	case negative:
+		return usesAccount(e.x, code)
+	case binary:
+		return usesAccount(e.x, code) || usesAccount(e.y, code)
+	}
+	return false
+}
+
+// Formula is a custom metric defined by the user, e.g., on the config file:
+//
+//	indicadores:
+//	  - nome: Crescimento do Lucro
+//	    formula: LucLiq / LucLiq[-1] - 1
+//	    formato: PERCENT
+type Formula struct {
+	Name    string `yaml:"Nome" mapstructure:"nome"`
+	Formula string `yaml:"Formula" mapstructure:"formula"`
+	Format  string `yaml:"Formato" mapstructure:"formato"` // NUMBER (default), INDEX or PERCENT
+
+	format int
+	expr   expr
+}
+
+// _formulas contains the custom metrics, added to the reports after the
+// built-in metrics.
+var _formulas []Formula
+
+// SetFormulas validates the custom metrics and sets them to be used by the
+// reports, the screener and the API.
+func SetFormulas(list []Formula) error {
+	builtin := builtinMetrics()
+	seen := make(map[string]bool)
+	for i := range list {
+		f := &list[i]
+		if err := f.compile(); err != nil {
+			return err
+		}
+		if _, ok := builtin[f.Name]; ok {
+			return fmt.Errorf("indicador %s: nome já usado por um indicador do relatório", f.Name)
+		}
+		if seen[strings.ToLower(f.Name)] {
+			return fmt.Errorf("indicador %s: nome repetido", f.Name)
+		}
+		seen[strings.ToLower(f.Name)] = true
+	}
+	_formulas = list
+	return nil
+}
+
+// compile validates the formula and its format.
+func (f *Formula) compile() error {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		return fmt.Errorf("indicador sem nome: %s", f.Formula)
+	}
+	e, err := parseFormula(f.Formula)
+	if err != nil {
+		return errors.Wrapf(err, "indicador %s", f.Name)
+	}
+	f.expr = e
+
+	f.format = NUMBER
+	if f.Format != "" {
+		format, ok := templateFormats[strings.ToUpper(f.Format)]
+		if !ok {
+			return fmt.Errorf("indicador %s: formato inválido: %s (opções: NUMBER|INDEX|PERCENT)", f.Name, f.Format)
+		}
+		f.format = format
+	}
+	return nil
+}
+
+// findFormula returns the custom metric 'name' (ignoring case) or, if not
+// found, a formula parsed from 'name' (e.g., "EBIT / Vendas"), formatted as
+// INDEX.
+func findFormula(name string) (*Formula, error) {
+	for i := range _formulas {
+		if strings.EqualFold(_formulas[i].Name, name) {
+			return &_formulas[i], nil
+		}
+	}
+	f := &Formula{Name: name, Formula: name, Format: "INDEX"}
+	if err := f.compile(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// customMetrics returns the custom metrics evaluated on 'env', preceded by
+// an empty line.
+func customMetrics(env formulaEnv) []metric {
+	if len(_formulas) == 0 {
+		return nil
+	}
+	list := []metric{{"", 0, EMPTY, grpCustom}}
+	for _, f := range _formulas {
+		list = append(list, metric{f.Name, f.expr.eval(env), f.format, grpCustom})
+	}
+	return list
+}
@@ -0,0 +1,173 @@
+package reports
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func Test_parseFormula(t *testing.T) {
+	v := map[uint32]float32{
+		p.Vendas:      1000,
+		p.CustoVendas: -600,
+		p.EBIT:        250,
+		p.Deprec:      -50,
+		p.Shares:      100,
+		p.LucLiq:      150,
+	}
+	values := yearValues{2021: v, 2020: {p.LucLiq: 100}}
+
+	tests := []struct {
+		formula string
+		want    float32
+	}{
+		{"Vendas", 1000},
+		{"(Vendas + CustoVendas) / Vendas", 0.4},
+		{"EBIT - Deprec", 300},
+		{"-Deprec * 2", 100},
+		{"vendas / shares", 10},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 2 - 3", 5},
+		{"Vendas / FCO", 0}, // safeDiv
+		{"0.5 * EBIT", 125},
+		{"LucLiq / LucLiq[-1] - 1", 0.5},
+		{"LucLiq[ -1 ] + LucLiq[0]", 250},
+		{"LucLiq / LucLiq[-2]", float32(math.NaN())}, // year not available
+	}
+	for _, tt := range tests {
+		e, err := parseFormula(tt.formula)
+		if err != nil {
+			t.Errorf("parseFormula(%q): %v", tt.formula, err)
+			continue
+		}
+		got := e.eval(values.env(2021))
+		if math.IsNaN(float64(tt.want)) {
+			if !math.IsNaN(float64(got)) {
+				t.Errorf("%q = %v, want NaN", tt.formula, got)
+			}
+			continue
+		}
+		if math.Abs(float64(got-tt.want)) > 1e-5 {
+			t.Errorf("%q = %v, want %v", tt.formula, got, tt.want)
+		}
+	}
+}
+
+func Test_parseFormulaErrors(t *testing.T) {
+	tests := []struct {
+		formula, err string
+	}{
+		{"Vendas / Xpto", "conta desconhecida: Xpto"},
+		{"(Vendas + EBIT", "falta fechar parênteses"},
+		{"Vendas +", "expressão incompleta"},
+		{"Vendas EBIT", "inesperado na posição 8"},
+		{"Vendas % 2", "inesperado na posição 8"},
+		{"1.2.3", "número inválido"},
+		{"LucLiq[1]", "ano inválido: [1]"},
+		{"LucLiq[-1", "falta fechar colchetes"},
+	}
+	for _, tt := range tests {
+		_, err := parseFormula(tt.formula)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("parseFormula(%q) error = %v, want %q", tt.formula, err, tt.err)
+		}
+	}
+}
+
+func Test_maxOffset(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    int
+	}{
+		{"Vendas", 0},
+		{"LucLiq / LucLiq[-1]", 1},
+		{"-(EBIT[-3] + 1) * Vendas[-2]", 3},
+	}
+	for _, tt := range tests {
+		e, err := parseFormula(tt.formula)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := maxOffset(e); got != tt.want {
+			t.Errorf("maxOffset(%q) = %d, want %d", tt.formula, got, tt.want)
+		}
+	}
+}
+
+func Test_usesAccount(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    bool
+	}{
+		{"LucLiq / Vendas", false},
+		{"Quote * Shares / (LucLiq * Escala)", true},
+		{"-(Quote[-1] + 1)", true},
+	}
+	for _, tt := range tests {
+		e, err := parseFormula(tt.formula)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := usesAccount(e, p.Quote); got != tt.want {
+			t.Errorf("usesAccount(%q) = %v, want %v", tt.formula, got, tt.want)
+		}
+	}
+}
+
+func TestSetFormulas(t *testing.T) {
+	t.Cleanup(func() { _formulas = nil })
+
+	err := SetFormulas([]Formula{
+		{Name: "Margem Bruta", Formula: "(Vendas + CustoVendas) / Vendas", Format: "percent"},
+		{Name: "Lucro por Ação", Formula: "LucLiq * Escala / Shares", Format: "INDEX"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := map[uint32]float32{p.Vendas: 1000, p.CustoVendas: -600, p.LucLiq: 100, p.Escala: 1000, p.Shares: 50000}
+	got := customMetrics(yearValues{2021: v}.env(2021))
+	want := []metric{
+		{"", 0, EMPTY, grpCustom},
+		{"Margem Bruta", 0.4, PERCENT, grpCustom},
+		{"Lucro por Ação", 2, INDEX, grpCustom},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].descr != want[i].descr || got[i].format != want[i].format ||
+			math.Abs(float64(got[i].val-want[i].val)) > 1e-5 {
+			t.Errorf("metric %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	r := Report{layout: layoutStd}
+	if m := r.metrics(2021, yearValues{2021: v}); m[len(m)-1].descr != "Lucro por Ação" {
+		t.Errorf("custom metrics not added to the report: %v", m[len(m)-1])
+	}
+
+	f, err := findFormula("margem bruta")
+	if err != nil || f.Name != "Margem Bruta" {
+		t.Errorf("findFormula(name) = %v, %v", f, err)
+	}
+	f, err = findFormula("EBIT / Vendas")
+	if err != nil || f.format != INDEX {
+		t.Errorf("findFormula(formula) = %v, %v", f, err)
+	}
+
+	for _, list := range [][]Formula{
+		{{Name: "ROE", Formula: "LucLiq / Equity"}},
+		{{Name: "X", Formula: "Vendas"}, {Name: "x", Formula: "EBIT"}},
+		{{Name: "X", Formula: "Vendas / Xpto"}},
+		{{Formula: "Vendas"}},
+		{{Name: "X", Formula: "Vendas", Format: "MOEDA"}},
+	} {
+		if err := SetFormulas(list); err == nil {
+			t.Errorf("SetFormulas(%v): expected error", list)
+		}
+	}
+}
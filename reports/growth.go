@@ -0,0 +1,148 @@
+package reports
+
+import (
+	"strconv"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+// CAGR periods (in years) shown on the growth section of the report
+var growthPeriods = []int{1, 3, 5, 10}
+
+// growthMetrics returns the metrics shown on the growth section of the
+// report, according to the statements layout.
+func growthMetrics(layout int, v map[uint32]float32) []metric {
+	proventos := v[p.Dividendos] + v[p.JurosCapProp]
+	lpa := safeDiv(v[p.LucLiq]*v[p.Escala], v[p.Shares])
+
+	var list []metric
+	switch layout {
+	case layoutBank:
+		list = []metric{{"Receitas da Interm. Financeira", v[p.Vendas], NUMBER, grpAccts}}
+	case layoutInsurer:
+		list = []metric{{"Prêmios Ganhos", v[p.PremiosGanhos], NUMBER, grpAccts}}
+	default:
+		list = []metric{
+			{"Receita Líquida", v[p.Vendas], NUMBER, grpAccts},
+			{"EBITDA", v[p.EBIT] - v[p.Deprec], NUMBER, grpAccts},
+		}
+	}
+
+	return append(list,
+		metric{"Lucro Líquido", v[p.LucLiq], NUMBER, grpAccts},
+		metric{"Patrimônio Líquido", v[p.Equity], NUMBER, grpAccts},
+		metric{"Proventos", proventos, NUMBER, grpAccts},
+		metric{"LPA", lpa, INDEX, grpAccts},
+	)
+}
+
+// addGrowthValues appends the growth metrics of 'year' to the 'series',
+// converted to the display unit.
+func (r Report) addGrowthValues(series []AccountSeries, year int, v map[uint32]float32) []AccountSeries {
+	for i, m := range growthMetrics(r.layout, v) {
+		if i >= len(series) {
+			series = append(series, AccountSeries{Descr: m.descr})
+		}
+		series[i].Values = append(series[i].Values, AccountYear{Year: year, Value: float64(r.display(m, year))})
+	}
+	return series
+}
+
+// signChange returns "⇧" if the value changed from negative to positive,
+// "⇩" if it changed from positive to negative, or "" otherwise.
+func signChange(v0, vn float64) string {
+	switch {
+	case v0 < 0 && vn > 0:
+		return "⇧"
+	case v0 > 0 && vn < 0:
+		return "⇩"
+	}
+	return ""
+}
+
+// cagr returns the compound annual growth rate of the last 'years' of the
+// series, or nil if not available. If the rate is undefined because the
+// value changed its sign, returns the sign change flag.
+func (s AccountSeries) cagr(years int) (*float64, string) {
+	n := len(s.Values)
+	if n == 0 {
+		return nil, ""
+	}
+	last := s.Values[n-1]
+	for _, v := range s.Values {
+		if v.Year == last.Year-years {
+			return growthRate(v.Value, last.Value, years), signChange(v.Value, last.Value)
+		}
+	}
+	return nil, ""
+}
+
+// growthDescrs returns the descriptions of the growth metrics of the layout.
+// Their year-over-year change is printed on the row below the metric, on
+// the metrics section of the report.
+func growthDescrs(layout int) map[string]bool {
+	descrs := make(map[string]bool)
+	for _, m := range growthMetrics(layout, map[uint32]float32{}) {
+		descrs[m.descr] = true
+	}
+	return descrs
+}
+
+// printGrowth prints the year-over-year change of the growth metrics on the
+// row below each metric ('rows' maps the metric description to its row),
+// one year per column starting on col C (year 'begin'). Then prints the
+// CAGR section, starting on 'row', with the CAGR of the metrics for the
+// periods in growthPeriods. Sign changes, where the growth is undefined,
+// are flagged with arrows.
+func (r Report) printGrowth(sheet *Sheet, series []AccountSeries, rows map[string]int, row, begin int) {
+	// Year-over-year change
+	for _, s := range series {
+		metricRow, ok := rows[s.Descr]
+		if !ok {
+			continue
+		}
+		s.growth()
+		for i, v := range s.Values {
+			cell := axis(2+v.Year-begin, metricRow+1)
+			switch {
+			case v.YoY != nil:
+				_ = sheet.printValue(cell, float32(*v.YoY), PERCENT, false)
+			case i > 0 && s.Values[i-1].Year == v.Year-1:
+				if flag := signChange(s.Values[i-1].Value, v.Value); flag != "" {
+					sheet.print(cell, &[]string{flag}, RIGHT, false)
+				}
+			}
+		}
+	}
+
+	// CAGR
+	sheet.print(axis(1, row), &[]string{"CRESCIMENTO"}, LEFT, true)
+	row++
+	header := []string{"CAGR"}
+	for _, n := range growthPeriods {
+		if n == 1 {
+			header = append(header, "1 ano")
+		} else {
+			header = append(header, strconv.Itoa(n)+" anos")
+		}
+	}
+	for i, h := range header {
+		_ = sheet.printTitle(axis(1+i, row), h)
+	}
+	row++
+	for _, s := range series {
+		sheet.print(axis(1, row), &[]string{s.Descr}, RIGHT, false)
+		for i, n := range growthPeriods {
+			rate, flag := s.cagr(n)
+			switch {
+			case rate != nil:
+				_ = sheet.printValue(axis(2+i, row), float32(*rate), PERCENT, false)
+			case flag != "":
+				sheet.print(axis(2+i, row), &[]string{flag}, RIGHT, false)
+			}
+		}
+		row++
+	}
+
+	sheet.print(axis(1, row+1), &[]string{"⇧ ⇩ mudança de sinal (crescimento indefinido)"}, LEFT, false)
+}
@@ -0,0 +1,132 @@
+package reports
+
+import (
+	"math"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestGrowthRateNegative(t *testing.T) {
+	for _, v := range [][2]float64{{-10, 20}, {10, -20}, {0, 10}, {10, 0}} {
+		if r := growthRate(v[0], v[1], 1); r != nil {
+			t.Errorf("growthRate(%v, %v) = %v, want nil", v[0], v[1], *r)
+		}
+	}
+
+	// Always negative accounts (e.g., costs, dividends paid) grow in magnitude
+	for _, tt := range []struct{ v0, vn, want float64 }{{-10, -50, 4}, {-50, -10, -0.8}} {
+		if r := growthRate(tt.v0, tt.vn, 1); r == nil || math.Abs(*r-tt.want) > 1e-9 {
+			t.Errorf("growthRate(%v, %v) = %v, want %v", tt.v0, tt.vn, r, tt.want)
+		}
+	}
+}
+
+func TestAccountSeries_cagr(t *testing.T) {
+	s := AccountSeries{Values: []AccountYear{
+		{Year: 2013, Value: 100},
+		{Year: 2015, Value: -50},
+		{Year: 2017, Value: 80},
+		{Year: 2018, Value: 120},
+		{Year: 2019, Value: 144},
+		{Year: 2020, Value: 160},
+	}}
+
+	tests := []struct {
+		years int
+		want  float64
+		flag  string
+		isNil bool
+	}{
+		{1, 160.0/144 - 1, "", false},
+		{3, math.Pow(160.0/80, 1.0/3) - 1, "", false},
+		{5, 0, "⇧", true},
+		{7, math.Pow(160.0/100, 1.0/7) - 1, "", false},
+		{10, 0, "", true},
+	}
+	for _, tt := range tests {
+		got, flag := s.cagr(tt.years)
+		if flag != tt.flag {
+			t.Errorf("cagr(%d) flag = %q, want %q", tt.years, flag, tt.flag)
+		}
+		if (got == nil) != tt.isNil {
+			t.Errorf("cagr(%d) = %v, want nil: %v", tt.years, got, tt.isNil)
+			continue
+		}
+		if got != nil && math.Abs(*got-tt.want) > 1e-9 {
+			t.Errorf("cagr(%d) = %v, want %v", tt.years, *got, tt.want)
+		}
+	}
+}
+
+func TestAddGrowthValues(t *testing.T) {
+	r := Report{layout: layoutStd}
+	v := map[uint32]float32{
+		p.Vendas: 1000, p.EBIT: 200, p.Deprec: -50, p.LucLiq: 100,
+		p.Equity: 800, p.Dividendos: 30, p.JurosCapProp: 10,
+		p.Escala: 1000, p.Shares: 50000,
+	}
+
+	var series []AccountSeries
+	series = r.addGrowthValues(series, 2019, v)
+	v[p.LucLiq] = -20
+	series = r.addGrowthValues(series, 2020, v)
+
+	want := map[string][]float64{
+		"Receita Líquida":    {1000, 1000},
+		"EBITDA":             {250, 250},
+		"Lucro Líquido":      {100, -20},
+		"Patrimônio Líquido": {800, 800},
+		"Proventos":          {40, 40},
+		"LPA":                {2, -0.4},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("got %d series, want %d", len(series), len(want))
+	}
+	for _, s := range series {
+		w, ok := want[s.Descr]
+		if !ok || len(s.Values) != len(w) {
+			t.Errorf("unexpected series %s: %+v", s.Descr, s.Values)
+			continue
+		}
+		for i := range w {
+			if math.Abs(s.Values[i].Value-w[i]) > 1e-4 {
+				t.Errorf("%s[%d] = %v, want %v", s.Descr, i, s.Values[i].Value, w[i])
+			}
+		}
+	}
+
+	if n := len(growthMetrics(layoutBank, v)); n != 5 {
+		t.Errorf("bank growth metrics = %d, want 5", n)
+	}
+}
+
+func TestPrintGrowth(t *testing.T) {
+	e := newExcel()
+	sheet, err := e.newSheet("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := []AccountSeries{{Descr: "Lucro Líquido", Values: []AccountYear{
+		{Year: 2018, Value: -10},
+		{Year: 2019, Value: 20},
+		{Year: 2020, Value: 30},
+	}}}
+	Report{}.printGrowth(sheet, series, map[string]int{"Lucro Líquido": 5}, 10, 2018)
+
+	cells := map[string]string{
+		"D6":  "⇧",
+		"E6":  "0.5",
+		"B10": "CRESCIMENTO",
+		"B11": "CAGR",
+		"C11": "1 ano",
+		"B12": "Lucro Líquido",
+		"C12": "0.5",
+	}
+	for cell, want := range cells {
+		if got := e.xlsx.GetCellValue("test", cell); got != want {
+			t.Errorf("%s = %q, want %q", cell, got, want)
+		}
+	}
+}
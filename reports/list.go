@@ -101,42 +101,30 @@ func GetSpcfctnCd(db *sql.DB, companyName string, ticker string) string {
 //
 // ListSector shows all companies from the same sector as 'company'
 //
-func ListSector(db *sql.DB, company, yamlFile string) (err error) {
-	// Companies from the same sector
-	secCo, secName, err := parsers.FromSector(company, yamlFile)
-	if len(secCo) <= 1 || err != nil {
-		err = errors.Wrap(err, "erro ao ler arquivo dos setores "+yamlFile)
-		return
-	}
-
-	// All companies stored on db
-	list, err := ListCompanies(db)
+func ListSector(db *sql.DB, company string) (err error) {
+	// Companies from the same segment, linked by CNPJ
+	secCo, secName, err := parsers.FromSector(db, company)
 	if err != nil {
-		err = errors.Wrap(err, "erro ao listar empresas")
-		return
+		return errors.Wrap(err, "erro ao ler setores")
+	}
+	if len(secCo) == 0 {
+		return fmt.Errorf("empresa '%s' não classificada em nenhum segmento", company)
 	}
 
-	// Translate company names to match the name stored on db
-	fmt.Printf("%-40s %s\n", "ARQUIVO YAML", "BANCO DE DADOS")
-	fmt.Printf("%-40s %s\n", strings.Repeat("-", 40), strings.Repeat("-", 40))
+	fmt.Printf("SETOR: %s\n\n", secName)
 	for _, s := range secCo {
-		z := parsers.FuzzyFind(s, list, 3)
-		if len(z) > 0 {
-			fmt.Printf("%-40s %s\n", s, z)
-		} else {
-			fmt.Printf("%-40s %s\n", s, "Nao encontrado")
-		}
+		fmt.Println(s)
 	}
-	fmt.Printf("\nSETOR: %s\n", secName)
 
 	return
 }
 
 //
 // ListCompaniesProfits lists companies by net profit: more sustainable growth
-// listed first
+// listed first. Profits are shown in 'unit' and, if 'infl' is not nil, in
+// constant currency of 'baseYear' (including the growth filter and CAGR).
 //
-func ListCompaniesProfits(db *sql.DB, rate float32) error {
+func ListCompaniesProfits(db *sql.DB, rate float32, unit Unit, infl parsers.Inflation, baseYear int) error {
 
 	info, err := companies(db)
 	if err != nil {
@@ -148,6 +136,11 @@ func ListCompaniesProfits(db *sql.DB, rate float32) error {
 		return fmt.Errorf("falha ao obter a faixa de datas (%v)", err)
 	}
 
+	deflator, err := deflators(infl, baseYear, yi, yf)
+	if err != nil {
+		return fmt.Errorf("falha ao obter o IPCA (%v)", err)
+	}
+
 	// Header
 	var sep string
 	fmt.Printf("%20s ", " ") // Space to match company name
@@ -155,7 +148,7 @@ func ListCompaniesProfits(db *sql.DB, rate float32) error {
 		fmt.Printf("%10d ", y)
 		sep += fmt.Sprintf("%s ", strings.Repeat("-", 10))
 	}
-	fmt.Printf("%10s\n", "CAGR")
+	fmt.Printf("%10s   (%s)\n", "CAGR", unitLabel(unit, deflator, baseYear))
 	sep += fmt.Sprintf("%s ", strings.Repeat("-", 10))
 	fmt.Printf("%20s %s\n", " ", sep)
 
@@ -166,6 +159,11 @@ func ListCompaniesProfits(db *sql.DB, rate float32) error {
 		if err != nil {
 			return fmt.Errorf("falha ao obter lucros de %s (%v)", co.name, err)
 		}
+		if deflator != nil {
+			for i := range profits {
+				profits[i].profit *= deflator[profits[i].year]
+			}
+		}
 
 		// FILTERS ----------------------------
 		// At least 4 years
@@ -203,7 +201,7 @@ func ListCompaniesProfits(db *sql.DB, rate float32) error {
 		i := 0
 		for y := yi; y <= yf; y++ {
 			if i < len(profits) && profits[i].year == y {
-				pt.Printf("%10.0f ", profits[i].profit)
+				pt.Printf("%10.0f ", profits[i].profit*unit.factor())
 				i++
 			} else {
 				fmt.Printf("%10s ", " ")
@@ -0,0 +1,118 @@
+package reports
+
+import (
+	p "github.com/dude333/rapina/parsers"
+)
+
+//
+// bankMetrics returns the metrics for banks, whose income statement starts
+// with the financial intermediation revenues (3.01) and expenses (3.02),
+// mapped as Vendas and CustoVendas. EBITDA, margins and debt ratios are
+// replaced by the net interest margin, efficiency and leverage ratios.
+//
+func bankMetrics(v map[uint32]float32) []metric {
+	rbif := v[p.Vendas] + v[p.CustoVendas] // net interest income
+	despAdm := v[p.DespesasPessoal] + v[p.DespesasAdm]
+	proventos := v[p.Dividendos] + v[p.JurosCapProp]
+
+	lpa := safeDiv(v[p.LucLiq]*v[p.Escala], v[p.Shares])
+	vpa := safeDiv(v[p.Equity]*v[p.Escala], v[p.Shares])
+
+	return []metric{
+		{"Patrimônio Líquido", v[p.Equity], NUMBER, grpAccts},
+		{"Ativo Total", v[p.AtivoTotal], NUMBER, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Receitas da Interm. Financeira", v[p.Vendas], NUMBER, grpAccts},
+		{"Despesas da Interm. Financeira", v[p.CustoVendas], NUMBER, grpAccts},
+		{"Resultado Bruto da Interm. Financeira", rbif, NUMBER, grpAccts},
+		{"Receitas de Serviços", v[p.ReceitasServicos], NUMBER, grpAccts},
+		{"Despesas Administrativas", despAdm, NUMBER, grpAccts},
+		{"Lucro Líquido", v[p.LucLiq], NUMBER, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"LPA", available(lpa, v[p.Shares]), INDEX, grpAccts},
+		{"VPA", available(vpa, v[p.Shares]), INDEX, grpAccts},
+		{"P/L", available(safeDiv(v[p.Quote], lpa), v[p.Quote], v[p.Shares]), INDEX, grpAccts},
+		{"P/VP", available(safeDiv(v[p.Quote], vpa), v[p.Quote], v[p.Shares]), INDEX, grpAccts},
+		{"Cotação", available(v[p.Quote], v[p.Quote]), INDEX, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Margem Financeira (RBIF/Ativo)", safeDiv(rbif, v[p.AtivoTotal]), PERCENT, grpAccts},
+		{"Índice de Eficiência", zeroIfNeg(safeDiv(-despAdm, rbif+v[p.ReceitasServicos])), PERCENT, grpAccts},
+		{"ROE", avgROE(v), PERCENT, grpAccts},
+		{"ROA", safeDiv(v[p.LucLiq], v[p.AtivoTotal]), PERCENT, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"PL/Ativo", zeroIfNeg(safeDiv(v[p.Equity], v[p.AtivoTotal])), PERCENT, grpAccts},
+		{"Alavancagem (Ativo/PL)", zeroIfNeg(safeDiv(v[p.AtivoTotal], v[p.Equity])), INDEX, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Proventos", proventos, NUMBER, grpAccts},
+		{"Payout", zeroIfNeg(safeDiv(proventos, v[p.LucLiq])), PERCENT, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Total de Ações", available(v[p.Shares], v[p.Shares]), GENERAL, grpShares},
+		{"Free Float", available(v[p.FreeFloat], v[p.FreeFloat]), PERCENT, grpShares},
+		{"", 0, EMPTY, grpShares},
+	}
+}
+
+//
+// insurerMetrics returns the metrics for insurance companies, based on the
+// earned premiums, claims and expenses of the income statement.
+//
+func insurerMetrics(v map[uint32]float32) []metric {
+	despesas := v[p.Sinistros] + v[p.CustosAquisicao] + v[p.DespesasAdm] + v[p.DespesasTributos]
+	proventos := v[p.Dividendos] + v[p.JurosCapProp]
+
+	lpa := safeDiv(v[p.LucLiq]*v[p.Escala], v[p.Shares])
+	vpa := safeDiv(v[p.Equity]*v[p.Escala], v[p.Shares])
+
+	return []metric{
+		{"Patrimônio Líquido", v[p.Equity], NUMBER, grpAccts},
+		{"Ativo Total", v[p.AtivoTotal], NUMBER, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Prêmios Ganhos", v[p.PremiosGanhos], NUMBER, grpAccts},
+		{"Sinistros Ocorridos", v[p.Sinistros], NUMBER, grpAccts},
+		{"Custos de Aquisição", v[p.CustosAquisicao], NUMBER, grpAccts},
+		{"Despesas Administrativas", v[p.DespesasAdm], NUMBER, grpAccts},
+		{"Resultado Financeiro", v[p.ResulFinanc], NUMBER, grpAccts},
+		{"Lucro Líquido", v[p.LucLiq], NUMBER, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"LPA", available(lpa, v[p.Shares]), INDEX, grpAccts},
+		{"VPA", available(vpa, v[p.Shares]), INDEX, grpAccts},
+		{"P/L", available(safeDiv(v[p.Quote], lpa), v[p.Quote], v[p.Shares]), INDEX, grpAccts},
+		{"P/VP", available(safeDiv(v[p.Quote], vpa), v[p.Quote], v[p.Shares]), INDEX, grpAccts},
+		{"Cotação", available(v[p.Quote], v[p.Quote]), INDEX, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Sinistralidade", zeroIfNeg(safeDiv(-v[p.Sinistros], v[p.PremiosGanhos])), PERCENT, grpAccts},
+		{"Índice Combinado", zeroIfNeg(safeDiv(-despesas, v[p.PremiosGanhos])), PERCENT, grpAccts},
+		{"ROE", avgROE(v), PERCENT, grpAccts},
+		{"ROA", safeDiv(v[p.LucLiq], v[p.AtivoTotal]), PERCENT, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Prêmios Ganhos/PL", zeroIfNeg(safeDiv(v[p.PremiosGanhos], v[p.Equity])), INDEX, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Proventos", proventos, NUMBER, grpAccts},
+		{"Payout", zeroIfNeg(safeDiv(proventos, v[p.LucLiq])), PERCENT, grpAccts},
+		{"", 0, EMPTY, grpAccts},
+
+		{"Total de Ações", available(v[p.Shares], v[p.Shares]), GENERAL, grpShares},
+		{"Free Float", available(v[p.FreeFloat], v[p.FreeFloat]), PERCENT, grpShares},
+		{"", 0, EMPTY, grpShares},
+	}
+}
+
+// avgROE returns the return on the average equity, or zero if the net income
+// or the equity are negative.
+func avgROE(v map[uint32]float32) float32 {
+	if v[p.LucLiq] > 0 && v[p.EquityAvg] > 0 {
+		return zeroIfNeg(safeDiv(v[p.LucLiq], v[p.EquityAvg]))
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package reports
+
+import (
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestSegmentLayout(t *testing.T) {
+	tests := []struct {
+		sectorName string
+		want       int
+	}{
+		{"Financeiro > Intermediários Financeiros > Bancos", layoutBank},
+		{"Financeiro > Previdência e Seguros > Seguradoras", layoutInsurer},
+		{"Financeiro > Previdência e Seguros > Corretoras de Seguros", layoutStd},
+		{"Consumo Cíclico > Tecidos. Vestuário e Calçados > Calçados", layoutStd},
+		{"", layoutStd},
+	}
+	for _, tt := range tests {
+		if got := segmentLayout(tt.sectorName); got != tt.want {
+			t.Errorf("segmentLayout(%q) = %d, want %d", tt.sectorName, got, tt.want)
+		}
+	}
+}
+
+func metricValue(t *testing.T, metrics []metric, descr string) float32 {
+	for _, m := range metrics {
+		if m.descr == descr {
+			return m.val
+		}
+	}
+	t.Fatalf("metric %q not found", descr)
+	return 0
+}
+
+func TestBankMetrics(t *testing.T) {
+	v := map[uint32]float32{
+		p.AtivoTotal:       1000,
+		p.Equity:           100,
+		p.EquityAvg:        100,
+		p.Vendas:           120,
+		p.CustoVendas:      -70,
+		p.ReceitasServicos: 30,
+		p.DespesasPessoal:  -20,
+		p.DespesasAdm:      -20,
+		p.LucLiq:           15,
+	}
+	l := metricsList(layoutBank, v)
+
+	tests := []struct {
+		descr string
+		want  float32
+	}{
+		{"Resultado Bruto da Interm. Financeira", 50},
+		{"Despesas Administrativas", -40},
+		{"Margem Financeira (RBIF/Ativo)", 0.05},
+		{"Índice de Eficiência", 0.5},
+		{"ROE", 0.15},
+		{"ROA", 0.015},
+		{"PL/Ativo", 0.1},
+		{"Alavancagem (Ativo/PL)", 10},
+	}
+	for _, tt := range tests {
+		AssertEqual(t, "bankMetrics ["+tt.descr+"]", metricValue(t, l, tt.descr), tt.want)
+	}
+
+	if len(metricsList(layoutBank, nil)) != len(l) {
+		t.Error("bankMetrics: descriptions and values must have the same length")
+	}
+}
+
+func TestInsurerMetrics(t *testing.T) {
+	v := map[uint32]float32{
+		p.PremiosGanhos:    200,
+		p.Sinistros:        -100,
+		p.CustosAquisicao:  -40,
+		p.DespesasAdm:      -30,
+		p.DespesasTributos: -10,
+		p.Equity:           400,
+	}
+	l := metricsList(layoutInsurer, v)
+
+	AssertEqual(t, "insurerMetrics [Sinistralidade]", metricValue(t, l, "Sinistralidade"), float32(0.5))
+	AssertEqual(t, "insurerMetrics [Índice Combinado]", metricValue(t, l, "Índice Combinado"), float32(0.9))
+	AssertEqual(t, "insurerMetrics [Prêmios Ganhos/PL]", metricValue(t, l, "Prêmios Ganhos/PL"), float32(0.5))
+}
@@ -0,0 +1,84 @@
+package reports
+
+import (
+	p "github.com/dude333/rapina/parsers"
+)
+
+// qualityMetrics returns the DuPont decomposition of the ROE, the Piotroski
+// F-Score and the Altman Z-Score for emerging markets.
+func qualityMetrics(v map[uint32]float32) []metric {
+	margin := safeDiv(v[p.LucLiq], v[p.Vendas])
+	turnover := safeDiv(v[p.Vendas], v[p.AtivoTotal])
+	multiplier := safeDiv(v[p.AtivoTotal], v[p.Equity])
+
+	return []metric{
+		{"", 0, EMPTY, grpQuality},
+		{"DuPont: Margem Líquida", margin, PERCENT, grpQuality},
+		{"DuPont: Giro do Ativo", turnover, INDEX, grpQuality},
+		{"DuPont: Multiplicador do PL (Ativo/PL)", multiplier, INDEX, grpQuality},
+		{"DuPont: ROE (Margem x Giro x Multiplicador)", margin * turnover * multiplier, PERCENT, grpQuality},
+		{"", 0, EMPTY, grpQuality},
+		{"Piotroski F-Score (0 a 9)", v[p.FScore], INDEX, grpQuality},
+		{"Altman Z''-Score (< 4,15: risco; > 5,85: seguro)", altmanZ(v), INDEX, grpQuality},
+	}
+}
+
+// piotroski returns the Piotroski F-Score, the number of tests passed (0 to
+// 9) comparing the current ('v') and the previous year ('prev') values:
+//
+//	Profitability: ROA > 0, FCO > 0, ROA increased, FCO > net income
+//	Leverage/liquidity: long term debt/assets decreased, current ratio
+//	increased, no new shares issued
+//	Operating efficiency: gross margin increased, asset turnover increased
+func piotroski(v, prev map[uint32]float32) float32 {
+	roa := func(v map[uint32]float32) float32 { return safeDiv(v[p.LucLiq], v[p.AtivoTotal]) }
+	leverage := func(v map[uint32]float32) float32 { return safeDiv(v[p.DividaNCirc], v[p.AtivoTotal]) }
+	liquidity := func(v map[uint32]float32) float32 { return safeDiv(v[p.AtivoCirc], v[p.PassivoCirc]) }
+	grossMargin := func(v map[uint32]float32) float32 { return safeDiv(v[p.Vendas]+v[p.CustoVendas], v[p.Vendas]) }
+	turnover := func(v map[uint32]float32) float32 { return safeDiv(v[p.Vendas], v[p.AtivoTotal]) }
+
+	if v[p.AtivoTotal] == 0 || prev[p.AtivoTotal] == 0 {
+		return 0
+	}
+
+	tests := []bool{
+		roa(v) > 0,
+		v[p.FCO] > 0,
+		roa(v) > roa(prev),
+		v[p.FCO] > v[p.LucLiq],
+		leverage(v) < leverage(prev) || (v[p.DividaNCirc] == 0 && prev[p.DividaNCirc] == 0),
+		liquidity(v) > liquidity(prev),
+		v[p.Shares] > 0 && prev[p.Shares] > 0 && v[p.Shares] <= prev[p.Shares],
+		grossMargin(v) > grossMargin(prev),
+		turnover(v) > turnover(prev),
+	}
+
+	var score float32
+	for _, ok := range tests {
+		if ok {
+			score++
+		}
+	}
+	return score
+}
+
+// altmanZ returns the Altman Z-Score for emerging markets:
+//
+//	Z'' = 3,25 + 6,56 X1 + 3,26 X2 + 6,72 X3 + 1,05 X4
+//
+// where X1 = working capital/assets, X2 = retained earnings/assets,
+// X3 = EBIT/assets and X4 = equity/liabilities.
+func altmanZ(v map[uint32]float32) float32 {
+	assets := v[p.AtivoTotal]
+	liabilities := v[p.PassivoCirc] + v[p.PassivoNCirc]
+	if assets == 0 || liabilities == 0 {
+		return 0
+	}
+
+	x1 := (v[p.AtivoCirc] - v[p.PassivoCirc]) / assets
+	x2 := (v[p.ReservasLucros] + v[p.LucrosAcumulados]) / assets
+	x3 := v[p.EBIT] / assets
+	x4 := v[p.Equity] / liabilities
+
+	return 3.25 + 6.56*x1 + 3.26*x2 + 6.72*x3 + 1.05*x4
+}
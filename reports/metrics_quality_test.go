@@ -0,0 +1,127 @@
+package reports
+
+import (
+	"math"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestQualityMetrics(t *testing.T) {
+	v := map[uint32]float32{
+		p.Vendas:     1000,
+		p.LucLiq:     100,
+		p.AtivoTotal: 2000,
+		p.Equity:     500,
+		p.FScore:     7,
+	}
+	metrics := qualityMetrics(v)
+
+	if got := metricValue(t, metrics, "DuPont: Margem Líquida"); got != 0.1 {
+		t.Errorf("margin = %v, want 0.1", got)
+	}
+	if got := metricValue(t, metrics, "DuPont: Giro do Ativo"); got != 0.5 {
+		t.Errorf("turnover = %v, want 0.5", got)
+	}
+	if got := metricValue(t, metrics, "DuPont: Multiplicador do PL (Ativo/PL)"); got != 4 {
+		t.Errorf("multiplier = %v, want 4", got)
+	}
+	roe := metricValue(t, metrics, "DuPont: ROE (Margem x Giro x Multiplicador)")
+	if math.Abs(float64(roe-v[p.LucLiq]/v[p.Equity])) > 1e-6 {
+		t.Errorf("DuPont ROE = %v, want %v", roe, v[p.LucLiq]/v[p.Equity])
+	}
+	if got := metricValue(t, metrics, "Piotroski F-Score (0 a 9)"); got != 7 {
+		t.Errorf("F-Score = %v, want 7", got)
+	}
+	for _, m := range metrics {
+		if m.group != grpQuality {
+			t.Errorf("%q: group = %d, want grpQuality", m.descr, m.group)
+		}
+	}
+}
+
+func TestPiotroski(t *testing.T) {
+	prev := map[uint32]float32{
+		p.AtivoTotal:  1000,
+		p.AtivoCirc:   400,
+		p.PassivoCirc: 300,
+		p.DividaNCirc: 200,
+		p.Vendas:      800,
+		p.CustoVendas: -500,
+		p.LucLiq:      50,
+		p.FCO:         60,
+		p.Shares:      1000,
+	}
+
+	tests := []struct {
+		name string
+		v    map[uint32]float32
+		want float32
+	}{
+		{
+			name: "all tests passed",
+			v: map[uint32]float32{
+				p.AtivoTotal:  1000,
+				p.AtivoCirc:   450,
+				p.PassivoCirc: 300,
+				p.DividaNCirc: 150,
+				p.Vendas:      900,
+				p.CustoVendas: -500,
+				p.LucLiq:      80,
+				p.FCO:         100,
+				p.Shares:      1000,
+			},
+			want: 9,
+		},
+		{
+			name: "all tests failed",
+			v: map[uint32]float32{
+				p.AtivoTotal:  1000,
+				p.AtivoCirc:   300,
+				p.PassivoCirc: 300,
+				p.DividaNCirc: 300,
+				p.Vendas:      700,
+				p.CustoVendas: -600,
+				p.LucLiq:      -10,
+				p.FCO:         -20,
+				p.Shares:      1200,
+			},
+			want: 0,
+		},
+		{
+			name: "no previous year",
+			v:    map[uint32]float32{p.AtivoTotal: 1000, p.LucLiq: 80, p.FCO: 100},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		pv := prev
+		if tt.name == "no previous year" {
+			pv = map[uint32]float32{}
+		}
+		if got := piotroski(tt.v, pv); got != tt.want {
+			t.Errorf("%s: piotroski() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAltmanZ(t *testing.T) {
+	v := map[uint32]float32{
+		p.AtivoTotal:       1000,
+		p.AtivoCirc:        400,
+		p.PassivoCirc:      200,
+		p.PassivoNCirc:     300,
+		p.ReservasLucros:   150,
+		p.LucrosAcumulados: 50,
+		p.EBIT:             100,
+		p.Equity:           500,
+	}
+	// 3.25 + 6.56*0.2 + 3.26*0.2 + 6.72*0.1 + 1.05*1
+	want := 3.25 + 6.56*0.2 + 3.26*0.2 + 6.72*0.1 + 1.05
+	if got := altmanZ(v); math.Abs(float64(got)-want) > 1e-5 {
+		t.Errorf("altmanZ() = %v, want %v", got, want)
+	}
+	if got := altmanZ(map[uint32]float32{}); got != 0 {
+		t.Errorf("altmanZ(empty) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,189 @@
+package reports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	p "github.com/dude333/rapina/parsers"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// A4 page size, in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// pdfDoc is a minimal PDF writer, with A4 pages containing text (Helvetica,
+// WinAnsi encoding), lines and rectangles.
+type pdfDoc struct {
+	pages []*pdfPage
+}
+
+// pdfPage is a page of the PDF document. It implements canvas, converting
+// the coordinates to the PDF origin (bottom left corner).
+type pdfPage struct {
+	buf bytes.Buffer
+}
+
+func (d *pdfDoc) newPage() *pdfPage {
+	pg := &pdfPage{}
+	d.pages = append(d.pages, pg)
+	return pg
+}
+
+func (pg *pdfPage) rect(x, y, w, h float64, color string) {
+	fmt.Fprintf(&pg.buf, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(color), x, pageHeight-y-h, w, h)
+}
+
+func (pg *pdfPage) line(x1, y1, x2, y2, width float64, color string) {
+	fmt.Fprintf(&pg.buf, "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		pdfColor(color), width, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+func (pg *pdfPage) polyline(points []float64, width float64, color string) {
+	if len(points) < 4 {
+		return
+	}
+	fmt.Fprintf(&pg.buf, "%s RG %.2f w 1 j ", pdfColor(color), width)
+	for i := 0; i+1 < len(points); i += 2 {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&pg.buf, "%.2f %.2f %s ", points[i], pageHeight-points[i+1], op)
+	}
+	pg.buf.WriteString("S\n")
+}
+
+func (pg *pdfPage) text(x, y, size float64, anchor string, bold bool, color, s string) {
+	switch anchor {
+	case anchorMiddle:
+		x -= textWidth(s, size, bold) / 2
+	case anchorEnd:
+		x -= textWidth(s, size, bold)
+	}
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&pg.buf, "BT %s rg /%s %g Tf %.2f %.2f Td (%s) Tj ET\n",
+		pdfColor(color), font, size, x, pageHeight-y, pdfString(s))
+}
+
+// write writes the PDF document.
+func (d *pdfDoc) write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catalog, 2: pages, 3 and 4: fonts, then each page and its contents
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = strconv.Itoa(5+2*i) + " 0 R"
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, pg := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(pg.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// pdfColor converts a "#RRGGBB" color to the PDF color components.
+func pdfColor(color string) string {
+	n, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return "0 0 0"
+	}
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(n>>16&0xff)/255, float64(n>>8&0xff)/255, float64(n&0xff)/255)
+}
+
+// pdfString encodes the text to Windows-1252 (WinAnsi), escaping the
+// special characters. Characters not available are replaced by '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Widths of the ASCII characters (32 to 126) of the Helvetica fonts, in
+// thousandths of the font size
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// textWidth returns the width of the text on the Helvetica font of 'size'.
+// Accented letters have the width of the letter without the accent.
+func textWidth(s string, size float64, bold bool) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+	w := 0
+	for _, r := range p.RemoveDiacritics(s) {
+		if r >= 32 && r <= 126 {
+			w += widths[r-32]
+		} else {
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,406 @@
+package reports
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/fetch"
+	"github.com/dude333/rapina/progress"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Position contains the holdings of one stock or FII calculated from the
+// transactions list.
+type Position struct {
+	Code     string
+	FII      bool
+	Quantity float64
+	AvgPrice float64 // average price, fees included
+	Realized float64 // result from sales
+	Income   float64 // received income (dividends, JCP and FII income)
+	Quote    float64 // latest quote
+}
+
+// Cost returns the amount invested on the current position.
+func (p Position) Cost() float64 { return p.Quantity * p.AvgPrice }
+
+// Value returns the current value of the position.
+func (p Position) Value() float64 { return p.Quantity * p.Quote }
+
+// Income contains the income received from 'Code' on 'Month' (YYYY-MM).
+type Income struct {
+	Month string
+	Code  string
+	Val   float64
+}
+
+// Portfolio implements the reports of a portfolio of stocks and FIIs.
+type Portfolio struct {
+	db           *sql.DB
+	fetchFII     *fetch.FII
+	fetchStock   *fetch.Stock
+	reportFormat int
+}
+
+type PortfolioOptions struct {
+	APIKey, DataDir string
+}
+
+// NewPortfolio creates a new instance of Portfolio.
+func NewPortfolio(db *sql.DB, opts PortfolioOptions) (*Portfolio, error) {
+	var log rapina.Logger
+
+	fetchStock, err := fetch.NewStock(db, log, opts.APIKey, opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchFII, err := fetch.NewFII(db, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Portfolio{
+		db:           db,
+		fetchFII:     fetchFII,
+		fetchStock:   fetchStock,
+		reportFormat: Rtable,
+	}, nil
+}
+
+// SetParms set the portfolio reports parameters.
+func (p *Portfolio) SetParms(parms map[string]string) {
+	if _, ok := parms["verbose"]; ok {
+		progress.SetDebug(true)
+	}
+	if r, ok := parms["format"]; ok {
+		if f := PortfolioFormat(r); f != 0 {
+			p.reportFormat = f
+		}
+	}
+}
+
+// PortfolioFormat returns the portfolio report output (Rtable or Rxlsx) for
+// the 'format' name or alias, or 0 if unknown.
+func PortfolioFormat(format string) int {
+	switch format {
+	case "table", "tabela", "tab":
+		return Rtable
+	case "xlsx", "excel":
+		return Rxlsx
+	}
+	return 0
+}
+
+// Report calculates the positions and the income received from the
+// 'transactions' and prints them on the terminal or saves them on the
+// spreadsheet 'filename', depending on the report format.
+func (p Portfolio) Report(transactions []rapina.Transaction, filename string) error {
+	if len(transactions) == 0 {
+		return errors.New("nenhuma transação encontrada")
+	}
+
+	positions, err := portfolioPositions(transactions)
+	if err != nil {
+		return err
+	}
+
+	var dividends []rapina.Dividend
+	for i := range positions {
+		pos := &positions[i]
+		pos.FII = p.isFII(pos.Code)
+
+		if pos.Quantity > 0 {
+			q, err := lastQuote(p.fetchStock, pos.Code)
+			if err != nil {
+				progress.ErrorMsg("%s: %v", pos.Code, err)
+			}
+			pos.Quote = q
+		}
+
+		first := firstTransaction(transactions, pos.Code)
+		if !pos.FII {
+			div, err := p.fetchStock.Dividends(pos.Code, first)
+			if err != nil {
+				progress.ErrorMsg("%s: %v", pos.Code, err)
+				continue
+			}
+			dividends = append(dividends, div...)
+			continue
+		}
+		div, err := p.fetchFII.Dividends(pos.Code, monthsSince(first))
+		if err != nil {
+			progress.ErrorMsg("%s: %v", pos.Code, err)
+			continue
+		}
+		dividends = append(dividends, *div...)
+	}
+
+	income := portfolioIncome(transactions, dividends)
+	for _, inc := range income {
+		for i := range positions {
+			if positions[i].Code == inc.Code {
+				positions[i].Income += inc.Val
+			}
+		}
+	}
+
+	if p.reportFormat == Rxlsx {
+		return p.saveXlsx(filename, positions, income)
+	}
+
+	fmt.Print(printPositions(positions))
+	fmt.Print(printIncome(income))
+
+	return nil
+}
+
+// portfolioPositions calculates the quantity, average price and realized
+// result for each code in 'transactions', which must be sorted by date.
+// Sales of more than the quantity held return an error.
+func portfolioPositions(transactions []rapina.Transaction) ([]Position, error) {
+	positions := make(map[string]*Position)
+	for _, t := range transactions {
+		pos, ok := positions[t.Code]
+		if !ok {
+			pos = &Position{Code: t.Code}
+			positions[t.Code] = pos
+		}
+
+		switch t.Operation {
+		case rapina.Buy:
+			cost := pos.Cost() + t.Quantity*t.Price + t.Fees
+			pos.Quantity += t.Quantity
+			pos.AvgPrice = cost / pos.Quantity
+		case rapina.Sell:
+			if t.Quantity > pos.Quantity {
+				return nil, fmt.Errorf("%s: venda de %v em %s maior que a posição (%v)",
+					t.Code, t.Quantity, t.Date, pos.Quantity)
+			}
+			pos.Realized += t.Quantity*(t.Price-pos.AvgPrice) - t.Fees
+			pos.Quantity -= t.Quantity
+			if pos.Quantity == 0 {
+				pos.AvgPrice = 0
+			}
+		}
+	}
+
+	list := make([]Position, 0, len(positions))
+	for _, pos := range positions {
+		list = append(list, *pos)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+
+	return list, nil
+}
+
+// portfolioIncome returns the income received for each dividend, given the
+// quotas or shares held on its base date, grouped by payment month and code.
+func portfolioIncome(transactions []rapina.Transaction, dividends []rapina.Dividend) []Income {
+	type key struct{ month, code string }
+	sum := make(map[key]float64)
+
+	for _, d := range dividends {
+		q := quotasOn(transactions, d.Code, d.Date)
+		if q <= 0 {
+			continue
+		}
+		date := d.PaymentDate
+		if len(date) < 7 {
+			date = d.Date
+		}
+		if len(date) < 7 {
+			continue
+		}
+		sum[key{date[:7], d.Code}] += q * d.Val
+	}
+
+	list := make([]Income, 0, len(sum))
+	for k, v := range sum {
+		list = append(list, Income{Month: k.month, Code: k.code, Val: v})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Month == list[j].Month {
+			return list[i].Code < list[j].Code
+		}
+		return list[i].Month < list[j].Month
+	})
+
+	return list
+}
+
+// quotasOn returns the number of quotas of 'code' held at the end of 'date'.
+func quotasOn(transactions []rapina.Transaction, code, date string) float64 {
+	var q float64
+	for _, t := range transactions {
+		if t.Code != code || t.Date > date {
+			continue
+		}
+		switch t.Operation {
+		case rapina.Buy:
+			q += t.Quantity
+		case rapina.Sell:
+			q -= t.Quantity
+		}
+	}
+	return q
+}
+
+// firstTransaction returns the date of the first transaction of 'code'.
+func firstTransaction(transactions []rapina.Transaction, code string) string {
+	for _, t := range transactions {
+		if t.Code == code {
+			return t.Date
+		}
+	}
+	return ""
+}
+
+// monthsSince returns the number of months from 'date' (YYYY-MM-DD) until
+// today, including both months.
+func monthsSince(date string) int {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 1
+	}
+	now := time.Now()
+	n := (now.Year()-t.Year())*12 + int(now.Month()-t.Month()) + 1
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// isFII checks on the B3 codes list if 'code' is a FII, falling back to the
+// code pattern (ABCD11) if not found.
+func (p Portfolio) isFII(code string) bool {
+	var spec string
+	err := p.db.QueryRow(`SELECT SpcfctnCd FROM stock_codes WHERE trading_code = ?;`, code).Scan(&spec)
+	if err == nil {
+		return strings.HasPrefix(spec, "CI")
+	}
+	return len(code) == 6 && strings.HasSuffix(code, "11")
+}
+
+func printPositions(positions []Position) *strings.Builder {
+	buf := &strings.Builder{}
+	pr := message.NewPrinter(language.BrazilianPortuguese)
+
+	pr.Fprintln(buf, line)
+	pr.Fprintln(buf, "CARTEIRA")
+	pr.Fprintln(buf, line)
+	pr.Fprintln(buf, "  CÓDIGO       QTD  PREÇO MÉDIO   COTAÇÃO   VALOR ATUAL  RENDIMENTOS")
+	pr.Fprintln(buf, "  ------  --------  -----------  --------  ------------  -----------")
+
+	var cost, quoted, value, income, realized float64
+	for _, pos := range positions {
+		pr.Fprintf(buf, "  %-6s  %8.0f  %11.2f  ", pos.Code, pos.Quantity, pos.AvgPrice)
+		if pos.Quote > 0 {
+			pr.Fprintf(buf, "%8.2f  %12.2f  ", pos.Quote, pos.Value())
+			quoted += pos.Cost() // valuation only for positions with quote
+		} else {
+			pr.Fprintf(buf, "%8s  %12s  ", "-", "-")
+		}
+		pr.Fprintf(buf, "%11.2f\n", pos.Income)
+		cost += pos.Cost()
+		value += pos.Value()
+		income += pos.Income
+		realized += pos.Realized
+	}
+
+	pr.Fprintln(buf, line)
+	pr.Fprintf(buf, "  Custo total:           R$ %14.2f\n", cost)
+	pr.Fprintf(buf, "  Valor atual:           R$ %14.2f\n", value)
+	var pct float64
+	if quoted > 0 {
+		pct = 100 * (value - quoted) / quoted
+	}
+	pr.Fprintf(buf, "  Valorização:           R$ %14.2f (%.2f%%)\n", value-quoted, pct)
+	pr.Fprintf(buf, "  Resultado das vendas:  R$ %14.2f\n", realized)
+	pr.Fprintf(buf, "  Rendimentos recebidos: R$ %14.2f\n", income)
+	buf.WriteByte('\n')
+
+	return buf
+}
+
+func printIncome(income []Income) *strings.Builder {
+	buf := &strings.Builder{}
+	if len(income) == 0 {
+		return buf
+	}
+	pr := message.NewPrinter(language.BrazilianPortuguese)
+
+	pr.Fprintln(buf, line)
+	pr.Fprintln(buf, "RENDIMENTOS POR MÊS")
+	pr.Fprintln(buf, line)
+	pr.Fprintln(buf, "  MÊS        RENDIMENTO  CÓDIGOS")
+	pr.Fprintln(buf, "  -------  ------------  -------")
+
+	for i := 0; i < len(income); {
+		month := income[i].Month
+		var total float64
+		var codes []string
+		for ; i < len(income) && income[i].Month == month; i++ {
+			total += income[i].Val
+			codes = append(codes, income[i].Code)
+		}
+		pr.Fprintf(buf, "  %s  R$%10.2f  %s\n", month, total, strings.Join(codes, " "))
+	}
+	buf.WriteByte('\n')
+
+	return buf
+}
+
+func (p Portfolio) saveXlsx(filename string, positions []Position, income []Income) error {
+	e := newExcel()
+
+	sheet, err := e.newSheet("Carteira")
+	if err != nil {
+		return err
+	}
+	titles := []string{"Código", "Tipo", "Quantidade", "Preço Médio", "Custo",
+		"Cotação", "Valor Atual", "Valorização", "Resultado Vendas", "Rendimentos"}
+	for col, title := range titles {
+		_ = sheet.printTitle(axis(col, 1), title)
+	}
+	for i, pos := range positions {
+		row := i + 2
+		kind := "Ação"
+		if pos.FII {
+			kind = "FII"
+		}
+		_ = sheet.print(axis(0, row), &[]string{pos.Code, kind}, DEFAULT, false)
+		for col, v := range []float64{pos.Quantity, pos.AvgPrice, pos.Cost(),
+			pos.Quote, pos.Value(), pos.Value() - pos.Cost(), pos.Realized, pos.Income} {
+			_ = sheet.printValue(axis(col+2, row), float32(v), INDEX, false)
+		}
+	}
+	sheet.xlsx.SetColWidth(sheet.name, "A", "J", 16)
+
+	sheet, err = e.newSheet("Rendimentos")
+	if err != nil {
+		return err
+	}
+	_ = sheet.printTitle(axis(0, 1), "Mês")
+	_ = sheet.printTitle(axis(1, 1), "Código")
+	_ = sheet.printTitle(axis(2, 1), "Rendimento")
+	for i, inc := range income {
+		row := i + 2
+		_ = sheet.print(axis(0, row), &[]string{inc.Month, inc.Code}, DEFAULT, false)
+		_ = sheet.printValue(axis(2, row), float32(inc.Val), INDEX, false)
+	}
+	sheet.xlsx.SetColWidth(sheet.name, "A", "C", 16)
+
+	if err := e.saveAndCloseExcel(filename); err != nil {
+		return err
+	}
+	progress.Status("Planilha salva em %s", filename)
+
+	return nil
+}
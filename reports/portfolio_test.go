@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dude333/rapina"
+)
+
+var testTransactions = []rapina.Transaction{
+	{Date: "2021-01-15", Code: "KNIP11", Operation: rapina.Buy, Quantity: 10, Price: 100, Fees: 10},
+	{Date: "2021-02-10", Code: "KNIP11", Operation: rapina.Buy, Quantity: 10, Price: 120},
+	{Date: "2021-02-20", Code: "PETR4", Operation: rapina.Buy, Quantity: 100, Price: 25},
+	{Date: "2021-03-01", Code: "KNIP11", Operation: rapina.Sell, Quantity: 5, Price: 130, Fees: 5},
+	{Date: "2021-03-05", Code: "PETR4", Operation: rapina.Sell, Quantity: 100, Price: 20},
+}
+
+func Test_portfolioPositions(t *testing.T) {
+	want := []Position{
+		{Code: "KNIP11", Quantity: 15, AvgPrice: 110.5, Realized: 5*(130-110.5) - 5},
+		{Code: "PETR4", Quantity: 0, AvgPrice: 0, Realized: -500},
+	}
+
+	got, err := portfolioPositions(testTransactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("portfolioPositions() = %+v, want %+v", got, want)
+	}
+	const e = 1e-9
+	for i := range want {
+		if got[i].Code != want[i].Code ||
+			math.Abs(got[i].Quantity-want[i].Quantity) > e ||
+			math.Abs(got[i].AvgPrice-want[i].AvgPrice) > e ||
+			math.Abs(got[i].Realized-want[i].Realized) > e {
+			t.Errorf("portfolioPositions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func Test_portfolioPositionsOversell(t *testing.T) {
+	transactions := append([]rapina.Transaction{}, testTransactions...)
+	transactions = append(transactions,
+		rapina.Transaction{Date: "2021-04-01", Code: "KNIP11", Operation: rapina.Sell, Quantity: 20, Price: 130})
+
+	if _, err := portfolioPositions(transactions); err == nil {
+		t.Error("expected error selling more than the position")
+	}
+}
+
+func Test_portfolioIncome(t *testing.T) {
+	dividends := []rapina.Dividend{
+		{Code: "KNIP11", Date: "2021-01-29", PaymentDate: "2021-02-12", Val: 1},
+		{Code: "KNIP11", Date: "2021-02-26", PaymentDate: "2021-03-12", Val: 0.5},
+		{Code: "KNIP11", Date: "2021-03-31", Val: 1},
+		{Code: "KNIP11", Date: "2020-12-30", PaymentDate: "2021-01-14", Val: 1},
+	}
+	dividends = append(dividends, // stock dividends and JCP, by ex-date
+		rapina.Dividend{Code: "PETR4", Date: "2021-02-25", PaymentDate: "2021-02-26", Val: 0.5},
+		rapina.Dividend{Code: "PETR4", Date: "2021-03-10", PaymentDate: "2021-03-11", Val: 0.5},
+	)
+	want := []Income{
+		{Month: "2021-02", Code: "KNIP11", Val: 10},
+		{Month: "2021-02", Code: "PETR4", Val: 50},
+		{Month: "2021-03", Code: "KNIP11", Val: 10 + 15},
+	}
+
+	got := portfolioIncome(testTransactions, dividends)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("portfolioIncome() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPortfolioFormat(t *testing.T) {
+	for format, want := range map[string]int{
+		"tabela": Rtable,
+		"table":  Rtable,
+		"xlsx":   Rxlsx,
+		"excel":  Rxlsx,
+		"csv":    0,
+	} {
+		if got := PortfolioFormat(format); got != want {
+			t.Errorf("PortfolioFormat(%q) = %d, want %d", format, got, want)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package reports
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"os/signal"
 	"path"
@@ -25,6 +27,16 @@ const (
 	grpShares
 	grpExtra
 	grpFleuriet
+	grpQuality
+	grpCustom   // formulas from the config file
+	grpTemplate // metrics defined on a template
+)
+
+// Statements layouts, used to select the metrics
+const (
+	layoutStd int = iota
+	layoutBank
+	layoutInsurer
 )
 
 // metric parameters
@@ -37,11 +49,9 @@ type metric struct {
 
 // Report parameters used in most functions
 type Report struct {
-	// average metric values/year. Index 0: year, index 1: metric
-	average [][]float32
-
 	// groups that will be printed on the output xlsx
 	//   - ExtraRatios: enables some extra financial ratios on report
+	//   - Quality: enables the DuPont, Piotroski and Altman metrics
 	//   - ShowShares: shows the number of shares and free float on report
 	//   - Sector: creates a sheet with the sector report
 	groups map[int]bool
@@ -49,21 +59,48 @@ type Report struct {
 	// if true will print the reports for comanpanies in the same sector
 	printSector bool
 
+	// unit used to display the monetary values
+	unit Unit
+
+	// if set, creates a sheet with the valuation using these parameters
+	valuation *ValuationParms
+
+	// inflation used to report the values in constant currency of 'baseYear'
+	// (nil for nominal values), and the resulting deflators by year
+	inflation p.Inflation
+	baseYear  int
+	deflators map[int]float32
+
+	// user-defined peer group (company names), used instead of the B3
+	// segment on the sector sheet and on the sector average
+	peers     []string
+	peersName string
+
+	// if set, defines the metrics printed instead of the groups
+	template *Template
+
+	// terminal width (0 if unknown, or not a terminal) and colors, used by
+	// ReportToStdout
+	width int
+	color bool
+
 	// get the stock quotes
 	fetchStock *fetch.Stock
 
 	/* Current company */
-	cid  int    // Company ID
-	cnpj string // Company CNPJ
-	code string // Company stock code
+	cid          int    // Company ID
+	cnpj         string // Company CNPJ
+	code         string // Company stock code
+	storedQuotes bool   // Quotes only read from the db (peers)
+	layout       int    // Statements layout (layoutStd, layoutBank, layoutInsurer)
 
 	/* Parameters from caller */
 	db       *sql.DB // Sqlite3 handler
 	company  string  // company name to be processed
 	spcfctnCd  	string  // spcfctnCd used to select the correct ticker
 	format   string  // report format
+	quiet    bool    // no progress messages (batch reports)
 	filename string  // path and filename of the output xlsx
-	yamlFile string  // file with the companies' sectors
 }
 
 func New(parms map[string]interface{}) (*Report, error) {
@@ -84,8 +121,39 @@ func New(parms map[string]interface{}) (*Report, error) {
 	if v, ok := parms["filename"]; ok {
 		r.filename = v.(string)
 	}
-	if v, ok := parms["yamlFile"]; ok {
-		r.yamlFile = v.(string)
+	if v, ok := parms["unit"]; ok {
+		r.unit = v.(Unit)
+	}
+	if v, ok := parms["inflation"]; ok {
+		r.inflation = v.(p.Inflation)
+	}
+	if v, ok := parms["baseYear"]; ok {
+		r.baseYear = v.(int)
+	}
+	if v, ok := parms["valuation"]; ok {
+		r.valuation = v.(*ValuationParms)
+	}
+	if v, ok := parms["peers"]; ok {
+		r.peers = v.([]string)
+	}
+	if v, ok := parms["peersName"]; ok {
+		r.peersName = v.(string)
+	}
+	if v, ok := parms["quiet"]; ok {
+		r.quiet = v.(bool)
+	}
+	if v, ok := parms["width"]; ok {
+		r.width = v.(int)
+	}
+	if v, ok := parms["color"]; ok {
+		r.color = v.(bool)
+	}
+	if v, ok := parms["template"]; ok && v.(string) != "" {
+		t, err := LoadTemplate(v.(string))
+		if err != nil {
+			return nil, err
+		}
+		r.template = t
 	}
 	if v, ok := parms["reports"]; ok {
 		p := v.(map[string]bool)
@@ -94,12 +162,20 @@ func New(parms map[string]interface{}) (*Report, error) {
 		r.groups[grpShares] = p["ShowShares"]
 		r.groups[grpExtra] = p["ExtraRatios"]
 		r.groups[grpFleuriet] = p["Fleuriet"]
+		r.groups[grpQuality] = p["Quality"]
+		r.groups[grpCustom] = true
 
 		r.printSector = true
 		if v, ok := p["PrintSector"]; ok {
 			r.printSector = v
 		}
 	}
+	if r.template != nil {
+		if r.groups == nil {
+			r.groups = make(map[int]bool, 1)
+		}
+		r.groups[grpTemplate] = true
+	}
 
 	dataDir := path.Join(".", "data")
 	if v, ok := parms["dataDir"]; ok {
@@ -117,6 +193,13 @@ func New(parms map[string]interface{}) (*Report, error) {
 	return &r, err
 }
 
+// printf prints the progress messages, unless on quiet mode.
+func (r Report) printf(format string, a ...interface{}) {
+	if !r.quiet {
+		fmt.Printf(format, a...)
+	}
+}
+
 //
 // ReportToXlsx reports company financial data from DB to Excel.
 //
@@ -127,29 +210,101 @@ func ReportToXlsx(parms map[string]interface{}) error {
 		return err
 	}
 
-	err = r.setCompanyAndTicker(r.company,r.spcfctnCd)
+	e := newExcel()
+	if err = r.workbook(e, "", true); err != nil {
+		return err
+	}
+
+	err = e.saveAndCloseExcel(r.filename)
+	if err == nil && !r.quiet {
+		fmt.Printf("[√] Dados salvos em %s\n", r.filename)
+	}
+
+	return err
+}
+
+//
+// workbook adds the company report to the Excel workbook, on a sheet named
+// after the company (or 'name', if set). If 'full' is set, the charts,
+// valuation and sector sheets are also added.
+//
+func (r *Report) workbook(e *Excel, name string, full bool) error {
+	d, err := r.workbookData()
 	if err != nil {
-		return fmt.Errorf("empresa '%s' não encontrada no banco de dados", r.company)
+		return err
 	}
+	return r.printWorkbook(e, name, d, full)
+}
 
-	e := newExcel()
-	sheet, _ := e.newSheet(r.company)
+// workbookData contains the company data read from the DB to be printed on
+// the workbook.
+type workbookData struct {
+	begin, end int
+	accounts   []accItems
+	values     map[int]map[uint32]float32
+	errs       map[int]error // errors reading the values of each year
+	lastYear   int
+	isTTM      bool
+	ttmErr     error
+}
+
+// workbookData sets the company and reads its data from the DB, so the
+// workbook can be printed without further queries (a shared workbook is
+// only locked while printing).
+func (r *Report) workbookData() (*workbookData, error) {
+	err := r.setCompanyAndTicker(r.company, r.spcfctnCd)
+	if err != nil {
+		return nil, fmt.Errorf("empresa '%s' não encontrada no banco de dados", r.company)
+	}
+
+	d := &workbookData{
+		values: make(map[int]map[uint32]float32),
+		errs:   make(map[int]error),
+	}
+	d.begin, d.end, err = timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.setDeflators(d.begin, d.end); err != nil {
+		return nil, err
+	}
+
+	d.accounts, _ = r.accountsItems(r.cid)
+	d.lastYear, d.isTTM, d.ttmErr = r.lastYear(r.cid)
+	for y := d.begin; y <= d.end; y++ {
+		d.values[y], d.errs[y] = r.accountsValues(y)
+	}
+
+	return d, nil
+}
+
+// printWorkbook prints the company data 'd' on the workbook (see workbook).
+func (r *Report) printWorkbook(e *Excel, name string, d *workbookData, full bool) error {
+	begin, end := d.begin, d.end
+
+	if name == "" {
+		name = r.company
+	}
+	sheet, err := e.newSheet(name)
+	if err != nil {
+		return err
+	}
 
 	// Company name
 	sheet.mergeCell("A1", "B1")
-	sheet.print("A1", &[]string{r.company}, LEFT, true)
+	sheet.print("A1", &[]string{r.company + " (" + r.unitLabel() + ")"}, LEFT, true)
 
 	// ACCOUNT NUMBERING AND DESCRIPTION (COLS A AND B) ===============\/
-	accounts, _ := r.accountsItems(r.cid)
+	accounts := d.accounts
 	baseItems, lastStatementsRow, lastMetricsRow := r.printCodesAndDescriptions(sheet, accounts, 'A', 2)
 
 	// 	VALUES (COLS C, D, E...) / PER YEAR ===========================\/
 
-	begin, end, err := timeRange(r.db)
-	if err != nil {
-		return err
-	}
 	var values map[uint32]float32
+	var growth []AccountSeries
+	history := make(yearValues)        // used by the formulas
+	metricRows := make(map[string]int) // used by the charts
+	var titleRow int
 
 	// LOOP THROUGH YEARS =============================================\/
 	for y := begin; y <= end; y++ {
@@ -159,15 +314,14 @@ func ReportToXlsx(parms map[string]interface{}) error {
 		cell := col + "1"
 		title := "[" + strconv.Itoa(y) + "]"
 
-		lastYear, isTTM, err := r.lastYear(r.cid)
-		if lastYear == y && isTTM && err == nil {
+		if d.lastYear == y && d.isTTM && d.ttmErr == nil {
 			title = "[TTM/" + strconv.Itoa(y) + "]"
 		}
 
 		// ACCOUNT VALUES (COLS C, D, E...) / YEAR ====================\/
-		values, err = r.accountsValues(y)
+		values, err = d.values[y], d.errs[y]
 		if err != nil {
-			fmt.Println("[x]", err)
+			r.printf("[x] %v\n", err)
 			continue
 		}
 		// Skip last year if empty
@@ -176,9 +330,10 @@ func ReportToXlsx(parms map[string]interface{}) error {
 			break
 		}
 		_ = sheet.printTitle(cell, title) // Print year as title on row 1
+		growth = r.addGrowthValues(growth, y, values)
 		for _, acct := range accounts {
 			cell := col + strconv.Itoa(row)
-			_ = sheet.printValue(cell, values[acct.code], NUMBER, baseItems[row])
+			_ = sheet.printValue(cell, values[acct.code]*r.factor(y), NUMBER, baseItems[row])
 			row++
 		}
 
@@ -186,17 +341,27 @@ func ReportToXlsx(parms map[string]interface{}) error {
 		row++
 		cell = col + strconv.Itoa(row)
 		_ = sheet.printTitle(cell, title) // Print year as title
+		titleRow = row
 		row++
-		// Print report in the sequence defined on metricsList()
-		for _, metric := range metricsList(values) {
+		// Print report in the sequence defined on metrics()
+		history[y] = values
+		yoy := growthDescrs(r.layout)
+		for _, metric := range r.metrics(y, history) {
 			if !r.groups[metric.group] {
 				continue
 			}
-			if metric.format != EMPTY {
+			if _, ok := metricRows[metric.descr]; !ok {
+				metricRows[metric.descr] = row
+			}
+			if metric.format != EMPTY && !math.IsNaN(float64(metric.val)) {
 				cell := col + strconv.Itoa(row)
-				_ = sheet.printValue(cell, metric.val, metric.format, false)
+				_ = sheet.printValue(cell, r.display(metric, y), metric.format, false)
 			}
 			row++
+			if yoy[metric.descr] { // skip the year-over-year change row
+				delete(yoy, metric.descr)
+				row++
+			}
 		}
 
 	} // next year
@@ -256,11 +421,20 @@ func ReportToXlsx(parms map[string]interface{}) error {
 	year = begin
 	top = lastStatementsRow + 2
 	bottom = lastMetricsRow
+	yoyRows := make(map[int]bool) // rows with the year-over-year change
+	for descr := range growthDescrs(r.layout) {
+		if row, ok := metricRows[descr]; ok {
+			yoyRows[row+1] = true
+		}
+	}
 	for col := 0; col <= wide-1; col++ {
 		year++
 		vCol := (2 + wide + 2) + col                                  // Column where the horizontal analysis will be printed
 		_ = sheet.printTitle(axis(vCol, top), "'"+strconv.Itoa(year)) // Print year
 		for row := top + 1; row <= bottom; row++ {
+			if yoyRows[row] {
+				continue
+			}
 			vt0 := axis(col+2, row)
 			vtn := axis(col+3, row)
 			formula := fmt.Sprintf(`=IF(OR(%s="", %s=""), "", IF(MIN(%s, %s)<=0, IF((%s - %s)>0, "      ⇧", "      ⇩"), (%s/%s)-1))`,
@@ -278,6 +452,9 @@ func ReportToXlsx(parms map[string]interface{}) error {
 	vCol := (2 + wide + 2) + wide + 1
 	_ = sheet.printTitle(axis(vCol, top), "CAGR")
 	for row := top + 1; row <= bottom; row++ {
+		if yoyRows[row] {
+			continue
+		}
 		vt0 := axis(2, row)
 		vtn := axis(2+wide, row)
 		formula := fmt.Sprintf(`=IF(OR(%s="", %s="", %s=0, (%s*%s)<0), "", (%s/%s)^(1/%d)-1)`,
@@ -285,9 +462,33 @@ func ReportToXlsx(parms map[string]interface{}) error {
 		_ = sheet.printFormula(axis(vCol, row), formula, PERCENT, false)
 	}
 
+	// GROWTH (YoY below the main metrics and their CAGR)
+	r.printGrowth(sheet, growth, metricRows, lastMetricsRow+3, begin)
+
 	// ADJUST COLUMNS WIDTH
 	sheet.autoWidth()
 
+	if !full {
+		return nil
+	}
+
+	// CHARTS
+	if titleRow > 0 {
+		if sheet4, err := e.newSheet("GRAFICOS"); err == nil {
+			companyCharts(sheet4, sheet, metricRows, titleRow, 2, 2+end-begin)
+		}
+	}
+
+	// VALUATION
+	if r.valuation != nil {
+		v, err := r.Valuation(r.company, r.spcfctnCd, *r.valuation)
+		if err != nil {
+			r.printf("[x] Valuation: %v\n", err)
+		} else if sheet3, err := e.newSheet("VALUATION"); err == nil {
+			valuationSheet(sheet3, v)
+		}
+	}
+
 	// SECTOR REPORT
 	if r.printSector {
 		sheet2, err := e.newSheet("SETOR")
@@ -300,23 +501,47 @@ func ReportToXlsx(parms map[string]interface{}) error {
 		}
 	}
 
-	err = e.saveAndCloseExcel(r.filename)
-	if err == nil {
-		fmt.Printf("[√] Dados salvos em %s\n", r.filename)
-	}
-
-	return err
+	return nil
 }
 
-//ReportToStdout reports company financial data from DB to Stdout.
+// ReportToStdout prints the metrics of the company on all years available
+// (the most recent ones that fit on the terminal width) and the growth, and
+// the sector statistics.
 func ReportToStdout(parms map[string]interface{}) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+
+	t, err := r.tearsheet(r.company, r.unit, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Print(printTearsheet(t, r.width, r.color))
+
+	// Sector statistics
+	if r.printSector {
+		s, err := r.SectorStats(t.Company, r.unit)
+		if err != nil {
+			fmt.Println("[x] Estatísticas do setor:", err)
+			return nil
+		}
+		fmt.Println()
+		fmt.Print(printSectorStats(s))
+	}
+
+	return nil
+}
 
+// ReportRawToStdout prints the accounts of the company on all years, one per
+// line ("year;CD_CONTA;DS_CONTA;value"), and the sector statistics.
+func ReportRawToStdout(parms map[string]interface{}) error {
 	r, err := New(parms)
 	if err != nil {
 		return err
 	}
 
-	err = r.setCompanyAndTicker(r.company,r.spcfctnCd)
+	err = r.setCompanyAndTicker(r.company, r.spcfctnCd)
 	if err != nil {
 		return fmt.Errorf("empresa '%s' não encontrada no banco de dados", r.company)
 	}
@@ -325,15 +550,20 @@ func ReportToStdout(parms map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
+	if err = r.setDeflators(begin, end); err != nil {
+		return err
+	}
 
 	acc := []AccountValue{}
 
 	for y := begin; y <= end; y++ {
-
 		d, err := r.RawAccounts(r.cid, y)
 		if err != nil {
 			return err
 		}
+		for i := range d {
+			d[i].value *= r.factor(y)
+		}
 
 		acc = append(acc, d...)
 	}
@@ -345,7 +575,62 @@ func ReportToStdout(parms map[string]interface{}) error {
 
 	fmt.Print(accBuf)
 
-	return err
+	// Sector statistics
+	if r.printSector {
+		s, err := r.SectorStats(r.company, r.unit)
+		if err != nil {
+			fmt.Println("[x] Estatísticas do setor:", err)
+			return nil
+		}
+		fmt.Println()
+		fmt.Print(printSectorStats(s))
+	}
+
+	return nil
+}
+
+// ReportToMarkdown prints the metrics of the company on the last years and
+// the growth as Markdown tables.
+func ReportToMarkdown(parms map[string]interface{}) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+	r.quiet = true
+
+	t, err := r.tearsheet(r.company, r.unit, tearsheetYears)
+	if err != nil {
+		return err
+	}
+	fmt.Print(markdownTearsheet(t))
+
+	return nil
+}
+
+// ReportToJSON reports the sector statistics of the company (median,
+// quartiles and percentile rank of each metric) in JSON to Stdout.
+func ReportToJSON(parms map[string]interface{}) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return err
+	}
+	if err = r.setDeflators(begin, end); err != nil {
+		return err
+	}
+
+	s, err := r.SectorStats(r.company, r.unit)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
 }
 
 func buildStdAccountReport(data []AccountValue) (*strings.Builder, error) {
@@ -388,25 +673,45 @@ func (r Report) sectorReport(sheet *Sheet, company string) (err error) {
 	// Companies from the same sector
 	companies, secName, err := r.fromSector(company)
 	if len(companies) <= 1 || err != nil {
-		err = errors.Wrap(err, "erro ao ler arquivo de setores "+r.yamlFile)
+		err = errors.Wrap(err, "erro ao ler setores")
 		return
 	}
-	companies = append([]string{sectorAverage}, companies...)
 
-	fmt.Println("[i] Criando relatório setorial (Ctrl+C para interromper)")
-	var top, row, col int = 2, 0, 0
-	var count int
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return
+	}
+
+	r.printf("[i] Criando relatório setorial (Ctrl+C para interromper)\n")
+	sd := r.sectorData(companies, begin, end)
+	if interrupt {
+		return nil
+	}
+
+	// Sector statistics followed by the companies
+	type block struct {
+		kind    int // blockX constants
+		company string
+	}
+	var list []block
+	for _, kind := range []int{blockAverage, blockMedian, blockP25, blockP75, blockRank} {
+		list = append(list, block{kind, company})
+	}
 	for _, co := range companies {
+		list = append(list, block{blockCompany, co})
+	}
+
+	var top, row, col int = 2, 0, 0
+	var count, lastCol int
+	for _, b := range list {
 		row = top
 		col++
 
-		fmt.Printf("[ ] - %s", co)
-		avg := false
-		if co == sectorAverage {
-			avg = true
-			co = company
+		r.printf("[ ] - %s", blockTitle(b.kind, b.company))
+		empty, err := r.companySummary(sheet, &row, &col, b.company, secName, count%3 == 0, b.kind, sd)
+		if col > lastCol {
+			lastCol = col
 		}
-		empty, err := r.companySummary(sheet, &row, &col, co, secName, count%3 == 0, avg)
 		ok := "√"
 		if err != nil || empty {
 			ok = "x"
@@ -421,34 +726,30 @@ func (r Report) sectorReport(sheet *Sheet, company string) (err error) {
 		if interrupt {
 			return nil
 		}
-		fmt.Printf("\r[%s\n", ok)
+		r.printf("\r[%s\n", ok)
 	}
 
+	sd.colorScales(sheet)
+	sd.scatter(sheet, company, lastCol+1, 2)
 	sheet.setColWidth(0, 2)
 
 	return
 }
 
 //
-// companySummary reports all companies from the same segment into the
-// 'Setor' sheet.
+// companySummary reports a company from the same segment, or a statistic
+// of the sector ('block'), into the 'Setor' sheet.
 //
-func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorName string, printDescr, sectorAvg bool) (empty bool, err error) {
-	// if !sectorAvg && !r.isCompany(company) {
-	// 	return true, nil
-	// }
-
-	err = r.setCompanyAndTicker(r.company,r.spcfctnCd)
-	if err != nil {
-		err = errors.Errorf("empresa '%s' não encontrada no banco de dados", _company)
+func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorName string, printDescr bool, block int, sd *sectorData) (empty bool, err error) {
+	if err = r.setPeer(_company); err != nil {
 		return
 	}
-
-	begin, end, err := timeRange(r.db)
-	if err != nil {
-		return
+	if block == blockCompany && len(sd.accounts[_company]) == 0 {
+		return true, nil
 	}
 
+	begin, end := sd.begin, sd.end
+
 	// Formats used in this report
 	sTitle := newFormat(DEFAULT, RIGHT, true).newStyle(sheet.xlsx)
 	fCompanyName := newFormat(DEFAULT, CENTER, true)
@@ -476,11 +777,12 @@ func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorNam
 		*col++
 	}
 	sheet.mergeCell(axis(*col, *row), axis(*col+end-begin+1, *row))
-	if sectorAvg {
-		sheet.printCell(*row-1, *col-1, sectorName, sSectorName)
-		sheet.printCell(*row, *col, sectorAverage, sCompanyName)
-	} else {
-		sheet.printCell(*row, *col, _company, sCompanyName)
+	if block == blockAverage {
+		sheet.printCell(*row-1, *col-1, sectorName+" ("+r.unitLabel()+")", sSectorName)
+	}
+	sheet.printCell(*row, *col, blockTitle(block, _company), sCompanyName)
+	if block == blockCompany {
+		sd.titles[_company] = axis(*col, *row)
 	}
 	if printDescr {
 		*col--
@@ -498,16 +800,13 @@ func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorNam
 
 	// Print values ONE YEAR PER COLUMN
 	for y := begin; y <= end; y++ {
-		var values map[uint32]float32
+		values := sd.accounts[_company][y]
 		var err error
-		if sectorAvg {
+		if block == blockAverage {
 			values, err = r.accountsAverage(_company, y)
-			r.average = append(r.average, []float32{})
-		} else {
-			values, err = r.accountsValues(y)
 		}
 		if err != nil {
-			fmt.Printf(" -- %v", err)
+			r.printf(" -- %v", err)
 			return false, err
 		}
 
@@ -525,13 +824,24 @@ func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorNam
 
 		// Print financial metrics
 		i := 0
-		for _, metric := range metricsList(values) {
+		history := yearValues(sd.accounts[_company])
+		if block == blockAverage {
+			history = yearValues{y: values}
+			// Previous years used by the formulas
+			for k := 1; k <= formulasOffset(r.template); k++ {
+				if prev, err := r.accountsAverage(_company, y-k); err == nil && sum(prev) != 0 {
+					history[y-k] = prev
+				}
+			}
+		}
+		metrics := r.metrics(y, history)
+		if block > blockAverage {
+			metrics = sd.statsMetrics(block, y, metrics)
+		}
+		for _, metric := range metrics {
 			if !r.groups[metric.group] {
 				continue
 			}
-			if sectorAvg {
-				r.average[y-begin] = append(r.average[y-begin], metric.val)
-			}
 			// Description
 			if printDescr {
 				stl := sDescr
@@ -549,20 +859,16 @@ func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorNam
 					{Type: "bottom", Color: "cccccc", Style: 1},
 					{Type: "left", Color: "cccccc", Style: 1},
 				}
-				// Color the cell background according to its value compared with the average
-				if len(r.average) > 0 && len(r.average[y-begin]) > 0 && len(r.average[y-begin]) >= i {
-					f := formatFill{Type: "pattern", Pattern: 1}
-					if metric.val > r.average[y-begin][i] {
-						f.Color = []string{"c6efce"} // green
-						fVal.Fill = f
-					} else if metric.val < r.average[y-begin][i] {
-						f.Color = []string{"ffc7ce"} // red
-						fVal.Fill = f
-					}
-				}
-
 				stl := fVal.newStyle(sheet.xlsx)
-				sheet.printCell(*row, *col, metric.val, stl)
+				var val interface{} = r.display(metric, y)
+				if math.IsNaN(float64(metric.val)) {
+					val = "" // previous year not available
+				}
+				sheet.printCell(*row, *col, val, stl)
+				// Colored by the position among the sector companies
+				if valid(metric.val) {
+					sd.addCell(block, _company, y, metric, axis(*col, *row))
+				}
 			}
 			*row++
 			i++
@@ -578,6 +884,11 @@ func (r *Report) companySummary(sheet *Sheet, row, col *int, _company, sectorNam
 
 	bottom := *row
 
+	// The percentile rank has no growth
+	if block == blockRank {
+		return
+	}
+
 	// CAGR (compound annual growth rate)
 	// CAGR (t0, tn) = (V(tn)/V(t0))^(1/(tn-t0-1))-1
 	wide := end - begin
@@ -601,12 +912,20 @@ func (r *Report) Summary(company string) (map[string]string, error) {
 	if err != nil {
 		return m, err
 	}
-	values, err := r.accountsValues(2020)
+	year, _, err := r.lastYear(r.cid)
 	if err != nil {
 		return m, err
 	}
+	history := make(yearValues)
+	for y := year - formulasOffset(r.template); y <= year; y++ {
+		values, err := r.accountsValues(y)
+		if err != nil {
+			return m, err
+		}
+		history[y] = values
+	}
 
-	for _, metric := range metricsList(values) {
+	for _, metric := range r.metrics(year, history) {
 		if !r.groups[metric.group] {
 			continue
 		}
@@ -615,27 +934,50 @@ func (r *Report) Summary(company string) (map[string]string, error) {
 	return m, nil
 }
 
+// metrics returns the metrics of the report on 'year': the ones defined on
+// the template, if set, or the built-in list of the statements layout
+// followed by the custom metrics. 'values' contains the account values of
+// the year and, for the formulas that use them, of the previous years.
+func (r Report) metrics(year int, values yearValues) []metric {
+	env := values.env(year)
+	if r.template != nil {
+		return r.template.metrics(r.layout, env)
+	}
+	return append(metricsList(r.layout, env(0)), customMetrics(env)...)
+}
+
 //
-// metricsList returns the sequence to be printed after the financial statements
+// metricsList returns the sequence to be printed after the financial statements,
+// according to the statements layout
 //
-func metricsList(v map[uint32]float32) (metrics []metric) {
+func metricsList(layout int, v map[uint32]float32) []metric {
+	switch layout {
+	case layoutBank:
+		return bankMetrics(v)
+	case layoutInsurer:
+		return insurerMetrics(v)
+	}
+	return stdMetrics(v)
+}
+
+//
+// stdMetrics returns the metrics for non financial companies
+//
+func stdMetrics(v map[uint32]float32) []metric {
 	dividaBruta := v[p.DividaCirc] + v[p.DividaNCirc]
 	caixa := v[p.Caixa] + v[p.AplicFinanceiras]
 	dividaLiquida := dividaBruta - caixa
 	EBITDA := v[p.EBIT] - v[p.Deprec]
 	proventos := v[p.Dividendos] + v[p.JurosCapProp]
 
-	var roe float32
-	if v[p.LucLiq] > 0 && v[p.EquityAvg] > 0 {
-		roe = zeroIfNeg(safeDiv(v[p.LucLiq], v[p.EquityAvg]))
-	}
+	roe := avgROE(v)
 	var cg float32 = v[p.AtivoCirc] - v[p.PassivoCirc]
 	var st float32 = v[p.Caixa] + v[p.AplicFinanceiras] - (v[p.DividaCirc] + v[p.DividendosJCP] + v[p.DividendosMin])
 	var ncg float32 = cg - st
 
 	var lpa float32 = safeDiv(v[p.LucLiq]*v[p.Escala], v[p.Shares])
 
-	return []metric{
+	list := []metric{
 		{"Patrimônio Líquido", v[p.Equity], NUMBER, grpAccts},
 		{"", 0, EMPTY, grpAccts},
 
@@ -647,10 +989,10 @@ func metricsList(v map[uint32]float32) (metrics []metric) {
 		{"Lucro Líquido", v[p.LucLiq], NUMBER, grpAccts},
 		{"", 0, EMPTY, grpAccts},
 
-		{"LPA", lpa, INDEX, grpAccts},
-		{"VPA", safeDiv(v[p.Equity]*v[p.Escala], v[p.Shares]), INDEX, grpAccts},
-		{"P/L", safeDiv(v[p.Quote], lpa), INDEX, grpAccts},
-		{"Cotação", v[p.Quote], INDEX, grpAccts},
+		{"LPA", available(lpa, v[p.Shares]), INDEX, grpAccts},
+		{"VPA", available(safeDiv(v[p.Equity]*v[p.Escala], v[p.Shares]), v[p.Shares]), INDEX, grpAccts},
+		{"P/L", available(safeDiv(v[p.Quote], lpa), v[p.Quote], v[p.Shares]), INDEX, grpAccts},
+		{"Cotação", available(v[p.Quote], v[p.Quote]), INDEX, grpAccts},
 		{"", 0, EMPTY, grpAccts},
 
 		{"Marg. EBITDA", zeroIfNeg(safeDiv(EBITDA, v[p.Vendas])), PERCENT, grpAccts},
@@ -677,8 +1019,8 @@ func metricsList(v map[uint32]float32) (metrics []metric) {
 		{"Payout", zeroIfNeg(safeDiv(proventos, v[p.LucLiq])), PERCENT, grpAccts},
 		{"", 0, EMPTY, grpAccts},
 
-		{"Total de Ações", v[p.Shares], GENERAL, grpShares},
-		{"Free Float", v[p.FreeFloat], PERCENT, grpShares},
+		{"Total de Ações", available(v[p.Shares], v[p.Shares]), GENERAL, grpShares},
+		{"Free Float", available(v[p.FreeFloat], v[p.FreeFloat]), PERCENT, grpShares},
 		{"", 0, EMPTY, grpShares},
 
 		{"Liquidez Corrente (Ativo Circ./Passivo Circ.)", safeDiv(v[p.AtivoCirc], v[p.PassivoCirc]), INDEX, grpExtra},
@@ -697,6 +1039,48 @@ func metricsList(v map[uint32]float32) (metrics []metric) {
 		{"Saldo de Tesouraria (ST)", st, NUMBER, grpFleuriet},
 		{"Necessidade de Capital de Giro (NCG=CG-ST)", ncg, NUMBER, grpFleuriet},
 	}
+
+	return append(list, qualityMetrics(v)...)
+}
+
+// Per share metrics, in R$: converted to constant currency, if set, but not
+// to the display unit
+var perShareMetrics = map[string]bool{"LPA": true, "VPA": true}
+
+// display returns the metric value of 'year' converted to the display unit
+// (and to constant currency, if set), if it is a monetary value.
+func (r Report) display(m metric, year int) float32 {
+	switch {
+	case m.format == NUMBER:
+		return m.val * r.factor(year)
+	case m.format == INDEX && perShareMetrics[m.descr]:
+		if d, ok := r.deflators[year]; ok {
+			return m.val * d
+		}
+	}
+	return m.val
+}
+
+// factor returns the factor to convert the values of 'year' stored on the DB
+// to the display unit and, if set, to the constant currency of the base year.
+func (r Report) factor(year int) float32 {
+	f := r.unit.factor()
+	if d, ok := r.deflators[year]; ok {
+		f *= d
+	}
+	return f
+}
+
+// setDeflators sets the deflators from 'begin' to 'end', if the report is in
+// constant currency.
+func (r *Report) setDeflators(begin, end int) (err error) {
+	r.deflators, err = deflators(r.inflation, r.baseYear, begin, end)
+	return errors.Wrap(err, "valores reais")
+}
+
+// unitLabel returns the label of the unit used to display the values.
+func (r Report) unitLabel() string {
+	return unitLabel(r.unit, r.deflators, r.baseYear)
 }
 
 func zeroIfNeg(n float32) float32 {
@@ -706,6 +1090,18 @@ func zeroIfNeg(n float32) float32 {
 	return n
 }
 
+// available returns 'val', or NaN (not available) if any of the 'data' it
+// depends on is missing (zero), like the quote or the number of shares, so
+// it's not taken as a real zero.
+func available(val float32, data ...float32) float32 {
+	for _, d := range data {
+		if d == 0 {
+			return float32(math.NaN())
+		}
+	}
+	return val
+}
+
 func safeDiv(n, d float32) float32 {
 	if d == 0 {
 		return 0
@@ -763,7 +1159,8 @@ func (r Report) printCodesAndDescriptions(sheet *Sheet, accounts []accItems, col
 	row += 2
 	col++
 	// Metrics descriptions
-	for _, metric := range metricsList(nil) {
+	yoy := growthDescrs(r.layout)
+	for _, metric := range r.metrics(0, nil) {
 		if !r.groups[metric.group] {
 			continue
 		}
@@ -772,6 +1169,12 @@ func (r Report) printCodesAndDescriptions(sheet *Sheet, accounts []accItems, col
 			sheet.print(cell, &[]string{metric.descr}, RIGHT, false)
 		}
 		row++
+		if yoy[metric.descr] { // year-over-year change below the metric
+			delete(yoy, metric.descr)
+			cell := string(col) + strconv.Itoa(row)
+			sheet.print(cell, &[]string{"var. anual"}, RIGHT, false)
+			row++
+		}
 	}
 	lastMetricsRow := row - 1
 
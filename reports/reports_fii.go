@@ -6,10 +6,12 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/dude333/rapina"
 	"github.com/dude333/rapina/fetch"
 	"github.com/dude333/rapina/progress"
+	"github.com/pkg/errors"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -21,6 +23,7 @@ const (
 	Rtable = iota + 1
 	Rcsv
 	Rcsvrend
+	Rxlsx
 )
 
 // FIITerminal implements reports related to FII funds on the terminal.
@@ -231,12 +234,274 @@ func revMonthsFromToday(n int) []string {
 
 /* ------- MONTHLY REPORTS -------- */
 
+// NAV holds the net asset value per quota of a FII in a given month, joined
+// with the fund quote on the last business day of that month.
+type NAV struct {
+	Date    string  // Reference month (YYYY-MM)
+	NAV     float64 // Net asset value per quota (R$)
+	Quote   float64 // Quote on the last business day of the month (R$)
+	PVP     float64 // Price to book value (Quote/NAV)
+	Premium float64 // Premium (>0) or discount (<0) to NAV, in %
+	Growth  float64 // NAV growth over the previous month, in %
+}
+
+// NAVHistory returns the NAV, P/VP and premium/discount history of the FII
+// 'code' for 'n' months, starting from the latest released.
+func NAVHistory(fetchFII *fetch.FII, fetchStock *fetch.Stock, code string, n int) ([]NAV, error) {
+	monthly, err := fetchFII.Monthly(code, n)
+	if err != nil {
+		return nil, err
+	}
+
+	navs := make([]NAV, 0, len(*monthly))
+	for _, m := range *monthly {
+		nav := NAV{Date: m.Date, NAV: m.NAV}
+		q, err := monthQuote(fetchStock, code, m.Date)
+		if err != nil {
+			progress.ErrorMsg("Cotação de %s (%s): %v", code, m.Date, err)
+		}
+		nav.Quote = q
+		navs = append(navs, nav)
+	}
+
+	navMetrics(navs)
+
+	return navs, nil
+}
+
+// monthQuote returns the quote of 'code' on the last business day of the
+// 'monthYear' (YYYY-MM), trying the previous days in case of holidays.
+func monthQuote(fetchStock *fetch.Stock, code, monthYear string) (float64, error) {
+	date, err := rapina.LastBusinessDayOfMonth(monthYear)
+	if err != nil {
+		return 0, err
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0, err
+	}
+
+	for tries := 0; tries < 5; tries++ {
+		q, err := fetchStock.Quote(code, t.Format("2006-01-02"))
+		if err == nil && q > 0 {
+			return q, nil
+		}
+		t = t.AddDate(0, 0, -1)
+		if t.Format("2006-01") != monthYear {
+			break
+		}
+	}
+
+	return 0, errors.New("cotação não encontrada")
+}
+
+// lastQuote returns the latest quote of 'code', trying the previous days in
+// case of holidays.
+func lastQuote(fetchStock *fetch.Stock, code string) (float64, error) {
+	for n := 1; n <= 5; n++ {
+		q, err := fetchStock.Quote(code, rapina.LastBusinessDay(n))
+		if err == nil && q > 0 {
+			return q, nil
+		}
+	}
+	return 0, errors.New("cotação não encontrada")
+}
+
+// navMetrics calculates the P/VP, premium and NAV growth of 'navs', sorted
+// from the latest to the oldest month.
+func navMetrics(navs []NAV) {
+	for i := range navs {
+		if navs[i].NAV > 0 && navs[i].Quote > 0 {
+			navs[i].PVP = navs[i].Quote / navs[i].NAV
+			navs[i].Premium = 100 * (navs[i].PVP - 1)
+		}
+		if i+1 < len(navs) && navs[i+1].NAV > 0 {
+			navs[i].Growth = 100 * (navs[i].NAV/navs[i+1].NAV - 1)
+		}
+	}
+}
+
+// Monthly prints the NAV, P/VP and premium/discount history on terminal.
 func (t FIITerminal) Monthly(codes []string, n int) error {
+	// Header
+	if t.reportFormat == Rcsv {
+		fmt.Println("Código,Mês,VP/Cota,Cotação,P/VP,Ágio/Deságio,Var. VP")
+	}
+
+	history := make(map[string][]NAV, len(codes))
+	for _, code := range codes {
+		navs, err := NAVHistory(t.fetchFII, t.fetchStock, code, n)
+		if err != nil {
+			progress.ErrorMsg("%s: %v", code, err)
+			continue
+		}
+		history[code] = navs
+
+		var buf *strings.Builder
+		switch t.reportFormat {
+		case Rcsv:
+			buf = t.csvNAV(code, navs)
+		default:
+			buf = t.printNAV(code, navs)
+		}
+		fmt.Print(buf)
+	}
+
+	if t.reportFormat == Rtable && len(history) > 1 {
+		fmt.Print(t.printNAVSummary(codes, history))
+	}
+
+	return nil
+}
+
+func (t FIITerminal) printNAV(code string, navs []NAV) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	var max float64
+	for _, nav := range navs {
+		max = math.Max(max, math.Abs(nav.Premium))
+	}
+
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, code)
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "  MÊS         VP/COTA      COTAÇÃO    P/VP   VAR. VP  DESÁGIO|ÁGIO")
+	p.Fprintln(buf, "  -------  ----------   ----------   -----   -------  ------------")
+
+	for _, nav := range navs {
+		p.Fprintf(buf, "  %s  R$%8.2f   ", nav.Date, nav.NAV)
+		if nav.Quote > 0 {
+			p.Fprintf(buf, "R$%8.2f   %5.2f  ", nav.Quote, nav.PVP)
+		} else {
+			p.Fprintf(buf, "%10s   %5s  ", "-", "-")
+		}
+		p.Fprintf(buf, "%+7.2f%%  ", nav.Growth)
+		if nav.Quote > 0 {
+			p.Fprintf(buf, "%s %+.1f%%", premiumBar(nav.Premium, max, 6), nav.Premium)
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	return buf
+}
+
+// premiumBar returns a text bar centered on '|', growing to the left for
+// discounts and to the right for premiums, scaled by 'max' to 'width' chars.
+func premiumBar(premium, max float64, width int) string {
+	var n int
+	if max > 0 {
+		n = int(math.Round(math.Abs(premium) / max * float64(width)))
+	}
+	left := strings.Repeat(" ", width)
+	right := strings.Repeat(" ", width)
+	if premium < 0 {
+		left = strings.Repeat(" ", width-n) + strings.Repeat("■", n)
+	} else {
+		right = strings.Repeat("■", n) + strings.Repeat(" ", width-n)
+	}
+
+	return left + "|" + right
+}
+
+func (t FIITerminal) csvNAV(code string, navs []NAV) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+	for _, nav := range navs {
+		p.Fprintf(buf, `%s,%s,"%f",`, code, nav.Date, nav.NAV)
+		if nav.Quote > 0 {
+			p.Fprintf(buf, `"%f","%f","%f%%",`, nav.Quote, nav.PVP, nav.Premium)
+		} else {
+			buf.WriteString(`"","","",`)
+		}
+		p.Fprintf(buf, `"%f%%"`, nav.Growth)
+		buf.WriteByte('\n')
+	}
+
+	return buf
+}
+
+// printNAVSummary compares the latest P/VP and the NAV growth over the
+// period for all 'codes'.
+func (t FIITerminal) printNAVSummary(codes []string, history map[string][]NAV) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "RESUMO")
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "  CÓDIGO    MÊS         VP/COTA      COTAÇÃO    P/VP   VAR. VP PERÍODO")
+	p.Fprintln(buf, "  ------    -------  ----------   ----------   -----   ---------------")
+
+	for _, code := range codes {
+		navs, ok := history[code]
+		if !ok || len(navs) == 0 {
+			continue
+		}
+		last, first := navs[0], navs[len(navs)-1]
+		p.Fprintf(buf, "  %-6s    %s  R$%8.2f   ", code, last.Date, last.NAV)
+		if last.Quote > 0 {
+			p.Fprintf(buf, "R$%8.2f   %5.2f   ", last.Quote, last.PVP)
+		} else {
+			p.Fprintf(buf, "%10s   %5s   ", "-", "-")
+		}
+		if first.NAV > 0 {
+			p.Fprintf(buf, "%+7.2f%%", 100*(last.NAV/first.NAV-1))
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	return buf
+}
+
+// UpdateList downloads the list of FIIs listed on B3 and stores it on the DB.
+func (t FIITerminal) UpdateList() error {
+	n, err := t.fetchFII.UpdateList()
+	if err != nil {
+		return err
+	}
+	progress.Status("%d FIIs armazenados", n)
+	return nil
+}
+
+// SegmentCodes returns the trading codes of all FIIs from 'segment'.
+func (t FIITerminal) SegmentCodes(segment string) ([]string, error) {
+	funds, err := t.fetchFII.Funds(segment)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FIIs do segmento %s", segment)
+	}
+	codes := make([]string, len(funds))
+	for i, f := range funds {
+		codes[i] = f.Code
+	}
+	return codes, nil
+}
+
+// List prints the FIIs stored on the DB, filtered by 'segment' if not empty.
+func (t FIITerminal) List(segment string) error {
+	funds, err := t.fetchFII.Funds(segment)
+	if err != nil {
+		return errors.Wrap(err, "lista de FIIs")
+	}
+
+	if t.reportFormat == Rcsv {
+		fmt.Println("Código,Nome,CNPJ,Administrador,Segmento")
+		for _, f := range funds {
+			fmt.Printf("%s,%q,%s,%q,%s\n", f.Code, f.Name, f.CNPJ, f.Administrator, f.Segment)
+		}
+		return nil
+	}
 
-	for _, c := range codes {
-		ii, err := t.fetchFII.MonthlyReportIDs(c, n)
-		progress.Status("indexes: %v (err: %v)", ii, err)
+	fmt.Println(line)
+	fmt.Println("  CÓDIGO  SEGMENTO          CNPJ                NOME")
+	fmt.Println("  ------  ---------------   ------------------  ----------------")
+	for _, f := range funds {
+		fmt.Printf("  %-6s  %-15s   %-18s  %s\n", f.Code, f.Segment, f.CNPJ, f.Name)
 	}
+	fmt.Println(line)
+	fmt.Printf("  %d FIIs\n", len(funds))
 
 	return nil
 }
@@ -0,0 +1,46 @@
+package reports
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_navMetrics(t *testing.T) {
+	navs := []NAV{
+		{Date: "2021-03", NAV: 110, Quote: 99},
+		{Date: "2021-02", NAV: 100, Quote: 120},
+		{Date: "2021-01", NAV: 100},
+	}
+	want := []NAV{
+		{Date: "2021-03", NAV: 110, Quote: 99, PVP: 0.9, Premium: -10, Growth: 10},
+		{Date: "2021-02", NAV: 100, Quote: 120, PVP: 1.2, Premium: 20, Growth: 0},
+		{Date: "2021-01", NAV: 100},
+	}
+
+	navMetrics(navs)
+
+	const e = 1e-9
+	for i := range want {
+		if math.Abs(navs[i].PVP-want[i].PVP) > e ||
+			math.Abs(navs[i].Premium-want[i].Premium) > e ||
+			math.Abs(navs[i].Growth-want[i].Growth) > e {
+			t.Errorf("navMetrics()[%d] = %+v, want %+v", i, navs[i], want[i])
+		}
+	}
+}
+
+func Test_premiumBar(t *testing.T) {
+	tests := []struct {
+		premium, max float64
+		want         string
+	}{
+		{10, 10, "   |■■■"},
+		{-4, 12, "  ■|   "},
+		{0, 0, "   |   "},
+	}
+	for _, tt := range tests {
+		if got := premiumBar(tt.premium, tt.max, 3); got != tt.want {
+			t.Errorf("premiumBar(%v, %v) = %q, want %q", tt.premium, tt.max, got, tt.want)
+		}
+	}
+}
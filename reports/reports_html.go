@@ -1 +1,380 @@
 package reports
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Tearsheet limits
+const (
+	tearsheetYears  = 5 // last years shown on the metrics table and charts
+	tearsheetCharts = 4 // number of charts
+)
+
+// Tearsheet is the one-page summary of a company: the header, the metrics
+// of the last years, the growth (CAGR) of the main accounts and the charts.
+// The values are formatted to be printed (HTML or PDF).
+type Tearsheet struct {
+	Company string
+	CNPJ    string
+	Ticker  string
+	Segment string
+	Unit    string
+	Years   []string       // metrics table columns, e.g., "2021", "TTM/2022"
+	Metrics []TearsheetRow // a row without description is a separator
+	Periods []string       // growth table columns, e.g., "1 ano", "3 anos"
+	Growth  []TearsheetRow
+	Charts  []TearsheetChart
+}
+
+// TearsheetRow is a row of a tearsheet table. A row with description and
+// without values is a section title.
+type TearsheetRow struct {
+	Descr  string
+	Values []string
+}
+
+// TearsheetChart contains the values of a chart, by year.
+type TearsheetChart struct {
+	Title  string
+	Labels []string
+	Series []TearsheetSeries
+
+	kind   string // chartCol or chartLine
+	format int
+}
+
+// TearsheetSeries is a series of a chart.
+type TearsheetSeries struct {
+	Name   string
+	Values []float64
+}
+
+// Tearsheet returns the tearsheet of the 'company', with the values
+// converted to 'unit'. The metrics are the ones selected on the report (by
+// default, the main metrics, followed by the custom metrics) or defined on
+// the template.
+func (r Report) Tearsheet(company string, unit Unit) (*Tearsheet, error) {
+	return r.tearsheet(company, unit, tearsheetYears)
+}
+
+// tearsheet returns the tearsheet of the 'company' with the last 'n' years
+// (all years if zero).
+func (r Report) tearsheet(company string, unit Unit, n int) (*Tearsheet, error) {
+	if r.spcfctnCd == "" {
+		r.spcfctnCd = "ON"
+	}
+	if err := r.setCompanyAndTicker(company, r.spcfctnCd); err != nil {
+		return nil, fmt.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+	r.unit = unit
+	if r.groups == nil {
+		r.groups = map[int]bool{grpAccts: true, grpCustom: true, grpTemplate: true}
+	}
+
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.setDeflators(begin, end); err != nil {
+		return nil, err
+	}
+
+	t := &Tearsheet{Company: r.company, CNPJ: r.cnpj, Ticker: r.code, Unit: r.unitLabel()}
+	if _, segment, err := parsers.FromSector(r.db, r.company); err == nil {
+		t.Segment = segment
+	}
+
+	// Account values of all years, used by the growth and the formulas
+	history := make(yearValues)
+	var growth []AccountSeries
+	var years []int
+	for y := begin; y <= end; y++ {
+		values, err := r.accountsValues(y)
+		if err != nil {
+			return nil, err
+		}
+		if sum(values) == 0 {
+			continue
+		}
+		history[y] = values
+		growth = r.addGrowthValues(growth, y, values)
+		years = append(years, y)
+	}
+	if len(years) == 0 {
+		return nil, errors.Errorf("nenhum demonstrativo de '%s' no banco de dados", r.company)
+	}
+	if n > 0 && len(years) > n {
+		years = years[len(years)-n:]
+	}
+
+	lastYear, isTTM, _ := r.lastYear(r.cid)
+	metrics := make([][]metric, len(years))
+	for i, y := range years {
+		title := strconv.Itoa(y)
+		if y == lastYear && isTTM {
+			title = "TTM/" + title
+		}
+		t.Years = append(t.Years, title)
+		for _, m := range r.metrics(y, history) {
+			if r.groups[m.group] {
+				metrics[i] = append(metrics[i], m)
+			}
+		}
+	}
+
+	t.Metrics = r.tearsheetMetrics(years, metrics)
+	t.Periods, t.Growth = tearsheetGrowth(growth)
+	t.Charts = r.tearsheetCharts(years, metrics)
+
+	return t, nil
+}
+
+// tearsheetMetrics returns the rows of the metrics table. The list of
+// metrics is the same on all 'years'. Repeated and trailing separators are
+// removed.
+func (r Report) tearsheetMetrics(years []int, metrics [][]metric) []TearsheetRow {
+	var rows []TearsheetRow
+	for i, m := range metrics[0] {
+		if m.format == EMPTY {
+			if m.descr != "" {
+				rows = append(rows, TearsheetRow{Descr: m.descr}) // section title
+			} else if len(rows) > 0 && rows[len(rows)-1].Descr != "" {
+				rows = append(rows, TearsheetRow{})
+			}
+			continue
+		}
+		row := TearsheetRow{Descr: m.descr}
+		for j, y := range years {
+			row.Values = append(row.Values, r.formatMetric(metrics[j][i], y))
+		}
+		rows = append(rows, row)
+	}
+	for len(rows) > 0 && rows[len(rows)-1].Descr == "" {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// formatMetric returns the metric value converted to the display unit and
+// formatted, or "-" if not available.
+func (r Report) formatMetric(m metric, year int) string {
+	if m.format == EMPTY || !valid(m.val) {
+		return "-"
+	}
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+	val := r.display(m, year)
+	switch m.format {
+	case PERCENT:
+		return pt.Sprintf("%.1f%%", 100*val)
+	case INDEX:
+		return pt.Sprintf("%.2f", val)
+	}
+	return pt.Sprintf("%.0f", val)
+}
+
+// tearsheetGrowth returns the periods and the rows of the growth table,
+// with the CAGR of the 'series' (sign changes are flagged with arrows).
+func tearsheetGrowth(series []AccountSeries) ([]string, []TearsheetRow) {
+	periods := make([]string, len(growthPeriods))
+	for i, n := range growthPeriods {
+		periods[i] = strconv.Itoa(n) + " anos"
+		if n == 1 {
+			periods[i] = "1 ano"
+		}
+	}
+
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+	rows := make([]TearsheetRow, len(series))
+	for i, s := range series {
+		rows[i].Descr = s.Descr
+		for _, n := range growthPeriods {
+			v := "-"
+			switch rate, flag := s.cagr(n); {
+			case rate != nil:
+				v = pt.Sprintf("%.1f%%", 100**rate)
+			case flag != "":
+				v = flag
+			}
+			rows[i].Values = append(rows[i].Values, v)
+		}
+	}
+
+	return periods, rows
+}
+
+// tearsheetCharts returns the first charts of companyChartList with values
+// available.
+func (r Report) tearsheetCharts(years []int, metrics [][]metric) []TearsheetChart {
+	labels := make([]string, len(years))
+	for i, y := range years {
+		labels[i] = strconv.Itoa(y)
+	}
+
+	var charts []TearsheetChart
+	for _, c := range companyChartList {
+		ch := TearsheetChart{Title: c.title, Labels: labels, kind: c.kind}
+		for _, descr := range c.metrics {
+			s := TearsheetSeries{Name: descr}
+			found := false
+			for i, y := range years {
+				var val float64
+				for _, m := range metrics[i] {
+					if m.descr == descr && m.format != EMPTY {
+						found = true
+						if !math.IsNaN(float64(m.val)) {
+							val = float64(r.display(m, y))
+						}
+						ch.format = m.format
+						break
+					}
+				}
+				s.Values = append(s.Values, val)
+			}
+			if found {
+				ch.Series = append(ch.Series, s)
+			}
+		}
+		if len(ch.Series) == 0 {
+			continue // e.g., EBITDA on banks
+		}
+		if charts = append(charts, ch); len(charts) == tearsheetCharts {
+			break
+		}
+	}
+
+	return charts
+}
+
+// SVG returns the chart as an inline SVG image.
+func (ch TearsheetChart) SVG() template.HTML {
+	const w, h = 360, 200
+	c := newSVGCanvas(w, h)
+	drawChart(c, ch, 0, 0, w, h)
+	return template.HTML(c.String())
+}
+
+// HTML writes the tearsheet as a self-contained HTML page.
+func (t *Tearsheet) HTML(w io.Writer) error {
+	tmpl, err := template.New("tearsheet").Funcs(template.FuncMap{
+		"negative": func(s string) bool { return strings.HasPrefix(s, "-") && s != "-" },
+		"inc":      func(n int) int { return n + 1 },
+	}).Parse(tearsheetHTML)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, t)
+}
+
+// ReportToHTML saves the tearsheet of the company as a HTML file.
+func ReportToHTML(parms map[string]interface{}) error {
+	return saveTearsheet(parms, (*Tearsheet).HTML)
+}
+
+// saveTearsheet saves the tearsheet of the company set on 'parms' on the
+// file set on 'parms', using the 'write' function (HTML or PDF).
+func saveTearsheet(parms map[string]interface{}, write func(*Tearsheet, io.Writer) error) error {
+	r, err := New(parms)
+	if err != nil {
+		return err
+	}
+
+	t, err := r.Tearsheet(r.company, r.unit)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(r.filename)
+	if err != nil {
+		return err
+	}
+	if err := write(t, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	r.printf("[√] Dados salvos em %s\n", r.filename)
+	return nil
+}
+
+const tearsheetHTML = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>{{.Company}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 12px; color: #333; max-width: 780px; margin: 24px auto; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+h2 { font-size: 13px; margin: 18px 0 6px; border-bottom: 1px solid #4472C4; color: #4472C4; }
+.info { color: #666; }
+.info span { margin-right: 16px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 2px 6px; text-align: right; white-space: nowrap; }
+th { border-bottom: 1px solid #999; }
+th:first-child, td:first-child { text-align: left; }
+tr:nth-child(even) td { background: #F3F6FB; }
+tr.sep td { background: none; height: 6px; padding: 0; }
+tr.title td { font-weight: bold; background: none; }
+.neg { color: #C00000; }
+.charts { display: flex; flex-wrap: wrap; justify-content: space-between; }
+.charts svg { width: 49%; height: auto; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>{{.Company}}</h1>
+<div class="info">
+<span>CNPJ: {{.CNPJ}}</span>
+{{- if .Ticker}}<span>Código: {{.Ticker}}</span>{{end}}
+{{- if .Segment}}<span>Segmento: {{.Segment}}</span>{{end}}
+</div>
+
+<h2>INDICADORES ({{.Unit}})</h2>
+<table>
+<tr><th></th>{{range .Years}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Metrics}}
+{{- if not .Descr}}
+<tr class="sep"><td colspan="{{len $.Years | inc}}"></td></tr>
+{{- else if not .Values}}
+<tr class="title"><td colspan="{{len $.Years | inc}}">{{.Descr}}</td></tr>
+{{- else}}
+<tr><td>{{.Descr}}</td>{{range .Values}}<td{{if negative .}} class="neg"{{end}}>{{.}}</td>{{end}}</tr>
+{{- end}}
+{{- end}}
+</table>
+
+{{- if .Growth}}
+<h2>CRESCIMENTO (CAGR)</h2>
+<table>
+<tr><th></th>{{range .Periods}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Growth}}
+<tr><td>{{.Descr}}</td>{{range .Values}}<td{{if negative .}} class="neg"{{end}}>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+<p class="info">⇧ ⇩ mudança de sinal (crescimento indefinido)</p>
+{{- end}}
+
+{{- if .Charts}}
+<h2>GRÁFICOS</h2>
+<div class="charts">
+{{- range .Charts}}
+{{.SVG}}
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`
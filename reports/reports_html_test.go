@@ -0,0 +1,160 @@
+package reports
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dude333/rapina"
+)
+
+func TestTearsheet(t *testing.T) {
+	db := companiesDB(t)
+	dir := t.TempDir()
+	r, err := New(map[string]interface{}{"db": db, "dataDir": dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO stock_codes VALUES ('BETA3', 'BETA S.A.', 'ON', '')`); err != nil {
+		t.Fatal(err)
+	}
+	date := rapina.LastBusinessDayOfYear(2021)
+	if _, err := db.Exec(`INSERT INTO stock_quotes (stock, date, close) VALUES ('BETA3', ?, 10)`, date); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := r.Tearsheet("BETA", UnitThousand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Company != "BETA S.A." || ts.CNPJ != "2" || ts.Ticker != "BETA3" || ts.Unit != "R$ mil" {
+		t.Errorf("unexpected header: %+v", ts)
+	}
+	if len(ts.Years) != 1 || ts.Years[0] != "2021" {
+		t.Errorf("years = %v, want [2021]", ts.Years)
+	}
+	if len(ts.Metrics) == 0 || ts.Metrics[0].Descr != "Patrimônio Líquido" || ts.Metrics[0].Values[0] != "200" {
+		t.Errorf("unexpected metrics: %+v", ts.Metrics)
+	}
+	for _, row := range ts.Metrics {
+		if row.Descr == "Cotação" && row.Values[0] != "10,00" {
+			t.Errorf("Cotação = %v, want 10,00", row.Values)
+		}
+	}
+	if len(ts.Growth) == 0 || ts.Growth[0].Values[0] != "-" {
+		t.Errorf("unexpected growth: %+v", ts.Growth)
+	}
+
+	var buf bytes.Buffer
+	if err := ts.HTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1>BETA S.A.</h1>", "Código: BETA3", "INDICADORES (R$ mil)", "<th>2021</th>", "<svg"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q on HTML", want)
+		}
+	}
+
+	buf.Reset()
+	if err := ts.PDF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	checkPDF(t, buf.Bytes())
+
+	if _, err := r.Tearsheet("XPTO", UnitThousand); err == nil {
+		t.Error("expected error for unknown company")
+	}
+}
+
+func TestReportToPDF(t *testing.T) {
+	db := companiesDB(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "acme.pdf")
+	parms := map[string]interface{}{"db": db, "dataDir": dir, "company": "ACME", "filename": file, "quiet": true}
+	if _, err := New(parms); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO stock_codes VALUES ('ACME3', 'ACME S.A.', 'ON', '')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReportToPDF(parms); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPDF(t, b)
+}
+
+func Test_chartTicks(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		want     []float64
+	}{
+		{0, 100, []float64{0, 50, 100}},
+		{-30, 80, []float64{-50, 0, 50, 100}},
+		{-3, 14, []float64{-5, 0, 5, 10, 15}},
+		{0, 0.27, []float64{0, 0.1, 0.2, 0.3}},
+		{0, 0, []float64{0, 0.5, 1}},
+	}
+	for _, tt := range tests {
+		got := chartTicks(tt.min, tt.max)
+		if len(got) != len(tt.want) {
+			t.Errorf("chartTicks(%v, %v) = %v, want %v", tt.min, tt.max, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if diff := got[i] - tt.want[i]; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("chartTicks(%v, %v) = %v, want %v", tt.min, tt.max, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func Test_pdfString(t *testing.T) {
+	if got, want := pdfString(`Ação (ON) \ ⇧`), "A\xe7\xe3o \\(ON\\) \\\\ ?"; got != want {
+		t.Errorf("pdfString = %q, want %q", got, want)
+	}
+}
+
+func Test_textWidth(t *testing.T) {
+	if got := textWidth("Ação", 10, false); got != textWidth("Acao", 10, false) || got != 22.79 {
+		t.Errorf("textWidth = %v, want 22.79", got)
+	}
+	if textWidth("R", 10, true) <= textWidth("r", 10, true) {
+		t.Error("bold R should be wider than r")
+	}
+}
+
+// checkPDF checks the PDF structure: header, trailer and the offsets of the
+// objects on the cross-reference table.
+func checkPDF(t *testing.T, b []byte) {
+	t.Helper()
+	s := string(b)
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatalf("invalid PDF header/trailer")
+	}
+	i := strings.Index(s, "xref\n")
+	if i < 0 {
+		t.Fatal("xref not found")
+	}
+	lines := strings.Split(s[i:], "\n")
+	for n, line := range lines[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		off, err := strconv.Atoi(line[:10])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if obj := strconv.Itoa(n+1) + " 0 obj"; !strings.HasPrefix(s[off:], obj) {
+			t.Errorf("offset %d: want %q, got %q", off, obj, s[off:off+10])
+		}
+	}
+}
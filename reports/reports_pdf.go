@@ -0,0 +1,133 @@
+package reports
+
+import (
+	"io"
+	"strings"
+)
+
+// Layout of the tearsheet on the PDF pages, in points
+const (
+	pdfMargin   = 36.0
+	pdfRowH     = 9.0
+	pdfFont     = 7.5
+	pdfColW     = 62.0
+	pdfChartH   = 135.0
+	pdfChartGap = 12.0
+)
+
+// pdfWriter draws the tearsheet on the PDF pages, adding a new page when the
+// next block does not fit on the current page.
+type pdfWriter struct {
+	doc  pdfDoc
+	page *pdfPage
+	y    float64
+}
+
+// need starts a new page if the current one has less than 'h' points left.
+func (pw *pdfWriter) need(h float64) {
+	if pw.page == nil || pw.y+h > pageHeight-pdfMargin {
+		pw.page = pw.doc.newPage()
+		pw.y = pdfMargin
+	}
+}
+
+// section prints a section title.
+func (pw *pdfWriter) section(title string) {
+	pw.need(2*pdfRowH + 4)
+	pw.y += 12
+	pw.page.text(pdfMargin, pw.y, 9, anchorStart, true, "#4472C4", title)
+	pw.page.line(pdfMargin, pw.y+3, pageWidth-pdfMargin, pw.y+3, 0.75, "#4472C4")
+	pw.y += 6
+}
+
+// table prints a table with the 'header' and the 'rows', with the values
+// aligned to the right.
+func (pw *pdfWriter) table(header []string, rows []TearsheetRow) {
+	right := pageWidth - pdfMargin
+	colX := func(i int) float64 { return right - float64(len(header)-1-i)*pdfColW }
+
+	pw.need(2 * pdfRowH)
+	pw.y += pdfRowH
+	for i, h := range header {
+		if h == "" {
+			continue
+		}
+		pw.page.text(colX(i), pw.y, pdfFont, anchorEnd, true, "#333333", h)
+	}
+	pw.page.line(pdfMargin, pw.y+2.5, right, pw.y+2.5, 0.5, "#999999")
+
+	for i, row := range rows {
+		if row.Descr == "" {
+			pw.y += pdfRowH / 2
+			continue
+		}
+		pw.need(pdfRowH)
+		if i%2 == 1 && len(row.Values) > 0 {
+			pw.page.rect(pdfMargin, pw.y+2.5, right-pdfMargin, pdfRowH, "#F3F6FB")
+		}
+		pw.y += pdfRowH
+		pw.page.text(pdfMargin+2, pw.y, pdfFont, anchorStart, len(row.Values) == 0, "#333333", row.Descr)
+		for j, v := range row.Values {
+			color := "#333333"
+			if strings.HasPrefix(v, "-") && v != "-" {
+				color = "#C00000"
+			}
+			pw.page.text(colX(j), pw.y, pdfFont, anchorEnd, false, color, pdfFlags.Replace(v))
+		}
+	}
+}
+
+// pdfFlags replaces the sign change flags, not available on the PDF fonts.
+var pdfFlags = strings.NewReplacer("⇧", "(+)", "⇩", "(-)")
+
+// PDF writes the tearsheet as a PDF document (A4).
+func (t *Tearsheet) PDF(w io.Writer) error {
+	pw := &pdfWriter{}
+	pw.need(pageHeight)
+
+	// Header
+	pw.y += 16
+	pw.page.text(pdfMargin, pw.y, 16, anchorStart, true, "#333333", t.Company)
+	info := []string{"CNPJ: " + t.CNPJ}
+	if t.Ticker != "" {
+		info = append(info, "Código: "+t.Ticker)
+	}
+	if t.Segment != "" {
+		info = append(info, "Segmento: "+t.Segment)
+	}
+	pw.y += 12
+	pw.page.text(pdfMargin, pw.y, 8, anchorStart, false, "#666666", strings.Join(info, "    "))
+
+	pw.section("INDICADORES (" + t.Unit + ")")
+	pw.table(append([]string{""}, t.Years...), t.Metrics)
+
+	if len(t.Growth) > 0 {
+		pw.section("CRESCIMENTO (CAGR)")
+		pw.table(append([]string{""}, t.Periods...), t.Growth)
+		pw.y += pdfRowH
+		pw.page.text(pdfMargin+2, pw.y, 6, anchorStart, false, "#666666", "(+) (-) mudança de sinal (crescimento indefinido)")
+	}
+
+	if len(t.Charts) > 0 {
+		pw.section("GRÁFICOS")
+		chartW := (pageWidth - 2*pdfMargin - pdfChartGap) / 2
+		for i, ch := range t.Charts {
+			if i%2 == 0 {
+				pw.need(pdfChartH + 6)
+				pw.y += 6
+			}
+			x := pdfMargin + float64(i%2)*(chartW+pdfChartGap)
+			drawChart(pw.page, ch, x, pw.y, chartW, pdfChartH)
+			if i%2 == 1 || i == len(t.Charts)-1 {
+				pw.y += pdfChartH
+			}
+		}
+	}
+
+	return pw.doc.write(w)
+}
+
+// ReportToPDF saves the tearsheet of the company as a PDF file.
+func ReportToPDF(parms map[string]interface{}) error {
+	return saveTearsheet(parms, (*Tearsheet).PDF)
+}
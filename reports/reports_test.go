@@ -68,7 +68,7 @@ func TestMetricsList(t *testing.T) {
 	for x := uint32(p.Caixa); x <= uint32(p.Dividendos); x++ {
 		v[x] = float32(x) * 123456
 	}
-	l := metricsList(v)
+	l := metricsList(layoutStd, v)
 
 	seq := []float32{
 		v[p.Equity],
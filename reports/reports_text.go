@@ -0,0 +1,198 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal colors
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiRed   = "\033[31m"
+)
+
+// Text table limits
+const (
+	textDescrMax = 40  // maximum width of the descriptions
+	textWidthMin = 60  // minimum table width
+	textWidthStd = 100 // table width if the terminal width is unknown
+)
+
+// printTearsheet prints the metrics of the tearsheet as a compact table for
+// the terminal, with the most recent years that fit on 'width' characters,
+// followed by the growth (CAGR). If 'color' is set, the negative values are
+// printed in red.
+func printTearsheet(t *Tearsheet, width int, color bool) *strings.Builder {
+	buf := &strings.Builder{}
+	if width <= 0 {
+		width = textWidthStd
+	} else if width < textWidthMin {
+		width = textWidthMin
+	}
+
+	bold := func(s string) string {
+		if color {
+			return ansiBold + s + ansiReset
+		}
+		return s
+	}
+	cell := func(v string, w int) string {
+		s := fmt.Sprintf("%*s", w, v)
+		if color && strings.HasPrefix(v, "-") && v != "-" {
+			return ansiRed + s + ansiReset
+		}
+		return s
+	}
+
+	fmt.Fprintln(buf, strings.Repeat("-", width))
+	fmt.Fprintln(buf, bold(t.Company+" ("+t.Unit+")"))
+	if info := tearsheetInfo(t, "   "); info != "" {
+		fmt.Fprintln(buf, info)
+	}
+	fmt.Fprintln(buf, strings.Repeat("-", width))
+
+	// Metrics, with the years that fit on the width
+	descrW := textColWidth(t.Metrics, t.Growth)
+	colW := 2 + maxWidth(t.Years, t.Metrics)
+	first := 0
+	if n := (width - descrW) / colW; n < len(t.Years) {
+		first = len(t.Years) - n
+		if n < 1 {
+			first = len(t.Years) - 1
+		}
+	}
+
+	fmt.Fprintf(buf, "%-*s", descrW, "")
+	for _, y := range t.Years[first:] {
+		fmt.Fprint(buf, bold(fmt.Sprintf("%*s", colW, y)))
+	}
+	buf.WriteByte('\n')
+	for _, row := range t.Metrics {
+		switch {
+		case row.Descr == "":
+			buf.WriteByte('\n')
+			continue
+		case len(row.Values) == 0:
+			fmt.Fprintln(buf, bold(row.Descr))
+			continue
+		}
+		fmt.Fprintf(buf, "%-*s", descrW, truncate(row.Descr, descrW-1))
+		for _, v := range row.Values[first:] {
+			fmt.Fprint(buf, cell(v, colW))
+		}
+		buf.WriteByte('\n')
+	}
+
+	// Growth
+	if len(t.Growth) > 0 {
+		colW := 2 + maxWidth(t.Periods, t.Growth)
+		fmt.Fprintf(buf, "\n%s", bold(fmt.Sprintf("%-*s", descrW, "CRESCIMENTO (CAGR)")))
+		for _, p := range t.Periods {
+			fmt.Fprint(buf, bold(fmt.Sprintf("%*s", colW, p)))
+		}
+		buf.WriteByte('\n')
+		for _, row := range t.Growth {
+			fmt.Fprintf(buf, "%-*s", descrW, truncate(row.Descr, descrW-1))
+			for _, v := range row.Values {
+				fmt.Fprint(buf, cell(v, colW))
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf
+}
+
+// markdownTearsheet prints the metrics and the growth of the tearsheet as
+// Markdown tables.
+func markdownTearsheet(t *Tearsheet) *strings.Builder {
+	buf := &strings.Builder{}
+	esc := strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`)
+
+	table := func(title string, header []string, rows []TearsheetRow) {
+		fmt.Fprintf(buf, "| %s |", esc.Replace(title))
+		for _, h := range header {
+			fmt.Fprintf(buf, " %s |", h)
+		}
+		fmt.Fprintf(buf, "\n|:---|%s\n", strings.Repeat("---:|", len(header)))
+		for _, row := range rows {
+			switch {
+			case row.Descr == "":
+				continue
+			case len(row.Values) == 0:
+				fmt.Fprintf(buf, "| **%s** |%s\n", esc.Replace(row.Descr), strings.Repeat(" |", len(header)))
+				continue
+			}
+			fmt.Fprintf(buf, "| %s |", esc.Replace(row.Descr))
+			for _, v := range row.Values {
+				fmt.Fprintf(buf, " %s |", v)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	fmt.Fprintf(buf, "### %s\n\n", esc.Replace(t.Company))
+	if info := tearsheetInfo(t, " · "); info != "" {
+		fmt.Fprintf(buf, "%s\n\n", esc.Replace(info))
+	}
+	table("Indicadores ("+t.Unit+")", t.Years, t.Metrics)
+	if len(t.Growth) > 0 {
+		buf.WriteByte('\n')
+		table("Crescimento (CAGR)", t.Periods, t.Growth)
+	}
+
+	return buf
+}
+
+// tearsheetInfo returns the CNPJ, the trading code and the segment of the
+// company, separated by 'sep'.
+func tearsheetInfo(t *Tearsheet, sep string) string {
+	var info []string
+	if t.CNPJ != "" {
+		info = append(info, "CNPJ: "+t.CNPJ)
+	}
+	if t.Ticker != "" {
+		info = append(info, "Código: "+t.Ticker)
+	}
+	if t.Segment != "" {
+		info = append(info, "Segmento: "+t.Segment)
+	}
+	return strings.Join(info, sep)
+}
+
+// textColWidth returns the width of the descriptions column, up to
+// textDescrMax.
+func textColWidth(tables ...[]TearsheetRow) int {
+	w := len("CRESCIMENTO (CAGR)")
+	for _, rows := range tables {
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row.Descr); n > w && len(row.Values) > 0 {
+				w = n
+			}
+		}
+	}
+	if w+1 > textDescrMax {
+		return textDescrMax
+	}
+	return w + 1
+}
+
+// maxWidth returns the width of the longest header or value.
+func maxWidth(header []string, rows []TearsheetRow) int {
+	w := 0
+	for _, h := range header {
+		if n := utf8.RuneCountInString(h); n > w {
+			w = n
+		}
+	}
+	for _, row := range rows {
+		for _, v := range row.Values {
+			if n := utf8.RuneCountInString(v); n > w {
+				w = n
+			}
+		}
+	}
+	return w
+}
@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"strings"
+	"testing"
+)
+
+func testTearsheet() *Tearsheet {
+	return &Tearsheet{
+		Company: "ACME S.A.",
+		CNPJ:    "1",
+		Ticker:  "ACME3",
+		Unit:    "R$ mil",
+		Years:   []string{"2019", "2020", "2021", "TTM/2022"},
+		Metrics: []TearsheetRow{
+			{Descr: "Receita Líquida", Values: []string{"1.000", "1.100", "1.200", "1.300"}},
+			{},
+			{Descr: "Lucro Líquido", Values: []string{"100", "-50", "-", "150"}},
+		},
+		Periods: []string{"1 ano", "3 anos"},
+		Growth:  []TearsheetRow{{Descr: "Receita Líquida", Values: []string{"8,3%", "⇧"}}},
+	}
+}
+
+func Test_printTearsheet(t *testing.T) {
+	ts := testTearsheet()
+	ts.Years = append([]string{"2017", "2018"}, ts.Years...)
+	for i := range ts.Metrics {
+		if ts.Metrics[i].Descr != "" {
+			ts.Metrics[i].Values = append([]string{"1", "2"}, ts.Metrics[i].Values...)
+		}
+	}
+
+	got := printTearsheet(ts, 100, false).String()
+	for _, want := range []string{
+		"ACME S.A. (R$ mil)\nCNPJ: 1   Código: ACME3\n",
+		"                         2017      2018      2019      2020      2021  TTM/2022\n",
+		"Receita Líquida             1         2     1.000     1.100     1.200     1.300\n\n",
+		"Lucro Líquido               1         2       100       -50         -       150\n",
+		"CRESCIMENTO (CAGR)    1 ano  3 anos\nReceita Líquida        8,3%       ⇧\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\033") {
+		t.Error("unexpected colors")
+	}
+
+	// Only the last years that fit
+	got = printTearsheet(ts, 60, false).String()
+	if strings.Contains(got, "2018") || !strings.Contains(got, "2019") || !strings.Contains(got, "TTM/2022") {
+		t.Errorf("expected only the last years:\n%s", got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if n := len([]rune(line)); n > 60 {
+			t.Errorf("line with %d characters: %q", n, line)
+		}
+	}
+
+	// Negative values in red
+	got = printTearsheet(ts, 100, true).String()
+	if !strings.Contains(got, ansiRed+"       -50"+ansiReset) {
+		t.Errorf("negative value not colored:\n%q", got)
+	}
+	if strings.Contains(got, ansiRed+"         -"+ansiReset) {
+		t.Error("missing value should not be colored")
+	}
+}
+
+func Test_markdownTearsheet(t *testing.T) {
+	want := "### ACME S.A.\n\n" +
+		"CNPJ: 1 · Código: ACME3\n\n" +
+		"| Indicadores (R$ mil) | 2019 | 2020 | 2021 | TTM/2022 |\n" +
+		"|:---|---:|---:|---:|---:|\n" +
+		"| Receita Líquida | 1.000 | 1.100 | 1.200 | 1.300 |\n" +
+		"| Lucro Líquido | 100 | -50 | - | 150 |\n" +
+		"\n" +
+		"| Crescimento (CAGR) | 1 ano | 3 anos |\n" +
+		"|:---|---:|---:|\n" +
+		"| Receita Líquida | 8,3% | ⇧ |\n"
+
+	if got := markdownTearsheet(testTearsheet()).String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+package reports
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Restatement contains the value of an account as originally filed and as
+// restated on the latest version of the annual report (DFP).
+type Restatement struct {
+	Company      string
+	Year         int
+	Account      string // CD_CONTA
+	Descr        string // DS_CONTA
+	Code         uint32 // bookkeeping account code
+	FirstVersion int
+	LastVersion  int
+	Original     float64
+	Restated     float64
+}
+
+// Change returns the relative change from the original to the restated
+// value, or NaN if the original value is zero.
+func (r Restatement) Change() float64 {
+	if r.Original == 0 {
+		return math.NaN()
+	}
+	return (r.Restated - r.Original) / math.Abs(r.Original)
+}
+
+// RestatementSummary contains the restatements of a company on a year.
+type RestatementSummary struct {
+	Company      string
+	Year         int
+	FirstVersion int
+	LastVersion  int
+	Accounts     int     // number of accounts changed
+	NetIncome    float64 // relative change of the net income (NaN if unchanged or not comparable)
+	Equity       float64 // relative change of the equity (NaN if unchanged or not comparable)
+	MaxChange    float64 // largest relative change (absolute value)
+}
+
+// Restatements returns the accounts whose values changed between the first
+// and the last version of the annual reports stored on the DB, for the
+// companies with names containing 'company' (all companies if empty).
+// Accounts are matched by statement type and code, since statements like
+// DFC_MD and DFC_MI share the same codes.
+func Restatements(db *sql.DB, company string) ([]Restatement, error) {
+	query := `
+	WITH v AS (
+		SELECT ID_CIA, YEAR, MIN(VERSAO) vmin, MAX(VERSAO) vmax
+		FROM dfp
+		GROUP BY ID_CIA, YEAR
+		HAVING MIN(VERSAO) < MAX(VERSAO)
+	)
+	SELECT
+		c.NAME, o.YEAR, o.CD_CONTA, l.DS_CONTA, l.CODE,
+		v.vmin, v.vmax, o.VL_CONTA, l.VL_CONTA
+	FROM v
+		JOIN companies c ON c.ID = v.ID_CIA
+		JOIN dfp o ON o.ID_CIA = v.ID_CIA AND o.YEAR = v.YEAR AND o.VERSAO = v.vmin
+		JOIN dfp l ON l.ID_CIA = v.ID_CIA AND l.YEAR = v.YEAR AND l.VERSAO = v.vmax
+			AND l.DATA_TYPE = o.DATA_TYPE AND l.CD_CONTA = o.CD_CONTA
+	WHERE
+		c.NAME LIKE ?
+		AND o.VL_CONTA <> l.VL_CONTA
+	ORDER BY c.NAME, o.YEAR, o.CD_CONTA, o.DATA_TYPE;`
+
+	rows, err := db.Query(query, "%"+company+"%")
+	if err != nil {
+		return nil, errors.Wrap(err, "lendo versões dos demonstrativos")
+	}
+	defer rows.Close()
+
+	var list []Restatement
+	for rows.Next() {
+		var r Restatement
+		var year string
+		err := rows.Scan(&r.Company, &year, &r.Account, &r.Descr, &r.Code,
+			&r.FirstVersion, &r.LastVersion, &r.Original, &r.Restated)
+		if err != nil {
+			return nil, err
+		}
+		r.Year, _ = strconv.Atoi(year)
+		list = append(list, r)
+	}
+
+	return list, rows.Err()
+}
+
+// SummarizeRestatements groups the restatements by company and year, sorted
+// by the largest change.
+func SummarizeRestatements(list []Restatement) []RestatementSummary {
+	var summary []RestatementSummary
+	idx := make(map[string]int)
+
+	for _, r := range list {
+		key := r.Company + "|" + strconv.Itoa(r.Year)
+		i, ok := idx[key]
+		if !ok {
+			summary = append(summary, RestatementSummary{
+				Company:      r.Company,
+				Year:         r.Year,
+				FirstVersion: r.FirstVersion,
+				LastVersion:  r.LastVersion,
+				NetIncome:    math.NaN(),
+				Equity:       math.NaN(),
+			})
+			i = len(summary) - 1
+			idx[key] = i
+		}
+
+		s := &summary[i]
+		s.Accounts++
+		change := r.Change()
+		switch r.Code {
+		case parsers.LucLiq:
+			s.NetIncome = change
+		case parsers.Equity:
+			s.Equity = change
+		}
+		if !math.IsNaN(change) && math.Abs(change) > s.MaxChange {
+			s.MaxChange = math.Abs(change)
+		}
+	}
+
+	sort.SliceStable(summary, func(i, j int) bool {
+		return summary[i].MaxChange > summary[j].MaxChange
+	})
+
+	return summary
+}
+
+// PrintRestatements prints the original and restated values of each
+// account, as a table or csv.
+func PrintRestatements(list []Restatement, format string) {
+	if format == "csv" {
+		fmt.Print(csvRestatements(list))
+		return
+	}
+	fmt.Print(printRestatements(list))
+}
+
+func printRestatements(list []Restatement) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	var last string
+	for _, r := range list {
+		title := fmt.Sprintf("%s [%d] versão %d → %d", r.Company, r.Year, r.FirstVersion, r.LastVersion)
+		if title != last {
+			if last != "" {
+				buf.WriteByte('\n')
+			}
+			p.Fprintln(buf, line)
+			p.Fprintln(buf, title)
+			p.Fprintln(buf, line)
+			p.Fprintln(buf, "  CONTA                    ORIGINAL        REAPRESENTADO       VAR.")
+			p.Fprintln(buf, "  ------------     ----------------     ----------------   --------")
+			last = title
+		}
+		p.Fprintf(buf, "  %-12s     %16.0f     %16.0f   %s   %s\n",
+			r.Account, r.Original, r.Restated, fmtChange(p, r.Change()), r.Descr)
+	}
+
+	return buf
+}
+
+func csvRestatements(list []Restatement) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	buf.WriteString("Empresa,Ano,Versão Original,Versão Atual,Conta,Descrição,Original,Reapresentado\n")
+	for _, r := range list {
+		p.Fprintf(buf, `"%s",%s,%s,%s,%s,"%s","%f","%f"`+"\n",
+			r.Company, strconv.Itoa(r.Year), strconv.Itoa(r.FirstVersion), strconv.Itoa(r.LastVersion),
+			r.Account, r.Descr, r.Original, r.Restated)
+	}
+
+	return buf
+}
+
+// PrintRestatementsSummary prints the companies that refiled their annual
+// reports and by how much, as a table or csv.
+func PrintRestatementsSummary(summary []RestatementSummary, format string) {
+	if format == "csv" {
+		fmt.Print(csvRestatementsSummary(summary))
+		return
+	}
+	fmt.Print(printRestatementsSummary(summary))
+}
+
+func printRestatementsSummary(summary []RestatementSummary) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	p.Fprintln(buf, line)
+	p.Fprintln(buf, "  ANO   VERSÕES  CONTAS   LUCRO LÍQ.         PL   MAIOR VAR.  EMPRESA")
+	p.Fprintln(buf, "  ----  -------  ------  ----------  ---------  ----------  -------")
+	for _, s := range summary {
+		p.Fprintf(buf, "  %s  %3d→%-3d  %6d    %s   %s    %s  %s\n",
+			strconv.Itoa(s.Year), s.FirstVersion, s.LastVersion, s.Accounts,
+			fmtChange(p, s.NetIncome), fmtChange(p, s.Equity), fmtChange(p, s.MaxChange), s.Company)
+	}
+	p.Fprintln(buf, line)
+
+	return buf
+}
+
+func csvRestatementsSummary(summary []RestatementSummary) *strings.Builder {
+	buf := &strings.Builder{}
+	p := message.NewPrinter(language.BrazilianPortuguese)
+
+	buf.WriteString("Empresa,Ano,Versão Original,Versão Atual,Contas,Var. Lucro Líq.,Var. PL,Maior Var.\n")
+	for _, s := range summary {
+		p.Fprintf(buf, `"%s",%s,%s,%s,%s,%s,%s,%s`+"\n",
+			s.Company, strconv.Itoa(s.Year), strconv.Itoa(s.FirstVersion), strconv.Itoa(s.LastVersion),
+			strconv.Itoa(s.Accounts), csvChange(p, s.NetIncome), csvChange(p, s.Equity), csvChange(p, s.MaxChange))
+	}
+
+	return buf
+}
+
+// fmtChange formats the relative change as percentage, or "-" if NaN.
+func fmtChange(p *message.Printer, change float64) string {
+	if math.IsNaN(change) {
+		return fmtRate(p, nil)
+	}
+	return fmtRate(p, &change)
+}
+
+func csvChange(p *message.Printer, change float64) string {
+	if math.IsNaN(change) {
+		return `""`
+	}
+	return p.Sprintf(`"%f%%"`, 100*change)
+}
@@ -0,0 +1,108 @@
+package reports
+
+import (
+	"database/sql"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestRestatements(t *testing.T) {
+	f, err := os.CreateTemp("", "rapina-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE companies (ID INTEGER PRIMARY KEY, CNPJ varchar(20), NAME varchar(100));`,
+		`CREATE TABLE dfp (ID PRIMARY KEY, ID_CIA integer, CODE integer, YEAR string, DATA_TYPE string,
+			VERSAO integer, MOEDA varchar(4), ESCALA_MOEDA varchar(7), DT_FIM_EXERC integer,
+			CD_CONTA varchar(18), DS_CONTA varchar(100), VL_CONTA real);`,
+		`INSERT INTO companies VALUES (1, '1', 'ACME S.A.'), (2, '2', 'BETA S.A.');`,
+	}
+	rows := []struct {
+		id, cid int
+		code    uint32
+		year    string
+		dt      string
+		version int
+		cd, ds  string
+		value   float64
+	}{
+		{1, 1, p.Equity, "2020", "BPP", 1, "2.03", "Patrimônio Líquido Consolidado", 1000},
+		{2, 1, p.LucLiq, "2020", "DRE", 1, "3.11", "Lucro/Prejuízo Consolidado do Período", 200},
+		{3, 1, p.Vendas, "2020", "DRE", 1, "3.01", "Receita", 500},
+		{4, 1, p.Equity, "2020", "BPP", 2, "2.03", "Patrimônio Líquido Consolidado", 900},
+		{5, 1, p.LucLiq, "2020", "DRE", 2, "3.11", "Lucro/Prejuízo Consolidado do Período", 150},
+		{6, 1, p.Vendas, "2020", "DRE", 2, "3.01", "Receita", 500},
+		{7, 1, p.Vendas, "2021", "DRE", 1, "3.01", "Receita", 600},
+		{8, 2, p.Vendas, "2020", "DRE", 1, "3.01", "Receita", 100},
+		{9, 2, p.Vendas, "2020", "DRE", 3, "3.01", "Receita", 110},
+		// same code on different statements, unchanged between versions
+		{10, 2, p.FCO, "2020", "DFC_MD", 1, "6.01", "Caixa Líquido Atividades Operacionais", 50},
+		{11, 2, p.FCO, "2020", "DFC_MI", 1, "6.01", "Caixa Líquido Atividades Operacionais", 70},
+		{12, 2, p.FCO, "2020", "DFC_MD", 3, "6.01", "Caixa Líquido Atividades Operacionais", 50},
+		{13, 2, p.FCO, "2020", "DFC_MI", 3, "6.01", "Caixa Líquido Atividades Operacionais", 70},
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range rows {
+		_, err := db.Exec(`INSERT INTO dfp (ID, ID_CIA, CODE, YEAR, DATA_TYPE, VERSAO, CD_CONTA, DS_CONTA, VL_CONTA)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, r.id, r.cid, r.code, r.year, r.dt, r.version, r.cd, r.ds, r.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := Restatements(db, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("Restatements: got %d, want 3: %+v", len(list), list)
+	}
+	if list[0].Company != "ACME S.A." || list[0].Account != "2.03" ||
+		list[0].Original != 1000 || list[0].Restated != 900 ||
+		list[0].FirstVersion != 1 || list[0].LastVersion != 2 {
+		t.Errorf("Restatements: unexpected %+v", list[0])
+	}
+
+	list, _ = Restatements(db, "BETA")
+	if len(list) != 1 || list[0].LastVersion != 3 {
+		t.Errorf("Restatements(BETA): unexpected %+v", list)
+	}
+
+	list, _ = Restatements(db, "")
+	summary := SummarizeRestatements(list)
+	if len(summary) != 2 {
+		t.Fatalf("SummarizeRestatements: got %d, want 2", len(summary))
+	}
+	s := summary[0] // largest change first
+	if s.Company != "ACME S.A." || s.Accounts != 2 ||
+		math.Abs(s.NetIncome+0.25) > 1e-9 || math.Abs(s.Equity+0.1) > 1e-9 || math.Abs(s.MaxChange-0.25) > 1e-9 {
+		t.Errorf("SummarizeRestatements: unexpected %+v", s)
+	}
+	if !math.IsNaN(summary[1].NetIncome) {
+		t.Errorf("SummarizeRestatements: net income should not be set: %+v", summary[1])
+	}
+
+	out := printRestatementsSummary(summary).String()
+	if !strings.Contains(out, "-25,0%") || !strings.Contains(out, "BETA S.A.") {
+		t.Errorf("printRestatementsSummary: unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,223 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Screen contains the companies ranked by a custom metric or formula on a
+// year.
+type Screen struct {
+	Name    string         `json:"name"`
+	Formula string         `json:"formula"`
+	Year    int            `json:"year"`
+	Unit    string         `json:"unit"`
+	Results []ScreenResult `json:"results"`
+
+	format int
+}
+
+// ScreenResult is the value of the metric of a company.
+type ScreenResult struct {
+	Company string  `json:"company"`
+	Value   float64 `json:"value"`
+}
+
+// FormulaSeries contains the values of a custom metric or formula of a
+// company, by year.
+type FormulaSeries struct {
+	Company string         `json:"company"`
+	Name    string         `json:"name"`
+	Formula string         `json:"formula"`
+	Unit    string         `json:"unit"`
+	Values  []FormulaValue `json:"values"`
+}
+
+// FormulaValue is the value of a formula on a year.
+type FormulaValue struct {
+	Year  int     `json:"year"`
+	Value float64 `json:"value"`
+}
+
+// Screen ranks the companies of the DB by the custom metric 'name' (see
+// SetFormulas) or by the formula 'name' (e.g., "LucLiq / Vendas"), on 'year'
+// (0 for the last year on the DB), in descending order. Companies without
+// the value (zero or division by zero) are not listed. The quotes, only
+// read if used by the formula, are never downloaded.
+func (r Report) Screen(name string, year int, unit Unit) (*Screen, error) {
+	f, err := findFormula(name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, last, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	if year == 0 || year > last {
+		year = last
+	}
+
+	list, err := r.alertCompanies("")
+	if err != nil {
+		return nil, err
+	}
+
+	r.unit = unit
+	s := &Screen{Name: f.Name, Formula: f.Formula, Year: year, Unit: r.unitLabel(), format: f.format}
+	for _, co := range list {
+		if err := r.setFormulaPeer(co.name, f); err != nil {
+			continue
+		}
+		values, err := r.formulaValues(year-maxOffset(f.expr), year)
+		if err != nil || sum(values[year]) == 0 {
+			continue
+		}
+		v := f.expr.eval(values.env(year))
+		if !valid(v) {
+			continue
+		}
+		s.Results = append(s.Results, ScreenResult{
+			Company: co.name,
+			Value:   float64(r.display(metric{val: v, format: f.format}, year)),
+		})
+	}
+
+	sort.SliceStable(s.Results, func(i, j int) bool {
+		return s.Results[i].Value > s.Results[j].Value
+	})
+
+	return s, nil
+}
+
+// FormulaSeries returns the values of the custom metric or formula 'name' of
+// the 'company' on each year available on the DB, converted to 'unit'.
+// Years without the value are not listed. As on Screen, the quotes are
+// only read from the DB.
+func (r Report) FormulaSeries(company, name string, unit Unit) (*FormulaSeries, error) {
+	f, err := findFormula(name)
+	if err != nil {
+		return nil, err
+	}
+
+	company, err = r.companyName(company)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setFormulaPeer(company, f); err != nil {
+		return nil, err
+	}
+
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.formulaValues(begin-maxOffset(f.expr), end)
+	if err != nil {
+		return nil, err
+	}
+
+	r.unit = unit
+	s := &FormulaSeries{Company: company, Name: f.Name, Formula: f.Formula, Unit: r.unitLabel()}
+	for y := begin; y <= end; y++ {
+		if sum(values[y]) == 0 {
+			continue
+		}
+		v := f.expr.eval(values.env(y))
+		if !valid(v) {
+			continue
+		}
+		s.Values = append(s.Values, FormulaValue{
+			Year:  y,
+			Value: float64(r.display(metric{val: v, format: f.format}, y)),
+		})
+	}
+
+	return s, nil
+}
+
+// setFormulaPeer sets the 'company' as a peer (quotes only read from the
+// DB) and, if the formula does not use the quote, skips reading it.
+func (r *Report) setFormulaPeer(company string, f *Formula) error {
+	if err := r.setPeer(company); err != nil {
+		return err
+	}
+	if !usesAccount(f.expr, parsers.Quote) {
+		r.code = ""
+	}
+	return nil
+}
+
+// formulaValues returns the account values of the company from 'begin' to
+// 'end'.
+func (r Report) formulaValues(begin, end int) (yearValues, error) {
+	values := make(yearValues)
+	for y := begin; y <= end; y++ {
+		v, err := r.accountsValues(y)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s (%d)", r.company, y)
+		}
+		values[y] = v
+	}
+	return values, nil
+}
+
+// PrintScreen prints the first 'top' companies of the screen (all if zero),
+// as a table or csv.
+func PrintScreen(s *Screen, top int, format string) {
+	results := s.Results
+	if top > 0 && top < len(results) {
+		results = results[:top]
+	}
+	if format == "csv" {
+		fmt.Print(csvScreen(s, results))
+		return
+	}
+	fmt.Print(printScreen(s, results))
+}
+
+func printScreen(s *Screen, results []ScreenResult) *strings.Builder {
+	buf := &strings.Builder{}
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+
+	fmt.Fprintln(buf, line)
+	fmt.Fprintf(buf, "%s [%d] (%s)\n", s.Name, s.Year, s.Unit)
+	if s.Formula != s.Name {
+		fmt.Fprintf(buf, "= %s\n", s.Formula)
+	}
+	fmt.Fprintln(buf, line)
+
+	for i, res := range results {
+		fmt.Fprintf(buf, "  %4d  %-44s", i+1, truncate(res.Company, 44))
+		switch s.format {
+		case PERCENT:
+			pt.Fprintf(buf, " %13.1f%%\n", 100*res.Value)
+		case INDEX:
+			pt.Fprintf(buf, " %14.2f\n", res.Value)
+		default:
+			pt.Fprintf(buf, " %14.0f\n", res.Value)
+		}
+	}
+	fmt.Fprintf(buf, "\n%d de %d empresas\n", len(results), len(s.Results))
+
+	return buf
+}
+
+func csvScreen(s *Screen, results []ScreenResult) *strings.Builder {
+	buf := &strings.Builder{}
+
+	fmt.Fprintf(buf, "Posição,Empresa,\"%s\"\n", strings.ReplaceAll(s.Name, `"`, `""`))
+	for i, res := range results {
+		fmt.Fprintf(buf, `%d,"%s",%s`+"\n",
+			i+1, strings.ReplaceAll(res.Company, `"`, `""`), strconv.FormatFloat(res.Value, 'f', -1, 64))
+	}
+
+	return buf
+}
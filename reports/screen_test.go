@@ -0,0 +1,89 @@
+package reports
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScreen(t *testing.T) {
+	db := companiesDB(t)
+	r := Report{db: db}
+
+	s, err := r.Screen("Equity * 2", 0, UnitThousand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Year != 2021 || s.Formula != "Equity * 2" {
+		t.Errorf("unexpected header: %+v", s)
+	}
+	want := []ScreenResult{{"GAMA S.A.", 2000}, {"BETA S.A.", 400}, {"ACME S.A.", 200}}
+	if len(s.Results) != len(want) {
+		t.Fatalf("got %+v, want %+v", s.Results, want)
+	}
+	for i := range want {
+		if s.Results[i].Company != want[i].Company || math.Abs(s.Results[i].Value-want[i].Value) > 1e-6 {
+			t.Errorf("result %d = %+v, want %+v", i, s.Results[i], want[i])
+		}
+	}
+
+	// Custom metric, converted to the unit
+	if err := SetFormulas([]Formula{{Name: "PL", Formula: "Equity"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _formulas = nil })
+	s, err = r.Screen("pl", 2021, UnitMillion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "PL" || len(s.Results) != 3 || math.Abs(s.Results[0].Value-1) > 1e-6 {
+		t.Errorf("unexpected screen: %+v", s)
+	}
+
+	// No data on the previous year
+	s, err = r.Screen("Equity / Equity[-1]", 0, UnitThousand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Results) != 0 {
+		t.Errorf("got %+v, want no results", s.Results)
+	}
+
+	if _, err := r.Screen("Equity / Xpto", 0, UnitThousand); err == nil || !strings.Contains(err.Error(), "Xpto") {
+		t.Errorf("expected unknown account error, got %v", err)
+	}
+}
+
+func TestFormulaSeries(t *testing.T) {
+	db := companiesDB(t)
+	r := Report{db: db}
+
+	s, err := r.FormulaSeries("BETA", "Equity / 100", UnitThousand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Company != "BETA S.A." || len(s.Values) != 1 || s.Values[0] != (FormulaValue{2021, 2}) {
+		t.Errorf("unexpected series: %+v", s)
+	}
+
+	if _, err := r.FormulaSeries("XPTO", "Equity", UnitThousand); err == nil {
+		t.Error("expected error for unknown company")
+	}
+}
+
+func Test_printScreen(t *testing.T) {
+	s := &Screen{Name: "Margem", Formula: "LucLiq / Vendas", Year: 2021, Unit: "R$ mil", format: PERCENT}
+	s.Results = []ScreenResult{{"ACME S.A.", 0.25}, {"BETA S.A.", 0.1}}
+
+	got := printScreen(s, s.Results[:1]).String()
+	for _, want := range []string{"Margem [2021]", "= LucLiq / Vendas", "ACME S.A.", "25,0%", "1 de 2 empresas"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	got = csvScreen(s, s.Results).String()
+	if want := "Posição,Empresa,\"Margem\"\n1,\"ACME S.A.\",0.25\n2,\"BETA S.A.\",0.1\n"; got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// SectorStats contains the distribution of the metrics of the companies of
+// the sector (or peer group) and the position of the company within it.
+type SectorStats struct {
+	Company string        `json:"company"`
+	Sector  string        `json:"sector"`
+	Unit    string        `json:"unit"`
+	Metrics []MetricStats `json:"metrics"`
+}
+
+// MetricStats contains the median and the quartiles of a metric of the
+// companies of the sector on a year, and the value and the percentile rank
+// (0-100) of the company, if available.
+type MetricStats struct {
+	Descr      string   `json:"description"`
+	Year       int      `json:"year"`
+	N          int      `json:"companies"`
+	Median     float64  `json:"median"`
+	P25        float64  `json:"p25"`
+	P75        float64  `json:"p75"`
+	Value      *float64 `json:"value,omitempty"`
+	Percentile *float64 `json:"percentile,omitempty"`
+}
+
+// Blocks of the sector sheet
+const (
+	blockCompany int = iota
+	blockAverage
+	blockMedian
+	blockP25
+	blockP75
+	blockRank
+)
+
+// Color scale used on the sector sheet (red: low, yellow: median, green: high)
+const colorScale = `[{"type":"3_color_scale","criteria":"=",` +
+	`"min_type":"%s","mid_type":"percentile","max_type":"%s",` +
+	`"min_value":"%s","mid_value":"50","max_value":"%s",` +
+	`"min_color":"#F8696B","mid_color":"#FFEB84","max_color":"#63BE7B"}]`
+
+// sectorData contains the accounts values and the metrics of the companies
+// of the sector by name and year, the sorted values of each metric by year
+// and description, and the cells where the metrics were printed on the
+// sector sheet.
+type sectorData struct {
+	begin, end int
+	accounts   map[string]map[int]map[uint32]float32
+	companies  map[string]map[int][]metric
+	metrics    map[int]map[string][]float32
+	cells      map[string][]string          // metric cells of the companies
+	rank       []string                     // percentile rank cells
+	sheetCells map[string]map[string]string // metric cells by company and year|descr
+	titles     map[string]string            // company name cells
+}
+
+// sectorData reads the accounts values of the 'companies' from 'begin' to
+// 'end' and the distribution of their metrics. Zeros are ignored, as they
+// usually mean that the data is not available.
+func (r Report) sectorData(companies []string, begin, end int) *sectorData {
+	d := &sectorData{
+		begin:      begin,
+		end:        end,
+		accounts:   make(map[string]map[int]map[uint32]float32),
+		companies:  make(map[string]map[int][]metric),
+		metrics:    make(map[int]map[string][]float32),
+		cells:      make(map[string][]string),
+		sheetCells: make(map[string]map[string]string),
+		titles:     make(map[string]string),
+	}
+
+	for _, co := range companies {
+		if err := r.setPeer(co); err != nil {
+			continue
+		}
+		d.accounts[co] = make(map[int]map[uint32]float32)
+		d.companies[co] = make(map[int][]metric)
+		for y := begin; y <= end; y++ {
+			values, err := r.accountsValues(y)
+			if err != nil || sum(values) == 0 {
+				continue
+			}
+			d.accounts[co][y] = values
+
+			if d.metrics[y] == nil {
+				d.metrics[y] = make(map[string][]float32)
+			}
+			seen := make(map[string]bool)
+			for _, m := range r.metrics(y, d.accounts[co]) {
+				if !r.groups[m.group] || m.format == EMPTY || seen[m.descr] {
+					continue
+				}
+				seen[m.descr] = true
+				d.companies[co][y] = append(d.companies[co][y], m)
+				if valid(m.val) {
+					d.metrics[y][m.descr] = append(d.metrics[y][m.descr], m.val)
+				}
+			}
+		}
+	}
+
+	for _, list := range d.metrics {
+		for _, values := range list {
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		}
+	}
+
+	return d
+}
+
+// statsMetrics replaces the values of the company 'metrics' by the statistic
+// of the sector printed on the 'block'. Metrics without data are left empty.
+func (d *sectorData) statsMetrics(block, year int, metrics []metric) []metric {
+	for i, m := range metrics {
+		if m.format == EMPTY {
+			continue
+		}
+		list := d.metrics[year][m.descr]
+		if len(list) == 0 {
+			metrics[i].format = EMPTY
+			continue
+		}
+		switch block {
+		case blockMedian:
+			metrics[i].val = quantile(list, 0.5)
+		case blockP25:
+			metrics[i].val = quantile(list, 0.25)
+		case blockP75:
+			metrics[i].val = quantile(list, 0.75)
+		case blockRank:
+			if !valid(m.val) {
+				metrics[i].format = EMPTY
+				continue
+			}
+			metrics[i].val = float32(percentileRank(list, m.val) / 100)
+			metrics[i].format = PERCENT
+		}
+	}
+	return metrics
+}
+
+// addCell saves the cell where a metric was printed, to be used on the color
+// scales and on the charts.
+func (d *sectorData) addCell(block int, company string, year int, m metric, cell string) {
+	switch block {
+	case blockCompany:
+		key := fmt.Sprintf("%d|%d|%s", year, m.group, m.descr)
+		d.cells[key] = append(d.cells[key], cell)
+		if d.sheetCells[company] == nil {
+			d.sheetCells[company] = make(map[string]string)
+		}
+		key = fmt.Sprintf("%d|%s", year, m.descr)
+		if _, ok := d.sheetCells[company][key]; !ok {
+			d.sheetCells[company][key] = cell
+		}
+	case blockRank:
+		d.rank = append(d.rank, cell)
+	}
+}
+
+// colorScales colors the metrics of each company according to their
+// position among the companies of the sector, and the percentile ranks.
+func (d *sectorData) colorScales(sheet *Sheet) {
+	for _, cells := range d.cells {
+		if len(cells) < 3 {
+			continue
+		}
+		format := fmt.Sprintf(colorScale, "min", "max", "0", "0")
+		_ = sheet.xlsx.SetConditionalFormat(sheet.name, strings.Join(cells, " "), format)
+	}
+	if len(d.rank) > 0 {
+		format := fmt.Sprintf(colorScale, "num", "num", "0", "1")
+		_ = sheet.xlsx.SetConditionalFormat(sheet.name, strings.Join(d.rank, " "), format)
+	}
+}
+
+// SectorStats returns the median, quartiles and percentile rank of the
+// metrics of the 'company' among the companies of its sector (or peer
+// group), on each year available on the DB. Values are converted to 'unit'.
+func (r Report) SectorStats(company string, unit Unit) (*SectorStats, error) {
+	name, err := r.companyName(company)
+	if err != nil {
+		return nil, err
+	}
+
+	r.unit = unit
+	if r.groups == nil {
+		r.groups = map[int]bool{grpAccts: true, grpShares: true, grpExtra: true, grpFleuriet: true, grpQuality: true, grpCustom: true, grpTemplate: true}
+	}
+
+	companies, sectorName, err := r.fromSector(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(companies) <= 1 {
+		return nil, errors.Errorf("setor de '%s' não encontrado", name)
+	}
+
+	begin, end, err := timeRange(r.db)
+	if err != nil {
+		return nil, err
+	}
+	d := r.sectorData(companies, begin, end)
+	if err := r.setPeer(name); err != nil {
+		return nil, err
+	}
+
+	s := &SectorStats{Company: name, Sector: sectorName, Unit: r.unitLabel()}
+	for y := begin; y <= end; y++ {
+		seen := make(map[string]bool)
+		for _, m := range r.metrics(y, d.accounts[name]) {
+			list := d.metrics[y][m.descr]
+			if !r.groups[m.group] || m.format == EMPTY || seen[m.descr] || len(list) == 0 {
+				continue
+			}
+			seen[m.descr] = true
+
+			stat := func(q float64) float64 {
+				return float64(r.display(metric{descr: m.descr, val: quantile(list, q), format: m.format}, y))
+			}
+			ms := MetricStats{
+				Descr:  m.descr,
+				Year:   y,
+				N:      len(list),
+				Median: stat(0.5),
+				P25:    stat(0.25),
+				P75:    stat(0.75),
+			}
+			if valid(m.val) {
+				v := float64(r.display(m, y))
+				rank := percentileRank(list, m.val)
+				ms.Value, ms.Percentile = &v, &rank
+			}
+			s.Metrics = append(s.Metrics, ms)
+		}
+	}
+
+	return s, nil
+}
+
+// quantile returns the q-th quantile (0 <= q <= 1) of the 'sorted' values,
+// interpolating linearly between the closest ranks.
+func quantile(sorted []float32, q float64) float32 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	h := q * float64(n-1)
+	i := int(h)
+	if i+1 >= n {
+		return sorted[n-1]
+	}
+	return sorted[i] + float32(h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// percentileRank returns the percentage (0-100) of the 'values' below 'v',
+// counting the values equal to 'v' as half.
+func percentileRank(values []float32, v float32) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var below, equal int
+	for _, x := range values {
+		if x < v {
+			below++
+		} else if x == v {
+			equal++
+		}
+	}
+	return (float64(below) + 0.5*float64(equal)) / float64(len(values)) * 100
+}
+
+// valid returns true if the metric value is available (not NaN or
+// infinite). Zero is a valid value (e.g., no dividends paid).
+func valid(v float32) bool {
+	f := float64(v)
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// printSectorStats prints the sector statistics, one metric per line.
+func printSectorStats(s *SectorStats) *strings.Builder {
+	buf := &strings.Builder{}
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+
+	fmt.Fprintf(buf, "%s (%s)\n", s.Sector, s.Unit)
+	buf.WriteString("ano;indicador;empresas;mediana;p25;p75;valor;percentil\n")
+	for _, m := range s.Metrics {
+		value, rank := "", ""
+		if m.Value != nil {
+			value = pt.Sprintf("%.2f", *m.Value)
+			rank = strconv.Itoa(int(math.Round(*m.Percentile)))
+		}
+		fmt.Fprintf(buf, "%d;%s;%d;", m.Year, m.Descr, m.N)
+		pt.Fprintf(buf, "%.2f;%.2f;%.2f;%s;%s\n", m.Median, m.P25, m.P75, value, rank)
+	}
+
+	return buf
+}
+
+// blockTitle returns the title of the block on the sector sheet.
+func blockTitle(block int, company string) string {
+	switch block {
+	case blockAverage:
+		return sectorAverage
+	case blockMedian:
+		return "MEDIANA DO SETOR"
+	case blockP25:
+		return "1º QUARTIL (P25)"
+	case blockP75:
+		return "3º QUARTIL (P75)"
+	case blockRank:
+		return "PERCENTIL: " + company
+	}
+	return company
+}
@@ -0,0 +1,211 @@
+package reports
+
+import (
+	"database/sql"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/dude333/rapina"
+	p "github.com/dude333/rapina/parsers"
+)
+
+func Test_quantile(t *testing.T) {
+	sorted := []float32{1, 2, 3, 4, 100} // outlier doesn't move the median
+
+	tests := []struct {
+		q    float64
+		want float32
+	}{
+		{0, 1},
+		{0.25, 2},
+		{0.5, 3},
+		{0.75, 4},
+		{1, 100},
+		{0.1, 1.4},
+	}
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.q); math.Abs(float64(got-tt.want)) > 1e-5 {
+			t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	if got := quantile([]float32{1, 2, 3, 4}, 0.5); got != 2.5 {
+		t.Errorf("quantile(even) = %v, want 2.5", got)
+	}
+	if got := quantile(nil, 0.5); got != 0 {
+		t.Errorf("quantile(nil) = %v, want 0", got)
+	}
+}
+
+func Test_percentileRank(t *testing.T) {
+	values := []float32{1, 2, 2, 3, 4}
+
+	tests := []struct {
+		v    float32
+		want float64
+	}{
+		{0, 0},
+		{1, 10},
+		{2, 40},
+		{4, 90},
+		{5, 100},
+	}
+	for _, tt := range tests {
+		if got := percentileRank(values, tt.v); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentileRank(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func Test_statsMetrics(t *testing.T) {
+	d := &sectorData{metrics: map[int]map[string][]float32{
+		2020: {"ROE": {0.05, 0.10, 0.15, 0.20}},
+	}}
+	metrics := func() []metric {
+		return []metric{
+			{"ROE", 0.15, PERCENT, grpAccts},
+			{"", 0, EMPTY, grpAccts},
+			{"Caixa", 100, NUMBER, grpAccts},
+		}
+	}
+
+	m := d.statsMetrics(blockMedian, 2020, metrics())
+	if math.Abs(float64(m[0].val-0.125)) > 1e-6 {
+		t.Errorf("median = %v, want 0.125", m[0].val)
+	}
+	if m[2].format != EMPTY {
+		t.Errorf("metric without sector data should be empty, got %v", m[2])
+	}
+
+	m = d.statsMetrics(blockRank, 2020, metrics())
+	if m[0].format != PERCENT || math.Abs(float64(m[0].val-0.625)) > 1e-6 {
+		t.Errorf("rank = %v, want 0.625 (PERCENT)", m[0])
+	}
+}
+
+func TestSectorStats(t *testing.T) {
+	db := companiesDB(t)
+
+	r := Report{db: db, peers: []string{"BETA S.A.", "GAMA S.A."}, peersName: "teste"}
+	s, err := r.SectorStats("ACME", UnitMillion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Company != "ACME S.A." || s.Sector != "Grupo: teste" || s.Unit != "R$ milhões" {
+		t.Errorf("unexpected header: %+v", s)
+	}
+	metrics := make(map[string]MetricStats)
+	for _, m := range s.Metrics {
+		metrics[m.Descr] = m
+	}
+	// No dividends is a real zero, but no quote is not available
+	if m, ok := metrics["Proventos"]; !ok || m.N != 3 || m.Median != 0 || m.Value == nil {
+		t.Errorf("zero values should be on the distribution: %+v", m)
+	}
+	for _, descr := range []string{"Cotação", "P/L", "LPA"} {
+		if m, ok := metrics[descr]; ok {
+			t.Errorf("metric without data should not be on the distribution: %+v", m)
+		}
+	}
+	m := metrics["Patrimônio Líquido"]
+	if m.Descr != "Patrimônio Líquido" || m.Year != 2021 || m.N != 3 {
+		t.Errorf("unexpected metric: %+v", m)
+	}
+	for _, v := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"median", m.Median, 0.2},
+		{"p25", m.P25, 0.15},
+		{"p75", m.P75, 0.6},
+		{"value", *m.Value, 0.1},
+		{"percentile", *m.Percentile, 100.0 / 6},
+	} {
+		if math.Abs(v.got-v.want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", v.name, v.got, v.want)
+		}
+	}
+
+	if _, err := r.SectorStats("XPTO", UnitThousand); err == nil {
+		t.Error("expected error for unknown company")
+	}
+}
+
+func TestSetPeer(t *testing.T) {
+	db := companiesDB(t)
+	r, err := New(map[string]interface{}{"db": db, "dataDir": t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := []string{
+		`CREATE TABLE sectors (SECTOR, SUBSECTOR, SEGMENT, NAME, CNPJ, TICKER);`,
+		`INSERT INTO sectors VALUES ('S', 'SS', 'SEG', 'BETA', '2', 'BETA3'), ('S', 'SS', 'SEG', 'GAMA', '3', 'GAMA3');`,
+		`INSERT INTO stock_quotes (stock, date, close) VALUES ('BETA3', '` + rapina.LastBusinessDayOfYear(2021) + `', 10);`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		company string
+		want    float32
+	}{
+		{"BETA S.A.", 10},
+		{"GAMA S.A.", 0}, // not on db: must not be downloaded
+	} {
+		if err := r.setPeer(tt.company); err != nil {
+			t.Fatal(err)
+		}
+		if !r.storedQuotes {
+			t.Error("peer quotes should be read only from the db")
+		}
+		values, err := r.accountsValues(2021)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := values[p.Quote]; got != tt.want {
+			t.Errorf("%s: quote = %v, want %v", tt.company, got, tt.want)
+		}
+	}
+}
+
+// companiesDB returns a DB with the equity of 3 companies in 2021.
+func companiesDB(t *testing.T) *sql.DB {
+	f, err := os.CreateTemp("", "rapina-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	stmts := []string{
+		`CREATE TABLE companies (ID INTEGER PRIMARY KEY, CNPJ varchar(20), NAME varchar(100));`,
+		`CREATE TABLE dfp (ID PRIMARY KEY, ID_CIA integer, CODE integer, YEAR string, DATA_TYPE string,
+			VERSAO integer, MOEDA varchar(4), ESCALA_MOEDA varchar(7), DT_FIM_EXERC integer,
+			CD_CONTA varchar(18), DS_CONTA varchar(100), VL_CONTA real);`,
+		`INSERT INTO companies VALUES (1, '1', 'ACME S.A.'), (2, '2', 'BETA S.A.'), (3, '3', 'GAMA S.A.');`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for cid, equity := range []float64{100, 200, 1000} { // GAMA is an outlier
+		_, err := db.Exec(`INSERT INTO dfp (ID, ID_CIA, CODE, YEAR, VERSAO, CD_CONTA, DS_CONTA, VL_CONTA)
+			VALUES (?, ?, ?, '2021', 1, '2.03', 'Patrimônio Líquido Consolidado', ?)`, cid, cid+1, p.Equity, equity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
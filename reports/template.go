@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Template defines the metrics printed on the reports, their order, labels
+// and formats, replacing the groups of metrics selected on the command line.
+type Template struct {
+	Name    string         `yaml:"Nome"`
+	Metrics []TemplateItem `yaml:"Indicadores"`
+}
+
+// TemplateItem is a line of the template: a built-in metric (by its
+// description, e.g., "ROE"), an account (e.g., "Vendas") or a formula over
+// the accounts (e.g., "(Vendas + CustoVendas) / Vendas"). An item with none
+// of them prints only its label (e.g., a section title) or an empty line.
+type TemplateItem struct {
+	Label   string `yaml:"Rotulo"`
+	Metric  string `yaml:"Indicador"`
+	Account string `yaml:"Conta"`
+	Formula string `yaml:"Formula"`
+	Format  string `yaml:"Formato"` // NUMBER, INDEX or PERCENT
+
+	format int
+	expr   expr
+}
+
+// templateFormats maps the format names used on the templates.
+var templateFormats = map[string]int{
+	"NUMBER":  NUMBER,
+	"INDEX":   INDEX,
+	"PERCENT": PERCENT,
+}
+
+// LoadTemplate loads the report template from a YAML (or JSON) file.
+func LoadTemplate(filename string) (*Template, error) {
+	y, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "modelo de relatório")
+	}
+
+	t := &Template{}
+	if err := yaml.UnmarshalStrict(y, t); err != nil {
+		return nil, errors.Wrapf(err, "modelo de relatório %s", filename)
+	}
+	if err := t.compile(); err != nil {
+		return nil, errors.Wrapf(err, "modelo de relatório %s", filename)
+	}
+
+	return t, nil
+}
+
+// compile validates the items, setting their labels, formats and formulas.
+func (t *Template) compile() error {
+	if len(t.Metrics) == 0 {
+		return errors.New("nenhum indicador definido")
+	}
+
+	builtin := builtinMetrics()
+	for _, f := range _formulas {
+		builtin[f.Name] = f.format
+	}
+
+	for i := range t.Metrics {
+		it := &t.Metrics[i]
+		n := 0
+		for _, s := range []string{it.Metric, it.Account, it.Formula} {
+			if s != "" {
+				n++
+			}
+		}
+		if n > 1 {
+			return fmt.Errorf("item %d: use apenas um entre Indicador, Conta e Formula", i+1)
+		}
+
+		it.format = NUMBER
+		switch {
+		case it.Metric != "":
+			format, ok := builtin[it.Metric]
+			if !ok {
+				return fmt.Errorf("item %d: indicador desconhecido: %s", i+1, it.Metric)
+			}
+			it.format = format
+			if it.Label == "" {
+				it.Label = it.Metric
+			}
+		case it.Account != "":
+			code, ok := valueCode(it.Account)
+			if !ok {
+				return fmt.Errorf("item %d: conta desconhecida: %s", i+1, it.Account)
+			}
+			it.expr = account{code: code}
+			if it.Label == "" {
+				it.Label = it.Account
+			}
+		case it.Formula != "":
+			e, err := parseFormula(it.Formula)
+			if err != nil {
+				return errors.Wrapf(err, "item %d", i+1)
+			}
+			it.expr = e
+			if it.Label == "" {
+				it.Label = it.Formula
+			}
+		default:
+			it.format = EMPTY
+		}
+
+		if it.Format != "" && it.format != EMPTY {
+			format, ok := templateFormats[strings.ToUpper(it.Format)]
+			if !ok {
+				return fmt.Errorf("item %d: formato inválido: %s (opções: NUMBER|INDEX|PERCENT)", i+1, it.Format)
+			}
+			it.format = format
+		}
+	}
+
+	return nil
+}
+
+// metrics returns the metrics of the template, with the account values of
+// 'env'. Built-in metrics not available for the statements layout are
+// printed without values.
+func (t *Template) metrics(layout int, env formulaEnv) []metric {
+	builtin := make(map[string]float32)
+	for _, m := range append(metricsList(layout, env(0)), customMetrics(env)...) {
+		if _, ok := builtin[m.descr]; !ok && m.format != EMPTY {
+			builtin[m.descr] = m.val
+		}
+	}
+
+	list := make([]metric, len(t.Metrics))
+	for i, it := range t.Metrics {
+		m := metric{descr: it.Label, format: it.format, group: grpTemplate}
+		switch {
+		case it.expr != nil:
+			m.val = it.expr.eval(env)
+		case it.Metric != "":
+			val, ok := builtin[it.Metric]
+			if !ok {
+				m.format = EMPTY
+			}
+			m.val = val
+		}
+		list[i] = m
+	}
+
+	return list
+}
+
+// builtinMetrics returns the format of the built-in metrics of all the
+// statements layouts, by description.
+func builtinMetrics() map[string]int {
+	builtin := make(map[string]int)
+	for _, layout := range []int{layoutStd, layoutBank, layoutInsurer} {
+		for _, m := range metricsList(layout, nil) {
+			if _, ok := builtin[m.descr]; !ok && m.format != EMPTY {
+				builtin[m.descr] = m.format
+			}
+		}
+	}
+	return builtin
+}
@@ -0,0 +1,95 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "modelo.yml")
+	err := os.WriteFile(filename, []byte(`
+Nome: Resumo
+Indicadores:
+  - Indicador: Receita Líquida
+    Rotulo: Receita
+  - Formula: (Vendas + CustoVendas) / Vendas
+    Rotulo: Margem Bruta
+    Formato: percent
+  - Rotulo: Bancos
+  - {}
+  - Conta: Shares
+    Formato: INDEX
+  - Indicador: Margem Financeira (RBIF/Ativo)
+    Rotulo: Margem Financeira
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := LoadTemplate(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := map[uint32]float32{p.Vendas: 1000, p.CustoVendas: -600, p.Shares: 10}
+	want := []metric{
+		{"Receita", 1000, NUMBER, grpTemplate},
+		{"Margem Bruta", 0.4, PERCENT, grpTemplate},
+		{"Bancos", 0, EMPTY, grpTemplate},
+		{"", 0, EMPTY, grpTemplate},
+		{"Shares", 10, INDEX, grpTemplate},
+		{"Margem Financeira", 0, EMPTY, grpTemplate}, // bank metric
+	}
+	got := tpl.metrics(layoutStd, yearValues{2021: v}.env(2021))
+	if len(got) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		g := got[i]
+		if g.descr != want[i].descr || g.format != want[i].format || g.group != grpTemplate ||
+			float32Abs(g.val-want[i].val) > 1e-5 {
+			t.Errorf("metric %d = %v, want %v", i, g, want[i])
+		}
+	}
+
+	r := Report{template: tpl, layout: layoutStd}
+	if m := r.metrics(2021, yearValues{2021: v}); len(m) != len(want) || m[0].descr != "Receita" {
+		t.Errorf("report metrics not from the template: %v", m)
+	}
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	tests := []struct {
+		yaml, err string
+	}{
+		{"Indicadores:\n  - Indicador: XPTO", "indicador desconhecido: XPTO"},
+		{"Indicadores:\n  - Conta: XPTO", "conta desconhecida: XPTO"},
+		{"Indicadores:\n  - Formula: Vendas /", "item 1"},
+		{"Indicadores:\n  - Conta: Vendas\n    Formula: EBIT", "apenas um"},
+		{"Indicadores:\n  - Conta: Vendas\n    Formato: MOEDA", "formato inválido"},
+		{"Indicadores:\n  - Campo: Vendas", "Campo"},
+		{"Nome: vazio", "nenhum indicador"},
+	}
+	dir := t.TempDir()
+	for i, tt := range tests {
+		filename := filepath.Join(dir, strings.Repeat("x", i+1)+".yml")
+		if err := os.WriteFile(filename, []byte(tt.yaml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadTemplate(filename)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("LoadTemplate(%q) error = %v, want %q", tt.yaml, err, tt.err)
+		}
+	}
+}
+
+func float32Abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
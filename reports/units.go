@@ -0,0 +1,82 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dude333/rapina/parsers"
+)
+
+// Unit is the unit used to display the monetary values, which are stored on
+// the DB in thousands of R$.
+type Unit int
+
+// Display units
+const (
+	UnitThousand Unit = iota // R$ mil (default)
+	UnitReal                 // R$
+	UnitMillion              // R$ milhões
+)
+
+// ParseUnit returns the display unit from the command line option:
+// unidade, mil or milhoes.
+func ParseUnit(unit string) (Unit, error) {
+	switch strings.ToLower(parsers.RemoveDiacritics(strings.TrimSpace(unit))) {
+	case "", "mil", "milhar", "r$ mil":
+		return UnitThousand, nil
+	case "unidade", "real", "reais", "r$":
+		return UnitReal, nil
+	case "milhao", "milhoes", "mi", "r$ milhoes":
+		return UnitMillion, nil
+	}
+	return UnitThousand, fmt.Errorf("unidade inválida: %s (opções: unidade|mil|milhoes)", unit)
+}
+
+// factor returns the factor to convert the values stored on the DB to the
+// unit.
+func (u Unit) factor() float32 {
+	switch u {
+	case UnitReal:
+		return 1000
+	case UnitMillion:
+		return 0.001
+	}
+	return 1
+}
+
+// String returns the unit label.
+func (u Unit) String() string {
+	switch u {
+	case UnitReal:
+		return "R$"
+	case UnitMillion:
+		return "R$ milhões"
+	}
+	return "R$ mil"
+}
+
+// deflators returns the factors used to convert the nominal values from
+// 'begin' to 'end' to the currency of 'base', or nil if 'infl' is nil.
+func deflators(infl parsers.Inflation, base, begin, end int) (map[int]float32, error) {
+	if infl == nil {
+		return nil, nil
+	}
+	d := make(map[int]float32, end-begin+1)
+	for y := begin; y <= end; y++ {
+		f, err := infl.Deflator(y, base)
+		if err != nil {
+			return nil, err
+		}
+		d[y] = float32(f)
+	}
+	return d, nil
+}
+
+// unitLabel returns the unit label, followed by the base year if the values
+// are in constant currency.
+func unitLabel(unit Unit, d map[int]float32, base int) string {
+	if d == nil {
+		return unit.String()
+	}
+	return fmt.Sprintf("%s de %d", unit, base)
+}
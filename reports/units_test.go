@@ -0,0 +1,105 @@
+package reports
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dude333/rapina/parsers"
+)
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Unit
+		wantErr bool
+	}{
+		{"", UnitThousand, false},
+		{"mil", UnitThousand, false},
+		{"MILHAR", UnitThousand, false},
+		{"unidade", UnitReal, false},
+		{"R$", UnitReal, false},
+		{"milhões", UnitMillion, false},
+		{"mi", UnitMillion, false},
+		{"bilhao", UnitThousand, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnit(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseUnit(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("ParseUnit(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestUnitFactor(t *testing.T) {
+	const v = 1500 // thousands of R$
+	tests := []struct {
+		unit Unit
+		want float32
+	}{
+		{UnitThousand, 1500},
+		{UnitReal, 1500000},
+		{UnitMillion, 1.5},
+	}
+	for _, tt := range tests {
+		if got := v * tt.unit.factor(); math.Abs(float64(got-tt.want)) > 1e-3 {
+			t.Errorf("%v: got %v, want %v", tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestDeflators(t *testing.T) {
+	infl := parsers.Inflation{2021: 0.10, 2022: 0.05}
+
+	d, err := deflators(nil, 2022, 2020, 2022)
+	if err != nil || d != nil {
+		t.Fatalf("nominal values: got %v, %v", d, err)
+	}
+	if got := unitLabel(UnitMillion, d, 2022); got != "R$ milhões" {
+		t.Errorf("unitLabel = %q", got)
+	}
+
+	d, err = deflators(infl, 2022, 2020, 2022)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]float32{2020: 1.155, 2021: 1.05, 2022: 1}
+	for y, w := range want {
+		if math.Abs(float64(d[y]-w)) > 1e-6 {
+			t.Errorf("deflator %d = %v, want %v", y, d[y], w)
+		}
+	}
+	if got := unitLabel(UnitThousand, d, 2022); got != "R$ mil de 2022" {
+		t.Errorf("unitLabel = %q", got)
+	}
+
+	if _, err := deflators(infl, 2022, 2019, 2022); err == nil {
+		t.Error("expected error for year without IPCA")
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	r := Report{unit: UnitMillion, deflators: map[int]float32{2020: 1.1}}
+
+	tests := []struct {
+		m    metric
+		want float32
+	}{
+		{metric{"Receita Líquida", 2000, NUMBER, grpAccts}, 2.2},
+		{metric{"LPA", 2, INDEX, grpAccts}, 2.2},
+		{metric{"VPA", 10, INDEX, grpAccts}, 11},
+		{metric{"P/L", 5, INDEX, grpAccts}, 5},
+		{metric{"ROE", 0.1, PERCENT, grpAccts}, 0.1},
+	}
+	for _, tt := range tests {
+		if got := r.display(tt.m, 2020); math.Abs(float64(got-tt.want)) > 1e-5 {
+			t.Errorf("display(%s) = %v, want %v", tt.m.descr, got, tt.want)
+		}
+		if got := r.display(tt.m, 2021); tt.m.format != NUMBER && got != tt.m.val {
+			t.Errorf("display(%s) without deflator = %v, want %v", tt.m.descr, got, tt.m.val)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dude333/rapina"
+	p "github.com/dude333/rapina/parsers"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Years of dividends averaged on the Bazin price
+const dpsYears = 3
+
+// ValuationParms contains the parameters of the discounted cash flow (DCF)
+// and of the Bazin price.
+type ValuationParms struct {
+	Growth         float64 // free cash flow growth during the projection
+	Discount       float64 // discount rate
+	TerminalGrowth float64 // perpetual growth after the projection
+	Years          int     // projection years
+	BazinYield     float64 // minimum dividend yield
+}
+
+// DefaultValuationParms returns the default valuation parameters.
+func DefaultValuationParms() ValuationParms {
+	return ValuationParms{
+		Growth:         0.05,
+		Discount:       0.12,
+		TerminalGrowth: 0.03,
+		Years:          10,
+		BazinYield:     0.06,
+	}
+}
+
+// Offsets applied to the parameters on the sensitivity tables
+var sensitivityOffsets = []float64{-0.02, -0.01, 0, 0.01, 0.02}
+
+// Valuation contains the intrinsic value per share of a company by the DCF,
+// Graham number and Bazin price, and the data used to calculate them.
+// Monetary values are in thousands of R$, except for the values per share.
+type Valuation struct {
+	Company   string
+	Code      string // stock code
+	Year      int    // year of the financial statements used
+	Parms     ValuationParms
+	FCF       float64 // free cash flow (FCO + FCI)
+	NetDebt   float64
+	Shares    float64
+	EPS       float64 // LPA
+	BVPS      float64 // VPA
+	DPS       float64 // average dividends per share of the last 3 years (NaN if not available)
+	Quote     float64 // latest quote (0 if not found)
+	QuoteDate string
+
+	DCF    float64 // NaN if not available
+	Graham float64 // NaN if not available
+	Bazin  float64 // NaN if not available
+
+	// Sensitivity of the DCF price to the discount rate (rows) and to the
+	// growth (columns) and terminal growth (columns) rates
+	Discounts       []float64
+	Growths         []float64
+	TerminalGrowths []float64
+	ByGrowth        [][]float64
+	ByTerminal      [][]float64
+}
+
+// Upside returns the upside of 'price' versus the latest quote, or NaN if
+// not available.
+func (v Valuation) Upside(price float64) float64 {
+	if v.Quote <= 0 || math.IsNaN(price) {
+		return math.NaN()
+	}
+	return price/v.Quote - 1
+}
+
+// Valuation calculates the intrinsic value of the company shares based on
+// its last financial statements.
+func (r *Report) Valuation(company, spcfctnCd string, parms ValuationParms) (*Valuation, error) {
+	if err := r.setCompanyAndTicker(company, spcfctnCd); err != nil {
+		return nil, fmt.Errorf("empresa '%s' não encontrada no banco de dados", company)
+	}
+
+	year, _, err := r.lastYear(r.cid)
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.accountsValues(year)
+	if err != nil {
+		return nil, err
+	}
+	if values[p.Shares] == 0 {
+		return nil, fmt.Errorf("número de ações de %s não encontrado (rode o 'update')", r.company)
+	}
+
+	// Average dividends of the last years
+	years := make([]map[uint32]float32, 0, dpsYears)
+	for y := year - dpsYears + 1; y <= year; y++ {
+		v := values
+		if y < year {
+			v = make(map[uint32]float32)
+			if err := r.dfp(r.cid, y, v); err != nil {
+				return nil, err
+			}
+		}
+		years = append(years, v)
+	}
+	dividends := avgDividends(years, dpsYears)
+
+	val := &Valuation{
+		Company: r.company,
+		Code:    r.code,
+		Year:    year,
+		Parms:   parms,
+		FCF:     float64(values[p.FCO] + values[p.FCI]),
+		NetDebt: float64(values[p.DividaCirc] + values[p.DividaNCirc] - values[p.Caixa] - values[p.AplicFinanceiras]),
+		Shares:  float64(values[p.Shares]),
+	}
+	scale := float64(values[p.Escala])
+	val.EPS = float64(values[p.LucLiq]) * scale / val.Shares
+	val.BVPS = float64(values[p.Equity]) * scale / val.Shares
+	val.DPS = dividends * scale / val.Shares
+
+	val.Quote, val.QuoteDate = r.latestQuote()
+
+	val.calculate(scale)
+
+	return val, nil
+}
+
+// avgDividends returns the average dividends (including JCP) of the years
+// with data, or NaN if fewer than 'min' years have data.
+func avgDividends(years []map[uint32]float32, min int) float64 {
+	var total float64
+	var n int
+	for _, v := range years {
+		if sum(v) == 0 {
+			continue
+		}
+		total += float64(v[p.Dividendos] + v[p.JurosCapProp])
+		n++
+	}
+	if n == 0 || n < min {
+		return math.NaN()
+	}
+	return total / float64(n)
+}
+
+// latestQuote returns the quote of the last business days.
+func (r Report) latestQuote() (float64, string) {
+	if r.code == "" {
+		return 0, ""
+	}
+	for n := 0; n <= 5; n++ {
+		date := rapina.LastBusinessDay(n)
+		if q, err := r.fetchStock.Quote(r.code, date); err == nil {
+			return q, date
+		}
+	}
+	return 0, ""
+}
+
+// calculate sets the prices per share and the sensitivity tables. 'scale'
+// converts the monetary values to R$.
+func (v *Valuation) calculate(scale float64) {
+	perShare := func(parms ValuationParms) float64 {
+		equity, err := dcf(v.FCF, v.NetDebt, parms)
+		if err != nil {
+			return math.NaN()
+		}
+		return equity * scale / v.Shares
+	}
+
+	v.DCF = perShare(v.Parms)
+	v.Graham = grahamNumber(v.EPS, v.BVPS)
+	v.Bazin = math.NaN()
+	if v.DPS > 0 && v.Parms.BazinYield > 0 {
+		v.Bazin = v.DPS / v.Parms.BazinYield
+	}
+
+	v.Discounts = offsets(v.Parms.Discount)
+	v.Growths = offsets(v.Parms.Growth)
+	v.TerminalGrowths = offsets(v.Parms.TerminalGrowth)
+	v.ByGrowth = make([][]float64, len(v.Discounts))
+	v.ByTerminal = make([][]float64, len(v.Discounts))
+	for i, d := range v.Discounts {
+		for _, g := range v.Growths {
+			parms := v.Parms
+			parms.Discount, parms.Growth = d, g
+			v.ByGrowth[i] = append(v.ByGrowth[i], perShare(parms))
+		}
+		for _, tg := range v.TerminalGrowths {
+			parms := v.Parms
+			parms.Discount, parms.TerminalGrowth = d, tg
+			v.ByTerminal[i] = append(v.ByTerminal[i], perShare(parms))
+		}
+	}
+}
+
+// offsets returns 'rate' added to the sensitivity offsets.
+func offsets(rate float64) []float64 {
+	list := make([]float64, len(sensitivityOffsets))
+	for i, o := range sensitivityOffsets {
+		list[i] = rate + o
+	}
+	return list
+}
+
+// dcf returns the equity value: the present value of the free cash flow
+// 'fcf' projected with the growth rate for the projection years, plus the
+// terminal value (Gordon growth model), minus the net debt.
+func dcf(fcf, netDebt float64, parms ValuationParms) (float64, error) {
+	d, g, tg := parms.Discount, parms.Growth, parms.TerminalGrowth
+	if parms.Years <= 0 {
+		return 0, errors.New("número de anos da projeção inválido")
+	}
+	if d <= tg {
+		return 0, errors.New("taxa de desconto deve ser maior que o crescimento na perpetuidade")
+	}
+
+	var pv float64
+	cf := fcf
+	for t := 1; t <= parms.Years; t++ {
+		cf *= 1 + g
+		pv += cf / math.Pow(1+d, float64(t))
+	}
+	terminal := cf * (1 + tg) / (d - tg)
+	pv += terminal / math.Pow(1+d, float64(parms.Years))
+
+	return pv - netDebt, nil
+}
+
+// grahamNumber returns sqrt(22.5 * EPS * BVPS), or NaN if EPS or BVPS are
+// not positive.
+func grahamNumber(eps, bvps float64) float64 {
+	if eps <= 0 || bvps <= 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(22.5 * eps * bvps)
+}
+
+// PrintValuation prints the valuation on the terminal.
+func PrintValuation(v *Valuation) {
+	fmt.Print(printValuation(v))
+}
+
+func printValuation(v *Valuation) *strings.Builder {
+	buf := &strings.Builder{}
+	pt := message.NewPrinter(language.BrazilianPortuguese)
+
+	pt.Fprintln(buf, line)
+	pt.Fprintf(buf, "VALUATION: %s %s [%s]\n", v.Company, v.Code, strconv.Itoa(v.Year))
+	pt.Fprintln(buf, line)
+	pt.Fprintf(buf, "  FCL (FCO+FCI, R$ mil)             %18.0f\n", v.FCF)
+	pt.Fprintf(buf, "  Dívida Líquida (R$ mil)           %18.0f\n", v.NetDebt)
+	pt.Fprintf(buf, "  Ações                             %18.0f\n", v.Shares)
+	pt.Fprintf(buf, "  LPA                               %18.2f\n", v.EPS)
+	pt.Fprintf(buf, "  VPA                               %18.2f\n", v.BVPS)
+	if math.IsNaN(v.DPS) {
+		pt.Fprintf(buf, "  Proventos/ação (média 3 anos)     %18s\n", "-")
+	} else {
+		pt.Fprintf(buf, "  Proventos/ação (média 3 anos)     %18.2f\n", v.DPS)
+	}
+	if v.Quote > 0 {
+		pt.Fprintf(buf, "  Cotação (%s)              %18.2f\n", v.QuoteDate, v.Quote)
+	} else {
+		pt.Fprintf(buf, "  Cotação                           %18s\n", "-")
+	}
+	pt.Fprintln(buf)
+	pt.Fprintf(buf, "  Crescimento %.1f%% em %d anos, desconto %.1f%%, perpetuidade %.1f%%, DY Bazin %.1f%%\n",
+		100*v.Parms.Growth, v.Parms.Years, 100*v.Parms.Discount, 100*v.Parms.TerminalGrowth, 100*v.Parms.BazinYield)
+	pt.Fprintln(buf)
+	pt.Fprintln(buf, "  MÉTODO          PREÇO JUSTO     UPSIDE")
+	pt.Fprintln(buf, "  ----------   --------------   --------")
+	for _, m := range []struct {
+		name  string
+		price float64
+	}{{"DCF", v.DCF}, {"Graham", v.Graham}, {"Bazin", v.Bazin}} {
+		pt.Fprintf(buf, "  %-10s   %s   %s\n", m.name, fmtPrice(pt, m.price), fmtChange(pt, v.Upside(m.price)))
+	}
+
+	pt.Fprintln(buf)
+	printSensitivity(buf, pt, "DESCONTO x CRESCIMENTO", v.Discounts, v.Growths, v.ByGrowth)
+	pt.Fprintln(buf)
+	printSensitivity(buf, pt, "DESCONTO x PERPETUIDADE", v.Discounts, v.TerminalGrowths, v.ByTerminal)
+	pt.Fprintln(buf, line)
+
+	return buf
+}
+
+// printSensitivity prints the DCF prices for each pair of rates.
+func printSensitivity(buf *strings.Builder, pt *message.Printer, title string, rows, cols []float64, prices [][]float64) {
+	pt.Fprintf(buf, "  %s\n", title)
+	pt.Fprintf(buf, "  %8s", "")
+	for _, c := range cols {
+		pt.Fprintf(buf, " %14.1f%%", 100*c)
+	}
+	pt.Fprintln(buf)
+	for i, r := range rows {
+		pt.Fprintf(buf, "  %7.1f%%", 100*r)
+		for j := range cols {
+			pt.Fprintf(buf, " %s", fmtPrice(pt, prices[i][j]))
+		}
+		pt.Fprintln(buf)
+	}
+}
+
+// fmtPrice formats the price, or "-" if NaN.
+func fmtPrice(pt *message.Printer, price float64) string {
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return fmt.Sprintf("%15s", "-")
+	}
+	return pt.Sprintf("%15.2f", price)
+}
+
+// valuationSheet prints the valuation on the sheet.
+func valuationSheet(sheet *Sheet, v *Valuation) {
+	row := 1
+	sheet.print(axis(0, row), &[]string{"VALUATION: " + v.Company + " " + v.Code + " [" + strconv.Itoa(v.Year) + "]"}, LEFT, true)
+	row += 2
+
+	inputs := []struct {
+		descr  string
+		val    float64
+		format int
+	}{
+		{"FCL (FCO+FCI, R$ mil)", v.FCF, NUMBER},
+		{"Dívida Líquida (R$ mil)", v.NetDebt, NUMBER},
+		{"Ações", v.Shares, NUMBER},
+		{"LPA", v.EPS, INDEX},
+		{"VPA", v.BVPS, INDEX},
+		{"Proventos/ação (média 3 anos)", v.DPS, INDEX},
+		{"Cotação " + v.QuoteDate, v.Quote, INDEX},
+		{"", 0, EMPTY},
+		{"Crescimento", v.Parms.Growth, PERCENT},
+		{"Anos", float64(v.Parms.Years), GENERAL},
+		{"Desconto", v.Parms.Discount, PERCENT},
+		{"Perpetuidade", v.Parms.TerminalGrowth, PERCENT},
+		{"DY Bazin", v.Parms.BazinYield, PERCENT},
+	}
+	for _, in := range inputs {
+		if in.format != EMPTY {
+			sheet.print(axis(0, row), &[]string{in.descr}, RIGHT, false)
+			if !math.IsNaN(in.val) {
+				_ = sheet.printValue(axis(1, row), float32(in.val), in.format, false)
+			}
+		}
+		row++
+	}
+	row++
+
+	_ = sheet.printTitle(axis(0, row), "Método")
+	_ = sheet.printTitle(axis(1, row), "Preço Justo")
+	_ = sheet.printTitle(axis(2, row), "Upside")
+	row++
+	for _, m := range []struct {
+		name  string
+		price float64
+	}{{"DCF", v.DCF}, {"Graham", v.Graham}, {"Bazin", v.Bazin}} {
+		sheet.print(axis(0, row), &[]string{m.name}, RIGHT, false)
+		if !math.IsNaN(m.price) {
+			_ = sheet.printValue(axis(1, row), float32(m.price), INDEX, false)
+		}
+		if up := v.Upside(m.price); !math.IsNaN(up) {
+			_ = sheet.printValue(axis(2, row), float32(up), PERCENT, false)
+		}
+		row++
+	}
+	row++
+
+	row = sensitivitySheet(sheet, row, "Desconto x Crescimento", v.Discounts, v.Growths, v.ByGrowth)
+	_ = sensitivitySheet(sheet, row+1, "Desconto x Perpetuidade", v.Discounts, v.TerminalGrowths, v.ByTerminal)
+
+	sheet.autoWidth()
+}
+
+// sensitivitySheet prints the DCF prices for each pair of rates, starting on
+// 'row'. Returns the next row.
+func sensitivitySheet(sheet *Sheet, row int, title string, rows, cols []float64, prices [][]float64) int {
+	_ = sheet.printTitle(axis(0, row), title)
+	for j, c := range cols {
+		_ = sheet.printValue(axis(1+j, row), float32(c), PERCENT, true)
+	}
+	row++
+	for i, r := range rows {
+		_ = sheet.printValue(axis(0, row), float32(r), PERCENT, true)
+		for j := range cols {
+			if price := prices[i][j]; !math.IsNaN(price) {
+				_ = sheet.printValue(axis(1+j, row), float32(price), INDEX, false)
+			}
+		}
+		row++
+	}
+	return row
+}
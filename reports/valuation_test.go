@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	p "github.com/dude333/rapina/parsers"
+)
+
+func TestDCF(t *testing.T) {
+	parms := ValuationParms{Growth: 0.1, Discount: 0.1, TerminalGrowth: 0, Years: 2}
+
+	// Growth equal to the discount rate: each projected year is worth 'fcf'
+	// and the terminal value is fcf*1.1^2/0.1 discounted by 1.1^2
+	got, err := dcf(100, 50, parms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 100.0 + 100 + 1000 - 50
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("dcf() = %v, want %v", got, want)
+	}
+
+	parms.TerminalGrowth = 0.1
+	if _, err := dcf(100, 50, parms); err == nil {
+		t.Error("expected error for terminal growth >= discount rate")
+	}
+	parms.TerminalGrowth, parms.Years = 0, 0
+	if _, err := dcf(100, 50, parms); err == nil {
+		t.Error("expected error for zero projection years")
+	}
+}
+
+func TestGrahamNumber(t *testing.T) {
+	if got := grahamNumber(2, 10); math.Abs(got-math.Sqrt(450)) > 1e-9 {
+		t.Errorf("grahamNumber(2, 10) = %v", got)
+	}
+	if got := grahamNumber(-2, 10); !math.IsNaN(got) {
+		t.Errorf("grahamNumber(-2, 10) = %v, want NaN", got)
+	}
+}
+
+func TestValuation_calculate(t *testing.T) {
+	v := &Valuation{
+		Parms:   ValuationParms{Growth: 0.1, Discount: 0.1, TerminalGrowth: 0, Years: 2, BazinYield: 0.06},
+		FCF:     100,
+		NetDebt: 50,
+		Shares:  1000,
+		EPS:     2,
+		BVPS:    10,
+		DPS:     0.9,
+		Quote:   10,
+	}
+	v.calculate(1000)
+
+	if math.Abs(v.DCF-1150) > 1e-6 {
+		t.Errorf("DCF = %v, want 1150", v.DCF)
+	}
+	if math.Abs(v.Bazin-15) > 1e-9 {
+		t.Errorf("Bazin = %v, want 15", v.Bazin)
+	}
+	if up := v.Upside(v.Bazin); math.Abs(up-0.5) > 1e-9 {
+		t.Errorf("Upside(Bazin) = %v, want 0.5", up)
+	}
+	if up := v.Upside(math.NaN()); !math.IsNaN(up) {
+		t.Errorf("Upside(NaN) = %v, want NaN", up)
+	}
+
+	n := len(sensitivityOffsets)
+	if len(v.ByGrowth) != n || len(v.ByGrowth[0]) != n || len(v.ByTerminal) != n {
+		t.Fatalf("unexpected sensitivity tables: %v %v", v.ByGrowth, v.ByTerminal)
+	}
+	if c := n / 2; v.ByGrowth[c][c] != v.DCF || v.ByTerminal[c][c] != v.DCF {
+		t.Errorf("center of sensitivity tables = %v, %v, want %v", v.ByGrowth[c][c], v.ByTerminal[c][c], v.DCF)
+	}
+	if v.ByGrowth[0][0] <= v.ByGrowth[n-1][0] || v.ByGrowth[0][0] >= v.ByGrowth[0][n-1] {
+		t.Errorf("price should decrease with the discount rate and increase with the growth: %v", v.ByGrowth)
+	}
+
+	out := printValuation(v).String()
+	for _, s := range []string{"DCF", "Graham", "Bazin", "DESCONTO x CRESCIMENTO", "1.150,00"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output does not contain %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestAvgDividends(t *testing.T) {
+	year := func(div, jcp float32) map[uint32]float32 {
+		return map[uint32]float32{p.LucLiq: 100, p.Dividendos: div, p.JurosCapProp: jcp}
+	}
+	empty := map[uint32]float32{}
+
+	if got := avgDividends([]map[uint32]float32{year(10, 5), year(20, 0), year(0, 0)}, 3); got != 35.0/3 {
+		t.Errorf("avgDividends() = %v, want %v", got, 35.0/3)
+	}
+	if got := avgDividends([]map[uint32]float32{empty, year(10, 5), year(20, 5)}, 2); got != 20 {
+		t.Errorf("avgDividends() with a missing year = %v, want 20", got)
+	}
+	if got := avgDividends([]map[uint32]float32{empty, year(10, 5), year(20, 5)}, 3); !math.IsNaN(got) {
+		t.Errorf("avgDividends() with fewer years = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/dude333/rapina"
+	"github.com/dude333/rapina/reports"
+)
+
+// accountSeriesHandler returns the time series of an account of a company
+// in JSON, e.g.:
+//
+//	/api/conta?empresa=WEG&conta=3.01&anos=2012-2023&unidade=milhoes
+func accountSeriesHandler(srv *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		company := r.FormValue("empresa")
+		account := r.FormValue("conta")
+		if company == "" || account == "" {
+			http.Error(w, "parâmetros 'empresa' e 'conta' são obrigatórios", http.StatusBadRequest)
+			return
+		}
+
+		begin, end, err := rapina.YearRange(r.FormValue("anos"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		unit, err := reports.ParseUnit(r.FormValue("unidade"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		series, err := srv.report.AccountSeries(company, account, begin, end, unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(series); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// sectorStatsHandler returns the median, quartiles and percentile rank of
+// the metrics of a company among the companies of its sector in JSON, e.g.:
+//
+//	/api/setor?empresa=WEG&unidade=milhoes
+func sectorStatsHandler(srv *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		company := r.FormValue("empresa")
+		if company == "" {
+			http.Error(w, "parâmetro 'empresa' é obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		unit, err := reports.ParseUnit(r.FormValue("unidade"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stats, err := srv.report.SectorStats(company, unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// formulaHandler returns, in JSON, the values of a custom metric (see the
+// config file) or formula of a company by year or, without 'empresa', the
+// companies ranked by it on a year (default: last year), e.g.:
+//
+//	/api/indicador?empresa=WEG&indicador=LucLiq/LucLiq[-1]-1
+//	/api/indicador?indicador=Margem%20EBIT&ano=2021
+func formulaHandler(srv *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formula := r.FormValue("indicador")
+		if formula == "" {
+			http.Error(w, "parâmetro 'indicador' é obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		unit, err := reports.ParseUnit(r.FormValue("unidade"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var res interface{}
+		if company := r.FormValue("empresa"); company != "" {
+			res, err = srv.report.FormulaSeries(company, formula, unit)
+		} else {
+			year := 0
+			if s := r.FormValue("ano"); s != "" {
+				if year, err = strconv.Atoi(s); err != nil {
+					http.Error(w, "ano inválido: "+s, http.StatusBadRequest)
+					return
+				}
+			}
+			res, err = srv.report.Screen(formula, year, unit)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// tearsheetHandler returns the one-page report of a company as HTML or, with
+// formato=pdf, as PDF, e.g.:
+//
+//	/relatorio?empresa=WEG&unidade=milhoes&formato=pdf
+func tearsheetHandler(srv *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		company := r.FormValue("empresa")
+		if company == "" {
+			http.Error(w, "parâmetro 'empresa' é obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		unit, err := reports.ParseUnit(r.FormValue("unidade"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := srv.report.Tearsheet(company, unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		if r.FormValue("formato") == "pdf" {
+			w.Header().Set("Content-Type", "application/pdf")
+			err = t.PDF(w)
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			err = t.HTML(w)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"fmt"
+	"html/template"
 	"math"
 	"net/url"
 	"strings"
 
 	"github.com/dude333/rapina/progress"
+	"github.com/dude333/rapina/reports"
 )
 
 // fiiDividendsPayload returns the data to be used in the FII template.
@@ -37,6 +40,8 @@ func fiiDividends(srv *Server, codes []string, n int) interface{} {
 		Name    string
 		Website string
 		Values  []value
+		NAV     []reports.NAV
+		Chart   template.HTML
 	}
 
 	var dataset []data
@@ -86,11 +91,19 @@ func fiiDividends(srv *Server, codes []string, n int) interface{} {
 			}
 		}
 
+		// NAV and P/VP from the monthly reports
+		navs, err := reports.NAVHistory(srv.fetchFII, srv.fetchStock, code, n)
+		if err != nil {
+			progress.ErrorMsg("%s: %v", code, err)
+		}
+
 		d := data{
 			Code:    code,
 			Name:    name,
 			Website: a,
 			Values:  values,
+			NAV:     navs,
+			Chart:   premiumChart(navs),
 		}
 
 		dataset = append(dataset, d)
@@ -99,6 +112,43 @@ func fiiDividends(srv *Server, codes []string, n int) interface{} {
 	return &dataset
 }
 
+// premiumChart returns an inline SVG bar chart with the premium (green) or
+// discount (red) to NAV, from the oldest to the latest month.
+func premiumChart(navs []reports.NAV) template.HTML {
+	const width, height, barWidth = 600, 120, 16
+
+	var max float64
+	for _, nav := range navs {
+		max = math.Max(max, math.Abs(nav.Premium))
+	}
+	if len(navs) == 0 || max == 0 {
+		return ""
+	}
+
+	step := float64(width) / float64(len(navs))
+	mid := float64(height) / 2
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" width="%d" height="%d">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="#999" />`, mid, width, mid)
+	for i := range navs {
+		nav := navs[len(navs)-1-i]
+		h := math.Abs(nav.Premium) / max * (mid - 4)
+		y, color := mid-h, "#28a745"
+		if nav.Premium < 0 {
+			y, color = mid, "#dc3545"
+		}
+		x := float64(i)*step + (step-barWidth)/2
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%d" height="%.1f" fill="%s">`,
+			x, y, barWidth, h, color)
+		fmt.Fprintf(&b, `<title>%s: %s%%</title></rect>`, nav.Date, ptFmtFloat(nav.Premium))
+	}
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
 // func financialsPayload(srv *Server, stockCode string) interface{} {
 // 	var payload struct {
 // 		Equity float32
@@ -91,6 +91,10 @@ func HTML(opts ...ServerOption) {
 		log.Fatal(err)
 	}
 
+	http.HandleFunc("/api/conta", accountSeriesHandler(srv))
+	http.HandleFunc("/api/setor", sectorStatsHandler(srv))
+	http.HandleFunc("/api/indicador", formulaHandler(srv))
+	http.HandleFunc("/relatorio", tearsheetHandler(srv))
 	http.HandleFunc("/", renderTemplate(srv))
 
 	log.Println("Listening on :3000...")
@@ -6,6 +6,7 @@ import "io"
 // retrieve stock data to/from a storage.
 type StockStorage interface {
 	Quote(code, date string) (float64, error)
+	Volumes(code, from string) (map[string]float64, error)
 	Code(companyName, stockType string) (string, error)
 	Save(stream io.Reader, code string) (int, error)
 }